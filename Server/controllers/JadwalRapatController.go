@@ -8,6 +8,7 @@ import (
 	"project-its/initializers"
 	"project-its/models"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -15,13 +16,20 @@ import (
 )
 
 // Create a new event
+// Event berulang dikembalikan per kejadian dalam rentang ?start=&end=
 func GetEventsRapat(c *gin.Context) {
+	dari, sampai, saring, err := rentangKalender(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	var events []models.JadwalRapat
 	if err := initializers.DB.Find(&events).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"rapat": events})
+	c.JSON(http.StatusOK, gin.H{"rapat": expandJadwalRapat(events, dari, sampai, saring)})
 }
 
 func CreateEventRapat(c *gin.Context) {
@@ -30,6 +38,11 @@ func CreateEventRapat(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	event.RRule = strings.TrimSpace(event.RRule)
+	if err := validasiRRule(event.RRule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	// Set notification menggunakan fungsi dari notificationController
 	loc, err := time.LoadLocation("Asia/Jakarta")
@@ -52,13 +65,14 @@ func CreateEventRapat(c *gin.Context) {
 		return
 	}
 
-	SetNotification(event.Title, startTime, "JadwalRapat") // Panggil fungsi SetNotification
-
 	if err := initializers.DB.Create(&event).Error; err != nil {
 		log.Printf("Error creating event: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+
+	SetNotificationEvent(event.Title, startTime, "JadwalRapat", sumberJadwalRapat, event.ID) // Panggil fungsi SetNotification
+	emitWebhook(eventJadwalRapatDibuat, event)
 	c.JSON(http.StatusOK, event)
 }
 
@@ -72,6 +86,10 @@ func DeleteEventRapat(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	initializers.DB.Where("sumber = ? AND event_id = ?", sumberJadwalRapat, id).Delete(&models.PengecualianJadwal{})
+	if err := batalkanPengingatEvent(initializers.DB, sumberJadwalRapat, id); err != nil {
+		log.Printf("Error cancelling reminders for jadwal rapat %s: %v", id, err)
+	}
 	c.Status(http.StatusNoContent)
 }
 
@@ -83,6 +101,16 @@ func ExportJadwalRapatToExcel(c *gin.Context) {
 		return
 	}
 
+	// Setiap kejadian event berulang ikut dicetak pada kalender tahunan
+	dari, sampai := rentangTahunExport(2024)
+	events_rapat = expandJadwalRapat(events_rapat, dari, sampai, false)
+
+	// Hari libur nasional dan cuti bersama diberi warna pada kalender
+	kalender, err := muatKalenderKerja(initializers.DB)
+	if err != nil {
+		log.Printf("Error loading hari libur for export: %v", err)
+	}
+
 	f := excelize.NewFile()
 	sheet := "Calendar 2024"
 	f.NewSheet(sheet)
@@ -96,7 +124,7 @@ func ExportJadwalRapatToExcel(c *gin.Context) {
 	rowOffset := 0
 	colOffset := 0
 	for i, month := range months {
-		setMonthDataRapat(f, sheet, month, rowOffset, colOffset, events_rapat)
+		setMonthDataRapat(f, sheet, month, rowOffset, colOffset, events_rapat, kalender)
 		colOffset += 9 // Sesuaikan offset untuk bulan berikutnya dalam baris yang sama
 		if (i+1)%3 == 0 {
 			rowOffset += 18 // Pindah ke baris berikutnya setiap 3 bulan
@@ -121,7 +149,7 @@ func ExportJadwalRapatToExcel(c *gin.Context) {
 	c.Writer.Write(buffer.Bytes())
 }
 
-func setMonthDataRapat(f *excelize.File, sheet, month string, rowOffset, colOffset int, events_rapat []models.JadwalRapat) {
+func setMonthDataRapat(f *excelize.File, sheet, month string, rowOffset, colOffset int, events_rapat []models.JadwalRapat, kalender *kalenderKerja) {
 	var (
 		monthStyle, titleStyle, dataStyle, blankStyle,
 		grayBlankStyle, grayDataStyle int
@@ -300,6 +328,7 @@ func setMonthDataRapat(f *excelize.File, sheet, month string, rowOffset, colOffs
 		fmt.Println(err)
 		return
 	}
+	tandaiHariLiburBulan(f, sheet, monthTime, rowOffset, colOffset, kalender)
 	// hide gridlines for the worksheet
 	disable := false
 	if err := f.SetSheetView(sheet, 0, &excelize.ViewOptions{
@@ -1,16 +1,38 @@
 package controllers
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"project-its/initializers"
 	"project-its/models"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// role penyetuju, penerima notifikasi pengajuan yang belum punya penyetuju tertentu
+const roleAdmin = "admin"
+
 func SetNotification(title string, startTime time.Time, category string) {
+	SetNotificationUntuk(title, startTime, category, nil)
+}
+
+// SetNotificationUntuk sama dengan SetNotification tapi hanya dikirim ke satu pengguna; userID nil berarti semua pengguna
+func SetNotificationUntuk(title string, startTime time.Time, category string, userID *uint) {
+	setNotification(models.Notification{Title: title, Start: startTime, Category: category, UserID: userID})
+}
+
+// SetNotificationEvent sama dengan SetNotification tapi notifikasi ditautkan ke event asalnya,
+// sehingga pengingatnya dapat dibatalkan lewat batalkanPengingatEvent saat event dihapus atau diubah
+func SetNotificationEvent(title string, startTime time.Time, category, sumber string, sumberID uint) {
+	setNotification(models.Notification{Title: title, Start: startTime, Category: category, Sumber: &sumber, SumberID: &sumberID})
+}
+
+func setNotification(notification models.Notification) {
 	// Set lokasi ke WIB
 	loc, err := time.LoadLocation("Asia/Jakarta")
 	if err != nil {
@@ -19,54 +41,215 @@ func SetNotification(title string, startTime time.Time, category string) {
 	}
 
 	// Parse waktu mulai event ke WIB
-	startTime, err = time.ParseInLocation(time.RFC3339, startTime.Format(time.RFC3339), loc) // Ubah ini
+	startTime, err := time.ParseInLocation(time.RFC3339, notification.Start.Format(time.RFC3339), loc) // Ubah ini
 	if err != nil {
 		log.Printf("Error parsing start time: %v", err)
 		return
 	}
 	log.Printf("Parsed start time in WIB: %v", startTime)
 
-	// Tentukan waktu notifikasi 24 jam sebelum event
-	notificationTime24 := startTime.Add(-24 * time.Hour)
-	log.Printf("24-hour notification scheduled for %s", notificationTime24)
+	notification.Start = startTime
+	if err := initializers.DB.Create(&notification).Error; err != nil {
+		log.Printf("Error creating notification: %v", err)
+		return
+	}
+	log.Printf("Notification created with category: %s, title: %s", notification.Category, notification.Title) // Tambahkan log ini
 
-	// Tentukan waktu notifikasi 1 jam sebelum event
-	notificationTime0 := startTime.Add(time.Hour)
-	log.Printf("1-hour notification  for %s", notificationTime0)
+	// Pengingat 24 jam dan 1 jam sebelum event disimpan ke database, dikirim oleh worker pengingat
+	jadwalkanPengingat(notification)
+}
 
-	// Simulasi pengiriman notifikasi 24 jam sebelum event
-	go func() {
-		time.Sleep(time.Until(notificationTime24))
-		log.Printf("24-hour notification sent for event %s at %s", title, notificationTime24) // Ubah ini
-	}()
+// createNotification mencatat notifikasi yang langsung berlaku tanpa menjadwalkan pengingat
+func createNotification(title string, start time.Time, category string) {
+	createNotificationUntuk(title, start, category, nil)
+}
 
-	// Simulasi pengiriman notifikasi 1 jam sebelum event
-	go func() {
-		time.Sleep(time.Until(notificationTime0))
-		log.Printf("1-hour notification sent for event %s at %s", title, notificationTime0) // Ubah ini
-	}()
+// createNotificationUntuk seperti createNotification tapi hanya untuk satu pengguna; userID nil berarti semua pengguna
+func createNotificationUntuk(title string, start time.Time, category string, userID *uint) {
+	kirimNotifikasi(&models.Notification{
+		Title:    title,
+		Start:    start,
+		Category: category,
+		UserID:   userID,
+	})
+}
 
-	notification := models.Notification{
+// createNotificationRole seperti createNotification tapi hanya untuk pengguna dengan role tertentu, mis. admin yang menyetujui
+func createNotificationRole(title string, start time.Time, category, role string) {
+	kirimNotifikasi(&models.Notification{
 		Title:    title,
-		Start:    startTime,
+		Start:    start,
 		Category: category,
+		Role:     &role,
+	})
+}
+
+// createNotificationUnik seperti createNotificationUntuk untuk pemeriksaan berkala; kunci yang sama hanya dibuat sekali
+func createNotificationUnik(title string, start time.Time, category string, userID *uint, kunci string) {
+	kirimNotifikasi(&models.Notification{
+		Title:     title,
+		Start:     start,
+		Category:  category,
+		UserID:    userID,
+		KunciUnik: &kunci,
+	})
+}
+
+// userIDUsername mencari id pengguna dari username untuk data lama yang hanya menyimpan username; nil bila tidak ditemukan
+func userIDUsername(username string) *uint {
+	var user models.User
+	if username == "" || initializers.DB.Select("id").Where("username = ?", username).Take(&user).Error != nil {
+		return nil
 	}
-	if err := initializers.DB.Create(&notification).Error; err != nil {
-		log.Printf("Error creating notification: %v", err)
+	return &user.ID
+}
+
+// kirimNotifikasi menyimpan notifikasi ke inbox lalu mengantrekan email dan webhook pribadi sesuai preferensi penerima
+// Notifikasi dengan KunciUnik yang sudah pernah dibuat dilewati tanpa mengantrekan ulang.
+func kirimNotifikasi(notification *models.Notification) {
+	res := initializers.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(notification)
+	if res.Error != nil {
+		log.Printf("Error creating notification: %v", res.Error)
+		return
+	}
+	if res.RowsAffected == 0 {
+		return
 	}
-	log.Printf("Notification created with category: %s, title: %s", category, title) // Tambahkan log ini
+	log.Printf("Notification created with category: %s, title: %s", notification.Category, notification.Title)
+	antreKanalNotifikasi(*notification)
+}
+
+// antreKanalNotifikasi mengantrekan email dan webhook pribadi untuk notifikasi yang sudah tersimpan
+func antreKanalNotifikasi(notification models.Notification) {
+	if err := antreEmailNotifikasi(initializers.DB, notification, nil); err != nil {
+		log.Printf("Error queueing notification email: %v", err)
+	}
+	if err := antreWebhookNotifikasi(initializers.DB, notification, nil); err != nil {
+		log.Printf("Error queueing notification webhook: %v", err)
+	}
+}
 
+// notifikasiInbox adalah notifikasi beserta status baca milik pengguna yang sedang login
+type notifikasiInbox struct {
+	models.Notification
+	Dibaca     bool       `json:"dibaca"`
+	DibacaPada *time.Time `json:"dibaca_pada"`
 }
 
+// queryInbox membatasi notifikasi yang ditujukan ke pengguna: ke dirinya, ke rolenya, atau ke semua pengguna.
+// Notifikasi yang sudah di-dismiss dan kategori yang in-app-nya ditahan atau senyap tidak ikut.
+func queryInbox(tx *gorm.DB, c *gin.Context) *gorm.DB {
+	userID := c.MustGet("userID").(uint)
+	role, _ := c.Get("role")
+	roleStr, _ := role.(string)
+
+	query := tx.Table("notifications").
+		Joins("LEFT JOIN status_notifikasis sn ON sn.notification_id = notifications.id AND sn.user_id = ?", userID).
+		Where("(notifications.user_id IS NULL AND notifications.role IS NULL) OR notifications.user_id = ? OR notifications.role = ?", userID, roleStr).
+		Where("sn.dihapus_pada IS NULL")
+	return filterInboxPreferensi(query, muatPreferensiUser(tx, userID))
+}
+
+// GetNotifications menampilkan inbox pengguna, terbaru dahulu.
+// ?status=unread|read, ?category=, paginasi dengan ?sebelum=<created_at item terakhir>&limit= (default 50, maks 200)
 func GetNotifications(c *gin.Context) {
-	var notifications []models.Notification
-	if err := initializers.DB.Find(&notifications).Error; err != nil {
+	query := queryInbox(initializers.DB, c)
+	switch c.Query("status") {
+	case "unread":
+		query = query.Where("sn.dibaca_pada IS NULL")
+	case "read":
+		query = query.Where("sn.dibaca_pada IS NOT NULL")
+	}
+	if category := c.Query("category"); category != "" {
+		query = query.Where("notifications.category = ?", category)
+	}
+	if sebelum := c.Query("sebelum"); sebelum != "" {
+		t, err := time.Parse(time.RFC3339Nano, sebelum)
+		if err != nil {
+			t, err = parseDate(sebelum)
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Format sebelum tidak valid, gunakan RFC3339 atau YYYY-MM-DD"})
+			return
+		}
+		query = query.Where("COALESCE(notifications.created_at, notifications.start) < ?", t)
+	}
+	limit := 50
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 {
+		limit = l
+	}
+	if limit > 200 {
+		limit = 200
+	}
+
+	var rows []struct {
+		models.Notification
+		DibacaPada *time.Time
+	}
+	if err := query.Select("notifications.*, sn.dibaca_pada").
+		Order("COALESCE(notifications.created_at, notifications.start) DESC, notifications.id DESC").
+		Limit(limit).Scan(&rows).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+
+	notifications := make([]notifikasiInbox, 0, len(rows))
+	for _, r := range rows {
+		notifications = append(notifications, notifikasiInbox{
+			Notification: r.Notification,
+			Dibaca:       r.DibacaPada != nil,
+			DibacaPada:   r.DibacaPada,
+		})
+	}
 	c.JSON(http.StatusOK, notifications)
 }
 
+// GetNotificationsUnreadCount menghitung notifikasi yang belum dibaca pengguna
+func GetNotificationsUnreadCount(c *gin.Context) {
+	var unread int64
+	if err := queryInbox(initializers.DB, c).Where("sn.dibaca_pada IS NULL").Count(&unread).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"unread": unread})
+}
+
+func MarkNotificationRead(c *gin.Context) {
+	now := time.Now()
+	tandaiNotifikasi(c, "dibaca_pada", &now)
+}
+
+func MarkNotificationUnread(c *gin.Context) {
+	tandaiNotifikasi(c, "dibaca_pada", nil)
+}
+
+// MarkAllNotificationsRead menandai semua notifikasi di inbox pengguna sebagai sudah dibaca
+func MarkAllNotificationsRead(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+	now := time.Now()
+
+	var ids []uint
+	if err := queryInbox(initializers.DB, c).Where("sn.dibaca_pada IS NULL").Pluck("notifications.id", &ids).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(ids) > 0 {
+		status := make([]models.StatusNotifikasi, 0, len(ids))
+		for _, id := range ids {
+			status = append(status, models.StatusNotifikasi{NotificationID: id, UserID: userID, DibacaPada: &now})
+		}
+		if err := initializers.DB.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "notification_id"}, {Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"dibaca_pada"}),
+		}).CreateInBatches(&status, 500).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"ditandai": len(ids)})
+}
+
+// DeleteNotification menyembunyikan notifikasi dari inbox pengguna; notifikasi tetap ada untuk penerima lain
 func DeleteNotification(c *gin.Context) {
 	id := c.Param("id")
 	log.Printf("ID yang diterima untuk dihapus: %s", id) // Tambahkan log ini
@@ -74,12 +257,46 @@ func DeleteNotification(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "ID harus disertakan"})
 		return
 	}
+	now := time.Now()
+	tandaiNotifikasi(c, "dihapus_pada", &now)
+}
+
+// tandaiNotifikasi mengisi satu kolom status notifikasi milik pengguna yang sedang login
+func tandaiNotifikasi(c *gin.Context, kolom string, nilai *time.Time) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID tidak valid"})
+		return
+	}
+	userID := c.MustGet("userID").(uint)
 
-	// Menghapus notifikasi berdasarkan ID
-	if err := initializers.DB.Where("id = ?", id).Delete(&models.Notification{}).Error; err != nil {
+	var notification models.Notification
+	if err := queryInbox(initializers.DB, c).Where("notifications.id = ?", id).Select("notifications.*").Take(&notification).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Notifikasi tidak ditemukan"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.Status(http.StatusNoContent) // Mengembalikan status 204 No Content
+	status := models.StatusNotifikasi{NotificationID: notification.ID, UserID: userID}
+	if kolom == "dihapus_pada" {
+		status.DihapusPada = nilai
+	} else {
+		status.DibacaPada = nilai
+	}
+	if err := initializers.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "notification_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{kolom}),
+	}).Create(&status).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if kolom == "dihapus_pada" {
+		c.Status(http.StatusNoContent) // Mengembalikan status 204 No Content
+		return
+	}
+	c.JSON(http.StatusOK, notifikasiInbox{Notification: notification, Dibaca: nilai != nil, DibacaPada: nilai})
 }
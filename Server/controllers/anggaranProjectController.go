@@ -0,0 +1,526 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"project-its/initializers"
+	"project-its/models"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+)
+
+type AnggaranProjectRequest struct {
+	SumberPendanaan *string        `json:"sumber_pendanaan"`
+	Tahun           *int           `json:"tahun"`
+	Nilai           *models.Amount `json:"nilai"`
+	MataUang        *string        `json:"mata_uang"`
+	Keterangan      *string        `json:"keterangan"`
+}
+
+type RealisasiProjectRequest struct {
+	AnggaranID *uint          `json:"anggaran_id"`
+	Tanggal    *string        `json:"tanggal"`
+	Nilai      *models.Amount `json:"nilai"`
+	MataUang   *string        `json:"mata_uang"`
+	NoDokumen  *string        `json:"no_dokumen"`
+	Keterangan *string        `json:"keterangan"`
+}
+
+// posAnggaran adalah satu baris anggaran beserta realisasinya, dipakai untuk detail maupun ringkasan
+type posAnggaran struct {
+	AnggaranID      *uint         `json:"anggaran_id"`
+	ProjectID       uint          `json:"project_id"`
+	SumberPendanaan string        `json:"sumber_pendanaan"`
+	Tahun           int           `json:"tahun"`
+	Divisi          string        `json:"divisi"`
+	MataUang        string        `json:"mata_uang"`
+	Anggaran        models.Amount `json:"anggaran"`
+	Realisasi       models.Amount `json:"realisasi"`
+	Sisa            models.Amount `json:"sisa"`
+}
+
+type ringkasanAnggaran struct {
+	Grup          string        `json:"grup"`
+	MataUang      string        `json:"mata_uang"`
+	Anggaran      models.Amount `json:"anggaran"`
+	Realisasi     models.Amount `json:"realisasi"`
+	Sisa          models.Amount `json:"sisa"`
+	JumlahProject int           `json:"jumlah_project"`
+}
+
+// ProjectAnggaranShow menampilkan pos anggaran, realisasi dan sisa satu project
+func ProjectAnggaranShow(c *gin.Context) {
+	var project models.Project
+	if err := initializers.DB.First(&project, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+
+	var anggaran []models.AnggaranProject
+	initializers.DB.Where("project_id = ?", project.ID).Order("tahun asc, id asc").Find(&anggaran)
+	var realisasi []models.RealisasiProject
+	initializers.DB.Where("project_id = ?", project.ID).Order("tanggal asc, id asc").Find(&realisasi)
+
+	pos := hitungPosAnggaran([]models.Project{project}, anggaran, realisasi)
+	c.JSON(http.StatusOK, gin.H{
+		"project":   &project,
+		"anggaran":  pos,
+		"realisasi": realisasi,
+		"total":     ringkasPosAnggaran(pos, func(p posAnggaran) string { return "Total" }),
+	})
+}
+
+func AnggaranProjectCreate(c *gin.Context) {
+	var project models.Project
+	if err := initializers.DB.First(&project, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+
+	var requestBody AnggaranProjectRequest
+	if err := c.BindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data: " + err.Error()})
+		return
+	}
+	if requestBody.Nilai == nil || *requestBody.Nilai < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nilai anggaran harus diisi dan tidak boleh negatif"})
+		return
+	}
+
+	anggaran := models.AnggaranProject{
+		ProjectID:       project.ID,
+		SumberPendanaan: derefString(requestBody.SumberPendanaan),
+		Nilai:           *requestBody.Nilai,
+		MataUang:        project.MataUang,
+		Keterangan:      requestBody.Keterangan,
+		CreateBy:        c.MustGet("username").(string),
+	}
+	if anggaran.SumberPendanaan == "" {
+		anggaran.SumberPendanaan = derefString(project.SumberPendanaan)
+	}
+	if requestBody.Tahun != nil {
+		anggaran.Tahun = *requestBody.Tahun
+	} else if project.Bulan != nil {
+		anggaran.Tahun = project.Bulan.Year()
+	}
+	if requestBody.MataUang != nil && *requestBody.MataUang != "" {
+		anggaran.MataUang = *requestBody.MataUang
+	}
+
+	if err := initializers.DB.Create(&anggaran).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan anggaran"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"anggaran": anggaran})
+}
+
+func AnggaranProjectUpdate(c *gin.Context) {
+	var anggaran models.AnggaranProject
+	if err := initializers.DB.First(&anggaran, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Anggaran tidak ditemukan"})
+		return
+	}
+
+	var requestBody AnggaranProjectRequest
+	if err := c.BindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data: " + err.Error()})
+		return
+	}
+
+	if requestBody.SumberPendanaan != nil {
+		anggaran.SumberPendanaan = *requestBody.SumberPendanaan
+	}
+	if requestBody.Tahun != nil {
+		anggaran.Tahun = *requestBody.Tahun
+	}
+	if requestBody.Nilai != nil {
+		if *requestBody.Nilai < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Nilai anggaran tidak boleh negatif"})
+			return
+		}
+		// Anggaran tidak boleh diturunkan di bawah yang sudah terealisasi
+		terpakai := totalRealisasiAnggaran(anggaran.ID, 0)
+		if *requestBody.Nilai < terpakai {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Nilai anggaran lebih kecil dari realisasi (%s)", terpakai)})
+			return
+		}
+		anggaran.Nilai = *requestBody.Nilai
+	}
+	if requestBody.MataUang != nil && *requestBody.MataUang != "" && *requestBody.MataUang != anggaran.MataUang {
+		// Realisasi dicatat dalam mata uang anggaran, jadi mata uang tidak bisa diganti setelah ada realisasi
+		var jumlah int64
+		initializers.DB.Model(&models.RealisasiProject{}).Where("anggaran_id = ?", anggaran.ID).Count(&jumlah)
+		if jumlah > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Mata uang tidak dapat diubah, anggaran sudah memiliki %d realisasi", jumlah)})
+			return
+		}
+		anggaran.MataUang = *requestBody.MataUang
+	}
+	if requestBody.Keterangan != nil {
+		anggaran.Keterangan = requestBody.Keterangan
+	}
+
+	if err := initializers.DB.Save(&anggaran).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan anggaran"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"anggaran": anggaran})
+}
+
+func AnggaranProjectDelete(c *gin.Context) {
+	var anggaran models.AnggaranProject
+	if err := initializers.DB.First(&anggaran, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Anggaran tidak ditemukan"})
+		return
+	}
+
+	var jumlah int64
+	initializers.DB.Model(&models.RealisasiProject{}).Where("anggaran_id = ?", anggaran.ID).Count(&jumlah)
+	if jumlah > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Anggaran sudah memiliki %d realisasi", jumlah)})
+		return
+	}
+
+	if err := initializers.DB.Delete(&anggaran).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghapus anggaran"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Anggaran deleted successfully"})
+}
+
+func RealisasiProjectCreate(c *gin.Context) {
+	var project models.Project
+	if err := initializers.DB.First(&project, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+
+	var requestBody RealisasiProjectRequest
+	if err := c.BindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data: " + err.Error()})
+		return
+	}
+
+	realisasi := models.RealisasiProject{
+		ProjectID: project.ID,
+		CreateBy:  c.MustGet("username").(string),
+	}
+	if msg := applyRealisasiRequest(&realisasi, project, requestBody); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	if err := initializers.DB.Create(&realisasi).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan realisasi"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"realisasi": &realisasi})
+}
+
+func RealisasiProjectUpdate(c *gin.Context) {
+	var realisasi models.RealisasiProject
+	if err := initializers.DB.First(&realisasi, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Realisasi tidak ditemukan"})
+		return
+	}
+
+	var requestBody RealisasiProjectRequest
+	if err := c.BindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data: " + err.Error()})
+		return
+	}
+
+	var project models.Project
+	initializers.DB.First(&project, realisasi.ProjectID)
+	if msg := applyRealisasiRequest(&realisasi, project, requestBody); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	if err := initializers.DB.Save(&realisasi).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan realisasi"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"realisasi": &realisasi})
+}
+
+func RealisasiProjectDelete(c *gin.Context) {
+	if err := initializers.DB.Delete(&models.RealisasiProject{}, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghapus realisasi"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Realisasi deleted successfully"})
+}
+
+// ProjectRingkasanAnggaran menjumlah anggaran, realisasi dan sisa per tahun, divisi atau sumber pendanaan
+func ProjectRingkasanAnggaran(c *gin.Context) {
+	group := c.DefaultQuery("group", "tahun")
+	var keyFunc func(p posAnggaran) string
+	switch group {
+	case "tahun":
+		keyFunc = func(p posAnggaran) string {
+			if p.Tahun == 0 {
+				return "Tanpa Tahun"
+			}
+			return strconv.Itoa(p.Tahun)
+		}
+	case "divisi":
+		keyFunc = func(p posAnggaran) string { return p.Divisi }
+	case "sumber":
+		keyFunc = func(p posAnggaran) string { return p.SumberPendanaan }
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "group harus tahun, divisi atau sumber"})
+		return
+	}
+
+	var projects []models.Project
+	initializers.DB.Find(&projects)
+	var anggaran []models.AnggaranProject
+	initializers.DB.Find(&anggaran)
+	var realisasi []models.RealisasiProject
+	initializers.DB.Find(&realisasi)
+
+	pos := hitungPosAnggaran(projects, anggaran, realisasi)
+	if tahun := c.Query("tahun"); tahun != "" {
+		filtered := []posAnggaran{}
+		for _, p := range pos {
+			if strconv.Itoa(p.Tahun) == tahun {
+				filtered = append(filtered, p)
+			}
+		}
+		pos = filtered
+	}
+
+	c.JSON(http.StatusOK, gin.H{"group": group, "ringkasan": ringkasPosAnggaran(pos, keyFunc)})
+}
+
+// hitungPosAnggaran memecah anggaran setiap project menjadi pos per sumber pendanaan.
+// Project tanpa baris anggaran memakai Project.Anggaran sebagai satu pos.
+func hitungPosAnggaran(projects []models.Project, anggaran []models.AnggaranProject, realisasi []models.RealisasiProject) []posAnggaran {
+	anggaranPerProject := make(map[uint][]models.AnggaranProject)
+	for _, a := range anggaran {
+		anggaranPerProject[a.ProjectID] = append(anggaranPerProject[a.ProjectID], a)
+	}
+
+	pos := []posAnggaran{}
+	posPerAnggaran := make(map[uint]int)
+	posUtama := make(map[uint]int) // pos untuk realisasi tanpa anggaran_id
+	for _, project := range projects {
+		divisi := derefString(project.DivInisiasi)
+		if divisi == "" {
+			divisi = "Tanpa Divisi"
+		}
+		tahunProject := 0
+		if project.Bulan != nil {
+			tahunProject = project.Bulan.Year()
+		}
+
+		for _, a := range anggaranPerProject[project.ID] {
+			id := a.ID
+			posPerAnggaran[a.ID] = len(pos)
+			pos = append(pos, posAnggaran{
+				AnggaranID:      &id,
+				ProjectID:       project.ID,
+				SumberPendanaan: sumberAtauDefault(a.SumberPendanaan),
+				Tahun:           a.Tahun,
+				Divisi:          divisi,
+				MataUang:        a.MataUang,
+				Anggaran:        a.Nilai,
+			})
+		}
+
+		utama := posAnggaran{
+			ProjectID:       project.ID,
+			SumberPendanaan: sumberAtauDefault(derefString(project.SumberPendanaan)),
+			Tahun:           tahunProject,
+			Divisi:          divisi,
+			MataUang:        project.MataUang,
+		}
+		if len(anggaranPerProject[project.ID]) == 0 && project.Anggaran != nil {
+			utama.Anggaran = *project.Anggaran
+		}
+		posUtama[project.ID] = len(pos)
+		pos = append(pos, utama)
+	}
+
+	for _, r := range realisasi {
+		idx, ok := -1, false
+		if r.AnggaranID != nil {
+			idx, ok = posPerAnggaran[*r.AnggaranID]
+		}
+		if !ok {
+			idx, ok = posUtama[r.ProjectID]
+		}
+		if ok {
+			pos[idx].Realisasi += r.Nilai
+		}
+	}
+
+	// Pos utama yang kosong (project sudah punya baris anggaran dan tanpa realisasi lepas) tidak ditampilkan
+	hasil := []posAnggaran{}
+	for _, p := range pos {
+		if p.AnggaranID == nil && p.Anggaran == 0 && p.Realisasi == 0 && len(anggaranPerProject[p.ProjectID]) > 0 {
+			continue
+		}
+		p.Sisa = p.Anggaran - p.Realisasi
+		hasil = append(hasil, p)
+	}
+	return hasil
+}
+
+func ringkasPosAnggaran(pos []posAnggaran, keyFunc func(p posAnggaran) string) []ringkasanAnggaran {
+	type kunci struct{ grup, mataUang string }
+	ringkasan := make(map[kunci]*ringkasanAnggaran)
+	projectPerGrup := make(map[kunci]map[uint]bool)
+	for _, p := range pos {
+		k := kunci{keyFunc(p), p.MataUang}
+		if ringkasan[k] == nil {
+			ringkasan[k] = &ringkasanAnggaran{Grup: k.grup, MataUang: k.mataUang}
+			projectPerGrup[k] = make(map[uint]bool)
+		}
+		ringkasan[k].Anggaran += p.Anggaran
+		ringkasan[k].Realisasi += p.Realisasi
+		ringkasan[k].Sisa += p.Sisa
+		projectPerGrup[k][p.ProjectID] = true
+	}
+
+	hasil := []ringkasanAnggaran{}
+	for k, r := range ringkasan {
+		r.JumlahProject = len(projectPerGrup[k])
+		hasil = append(hasil, *r)
+	}
+	sort.Slice(hasil, func(i, j int) bool {
+		if hasil[i].Grup != hasil[j].Grup {
+			return hasil[i].Grup < hasil[j].Grup
+		}
+		return hasil[i].MataUang < hasil[j].MataUang
+	})
+	return hasil
+}
+
+// applyRealisasiRequest mengisi realisasi dari request dan memastikan tidak melebihi sisa anggaran
+func applyRealisasiRequest(realisasi *models.RealisasiProject, project models.Project, requestBody RealisasiProjectRequest) string {
+	if requestBody.Nilai != nil {
+		realisasi.Nilai = *requestBody.Nilai
+	}
+	if realisasi.Nilai <= 0 {
+		return "Nilai realisasi harus lebih dari 0"
+	}
+	if requestBody.AnggaranID != nil {
+		realisasi.AnggaranID = requestBody.AnggaranID
+	}
+	if requestBody.Tanggal != nil && *requestBody.Tanggal != "" {
+		tanggal, err := time.Parse("2006-01-02", *requestBody.Tanggal)
+		if err != nil {
+			return "Invalid date format: " + err.Error()
+		}
+		realisasi.Tanggal = &tanggal
+	}
+	if requestBody.NoDokumen != nil {
+		realisasi.NoDokumen = requestBody.NoDokumen
+	}
+	if requestBody.Keterangan != nil {
+		realisasi.Keterangan = requestBody.Keterangan
+	}
+
+	var jumlahAnggaran int64
+	initializers.DB.Model(&models.AnggaranProject{}).Where("project_id = ?", project.ID).Count(&jumlahAnggaran)
+
+	mataUang, batas := project.MataUang, models.Amount(0)
+	var terpakai models.Amount
+	if realisasi.AnggaranID != nil {
+		var anggaran models.AnggaranProject
+		if err := initializers.DB.Where("id = ? AND project_id = ?", *realisasi.AnggaranID, project.ID).First(&anggaran).Error; err != nil {
+			return "Anggaran tidak ditemukan pada project ini"
+		}
+		mataUang, batas = anggaran.MataUang, anggaran.Nilai
+		terpakai = totalRealisasiAnggaran(anggaran.ID, realisasi.ID)
+	} else {
+		if jumlahAnggaran > 0 {
+			return "anggaran_id harus diisi karena project memiliki rincian anggaran"
+		}
+		if project.Anggaran != nil {
+			batas = *project.Anggaran
+		}
+		var rows []models.RealisasiProject
+		initializers.DB.Where("project_id = ? AND id <> ?", project.ID, realisasi.ID).Find(&rows)
+		for _, r := range rows {
+			terpakai += r.Nilai
+		}
+	}
+
+	if requestBody.MataUang != nil && *requestBody.MataUang != "" && *requestBody.MataUang != mataUang {
+		return fmt.Sprintf("Mata uang realisasi harus %s sesuai anggaran", mataUang)
+	}
+	realisasi.MataUang = mataUang
+
+	if terpakai+realisasi.Nilai > batas {
+		return fmt.Sprintf("Realisasi melebihi sisa anggaran (sisa %s %s)", mataUang, batas-terpakai)
+	}
+	return ""
+}
+
+// totalRealisasiAnggaran menjumlah realisasi satu pos anggaran, kecuali realisasi dengan id exceptID
+func totalRealisasiAnggaran(anggaranID uint, exceptID uint) models.Amount {
+	var rows []models.RealisasiProject
+	initializers.DB.Where("anggaran_id = ? AND id <> ?", anggaranID, exceptID).Find(&rows)
+	var total models.Amount
+	for _, r := range rows {
+		total += r.Nilai
+	}
+	return total
+}
+
+func sumberAtauDefault(sumber string) string {
+	if sumber == "" {
+		return "Tanpa Sumber"
+	}
+	return sumber
+}
+
+// amountCellValue menulis anggaran sebagai angka, sel dikosongkan jika nil
+func amountCellValue(a *models.Amount) interface{} {
+	if a == nil {
+		return nil
+	}
+	return a.Float64()
+}
+
+func newAnggaranStyle(f *excelize.File) (int, error) {
+	numFmt := "#,##0.00"
+	return f.NewStyle(&excelize.Style{
+		CustomNumFmt: &numFmt,
+		Border: []excelize.Border{
+			{Type: "left", Color: "000000", Style: 1},
+			{Type: "right", Color: "000000", Style: 1},
+			{Type: "top", Color: "000000", Style: 1},
+			{Type: "bottom", Color: "000000", Style: 1},
+		},
+	})
+}
+
+// writeTotalAnggaran menulis baris total anggaran per mata uang mulai dari baris startRow
+func writeTotalAnggaran(f *excelize.File, sheetName string, startRow int, totals map[string]models.Amount, styleLabel, styleAnggaran int) error {
+	mataUang := make([]string, 0, len(totals))
+	for k := range totals {
+		mataUang = append(mataUang, k)
+	}
+	sort.Strings(mataUang)
+
+	for i, mu := range mataUang {
+		row := startRow + i
+		f.SetCellValue(sheetName, fmt.Sprintf("F%d", row), "Total Anggaran "+mu)
+		f.SetCellValue(sheetName, fmt.Sprintf("G%d", row), totals[mu].Float64())
+		if err := f.SetCellStyle(sheetName, fmt.Sprintf("F%d", row), fmt.Sprintf("F%d", row), styleLabel); err != nil {
+			return err
+		}
+		if err := f.SetCellStyle(sheetName, fmt.Sprintf("G%d", row), fmt.Sprintf("G%d", row), styleAnggaran); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -15,6 +15,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
 )
 
 type arsipRequest struct {
@@ -27,6 +28,7 @@ type arsipRequest struct {
 	TanggalDokumen    *string `json:"tanggal_dokumen"`
 	TanggalPenyerahan *string `json:"tanggal_penyerahan"`
 	Keterangan        *string `json:"keterangan"`
+	BoxID             *uint   `json:"box_id"`
 	CreateBy          string  `json:"create_by"`
 }
 
@@ -195,8 +197,18 @@ func ArsipCreate(c *gin.Context) {
 		CreateBy:          requestBody.CreateBy,
 	}
 
-	if err := initializers.DB.Create(&arsip).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create arsip"})
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&arsip).Error; err != nil {
+			return err
+		}
+		// Masukkan langsung ke box jika box_id dikirim
+		if requestBody.BoxID != nil {
+			return pindahkanArsip(tx, &arsip, *requestBody.BoxID, nil, requestBody.CreateBy)
+		}
+		return nil
+	})
+	if err != nil {
+		respondPindahArsipError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"arsip": arsip})
@@ -248,7 +260,8 @@ func ArsipUpdate(c *gin.Context) {
 	if requestBody.Perihal != nil {
 		arsip.Perihal = requestBody.Perihal
 	}
-	if requestBody.NoBox != nil {
+	// No box teks bebas hanya untuk arsip lama yang belum masuk box; selain itu mengikuti box
+	if requestBody.NoBox != nil && arsip.BoxID == nil {
 		arsip.NoBox = requestBody.NoBox
 	}
 	if requestBody.Keterangan != nil {
@@ -258,8 +271,24 @@ func ArsipUpdate(c *gin.Context) {
 		arsip.CreateBy = requestBody.CreateBy
 	}
 
-	if err := initializers.DB.Save(&arsip).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update arsip"})
+	username := c.MustGet("username").(string)
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&arsip).Error; err != nil {
+			return err
+		}
+		// box_id 0 mengeluarkan arsip dari box, selain itu dipindahkan seperti ArsipPindah
+		switch {
+		case requestBody.BoxID == nil:
+			return nil
+		case *requestBody.BoxID == 0:
+			return keluarkanArsipDariBox(tx, &arsip, nil, username)
+		case arsip.BoxID == nil || *arsip.BoxID != *requestBody.BoxID:
+			return pindahkanArsip(tx, &arsip, *requestBody.BoxID, nil, username)
+		}
+		return nil
+	})
+	if err != nil {
+		respondPindahArsipError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"arsip": arsip})
@@ -315,26 +344,7 @@ func CreateExcelArsip(c *gin.Context) {
 		}
 	}
 
-	styleHeader, err := f.NewStyle(&excelize.Style{
-		Font: &excelize.Font{
-			Bold: true,
-		},
-		Fill: excelize.Fill{
-			Type:    "pattern",
-			Color:   []string{"#6EB6F8"},
-			Pattern: 1,
-		},
-		Alignment: &excelize.Alignment{
-			Horizontal: "center",
-			Vertical:   "center",
-		},
-		Border: []excelize.Border{
-			{Type: "left", Color: "000000", Style: 1},
-			{Type: "right", Color: "000000", Style: 1},
-			{Type: "top", Color: "000000", Style: 1},
-			{Type: "bottom", Color: "000000", Style: 1},
-		},
-	})
+	styleHeader, err := newArsipHeaderStyle(f)
 	if err != nil {
 		return
 	}
@@ -362,14 +372,7 @@ func CreateExcelArsip(c *gin.Context) {
 		f.SetCellValue(arsipSheetName, fmt.Sprintf("H%d", rowNum), arsip.TanggalPenyerahan.Format("2006-01-02"))
 	}
 
-	styleAll, err := f.NewStyle(&excelize.Style{
-		Border: []excelize.Border{
-			{Type: "left", Color: "000000", Style: 1},
-			{Type: "top", Color: "000000", Style: 1},
-			{Type: "right", Color: "000000", Style: 1},
-			{Type: "bottom", Color: "000000", Style: 1},
-		},
-	})
+	styleAll, err := newArsipDataStyle(f)
 	if err != nil {
 		return
 	}
@@ -394,6 +397,42 @@ func CreateExcelArsip(c *gin.Context) {
 	c.Writer.Write(buf.Bytes())
 }
 
+// newArsipHeaderStyle adalah style header yang dipakai semua export arsip
+func newArsipHeaderStyle(f *excelize.File) (int, error) {
+	return f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{
+			Bold: true,
+		},
+		Fill: excelize.Fill{
+			Type:    "pattern",
+			Color:   []string{"#6EB6F8"},
+			Pattern: 1,
+		},
+		Alignment: &excelize.Alignment{
+			Horizontal: "center",
+			Vertical:   "center",
+		},
+		Border: []excelize.Border{
+			{Type: "left", Color: "000000", Style: 1},
+			{Type: "right", Color: "000000", Style: 1},
+			{Type: "top", Color: "000000", Style: 1},
+			{Type: "bottom", Color: "000000", Style: 1},
+		},
+	})
+}
+
+// newArsipDataStyle adalah style border untuk baris data export arsip
+func newArsipDataStyle(f *excelize.File) (int, error) {
+	return f.NewStyle(&excelize.Style{
+		Border: []excelize.Border{
+			{Type: "left", Color: "000000", Style: 1},
+			{Type: "top", Color: "000000", Style: 1},
+			{Type: "right", Color: "000000", Style: 1},
+			{Type: "bottom", Color: "000000", Style: 1},
+		},
+	})
+}
+
 func derefString(s *string) string {
 	if s != nil {
 		return *s
@@ -17,12 +17,18 @@ import (
 
 // Create a new event
 func GetEventsBookingRapat(c *gin.Context) {
+	dari, sampai, saring, err := rentangKalender(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	var events []models.BookingRapat
 	if err := initializers.DB.Find(&events).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"booking": events})
+	c.JSON(http.StatusOK, gin.H{"booking": expandBookingRapat(events, dari, sampai, saring)})
 }
 
 // Example of using generated UUID
@@ -33,34 +39,24 @@ func CreateEventBookingRapat(c *gin.Context) {
 		return
 	}
 
-	// Set notification menggunakan fungsi dari notificationController
-	loc, err := time.LoadLocation("Asia/Jakarta")
-	if err != nil {
-		log.Printf("Error loading location: %v", err)
-		return
-	}
-
-	var startTime time.Time
-	if event.AllDay {
-		// Jika AllDay = true, set waktu ke tengah malam
-		startTime, err = time.ParseInLocation("2006-01-02T15:04:05", event.Start+"T00:00:00", loc)
-	} else {
-		// Jika tidak, parse dengan format RFC3339
-		startTime, err = time.ParseInLocation(time.RFC3339, event.Start, loc)
+	event.ID = 0
+	event.RRule = strings.TrimSpace(event.RRule)
+	if event.RuangRapatID != nil && *event.RuangRapatID == 0 {
+		event.RuangRapatID = nil
 	}
+	event.CreateBy = c.MustGet("username").(string)
 
-	if err != nil {
-		log.Printf("Error parsing start time: %v", err)
+	// Booking yang bentrok dengan booking lain di ruang yang sama ditolak
+	if err := simpanBookingRapat(&event, false); err != nil {
+		log.Printf("Error creating event: %v", err)
+		respondBookingRapatError(c, err)
 		return
 	}
 
-	SetNotification(event.Title, startTime, "BookingRapat") // Panggil fungsi SetNotification
+	// Set notification menggunakan fungsi dari notificationController
+	SetNotificationEvent(event.Title, event.StartAt.In(jakartaLocation()), "BookingRapat", sumberBookingRapat, event.ID) // Panggil fungsi SetNotification
+	emitWebhook(eventBookingRapatDibuat, event)
 
-	if err := initializers.DB.Create(&event).Error; err != nil {
-		log.Printf("Error creating event: %v", err) // Add this line
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
 	c.JSON(http.StatusOK, event)
 }
 
@@ -70,10 +66,19 @@ func DeleteEventBookingRapat(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "ID harus disertakan"})
 		return
 	}
-	if err := initializers.DB.Where("id = ?", id).Delete(&models.BookingRapat{}).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	res := initializers.DB.Where("id = ?", id).Delete(&models.BookingRapat{})
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": res.Error.Error()})
 		return
 	}
+	// Booking yang sudah tidak ada tidak memicu event dihapus lagi
+	if res.RowsAffected > 0 {
+		initializers.DB.Where("sumber = ? AND event_id = ?", sumberBookingRapat, id).Delete(&models.PengecualianJadwal{})
+		if err := batalkanPengingatEvent(initializers.DB, sumberBookingRapat, id); err != nil {
+			log.Printf("Error cancelling reminders for booking %s: %v", id, err)
+		}
+		emitWebhook(eventBookingRapatDihapus, gin.H{"id": id})
+	}
 	c.Status(http.StatusNoContent)
 }
 
@@ -84,8 +89,18 @@ func ExportBookingRapatToExcel(c *gin.Context) {
 		return
 	}
 
+	// Setiap kejadian booking berulang ikut dicetak pada kalender tahunan
+	dari, sampai := rentangTahunExport(2024)
+	events_rapat = expandBookingRapat(events_rapat, dari, sampai, false)
+
 	log.Printf("Jumlah event yang ditemukan: %d", len(events_rapat))
 
+	// Hari libur nasional dan cuti bersama diberi warna pada kalender
+	kalender, err := muatKalenderKerja(initializers.DB)
+	if err != nil {
+		log.Printf("Error loading hari libur for export: %v", err)
+	}
+
 	f := excelize.NewFile()
 	sheet := "Calendar 2024"
 	f.NewSheet(sheet)
@@ -99,7 +114,7 @@ func ExportBookingRapatToExcel(c *gin.Context) {
 	rowOffset := 0
 	colOffset := 0
 	for i, month := range months {
-		setMonthDataBookingRapat(f, sheet, month, rowOffset, colOffset, events_rapat)
+		setMonthDataBookingRapat(f, sheet, month, rowOffset, colOffset, events_rapat, kalender)
 		colOffset += 9 // Sesuaikan offset untuk bulan berikutnya dalam baris yang sama
 		if (i+1)%3 == 0 {
 			rowOffset += 18 // Pindah ke baris berikut nya setiap 3 bulan
@@ -124,7 +139,7 @@ func ExportBookingRapatToExcel(c *gin.Context) {
 	c.Writer.Write(buffer.Bytes())
 }
 
-func setMonthDataBookingRapat(f *excelize.File, sheet, month string, rowOffset, colOffset int, events_rapat []models.BookingRapat) {
+func setMonthDataBookingRapat(f *excelize.File, sheet, month string, rowOffset, colOffset int, events_rapat []models.BookingRapat, kalender *kalenderKerja) {
 	// Log awal fungsi
 	log.Printf("Memproses data untuk bulan: %s", month)
 
@@ -317,6 +332,7 @@ func setMonthDataBookingRapat(f *excelize.File, sheet, month string, rowOffset,
 		fmt.Println(err)
 		return
 	}
+	tandaiHariLiburBulan(f, sheet, monthTime, rowOffset, colOffset, kalender)
 	// hide gridlines for the worksheet
 	disable := false
 	if err := f.SetSheetView(sheet, 0, &excelize.ViewOptions{
@@ -334,6 +350,15 @@ func setMonthDataBookingRapat(f *excelize.File, sheet, month string, rowOffset,
 	log.Printf("Selesai memproses data untuk bulan: %s", month)
 }
 
+// akhirHariEksklusif menormalkan akhir event all day. FullCalendar mengirim endStr eksklusif;
+// end kosong atau tidak setelah start (data lama yang menyimpan hari terakhir) dianggap satu hari.
+func akhirHariEksklusif(start, end time.Time) time.Time {
+	if end.After(start) {
+		return end
+	}
+	return start.AddDate(0, 0, 1)
+}
+
 func parseEventTime(allDay bool, startTimeStr, endTimeStr string) (startTime, endTime time.Time, err error) {
 	loc, err := time.LoadLocation("Asia/Jakarta")
 	if err != nil {
@@ -348,13 +373,15 @@ func parseEventTime(allDay bool, startTimeStr, endTimeStr string) (startTime, en
 			log.Printf("Error parsing start time: %v", err)
 			return
 		}
-		endTime, err = time.ParseInLocation("2006-01-02", endTimeStr, loc)
-		if err != nil {
-			log.Printf("Error parsing end time: %v", err)
-			return
+		if endTimeStr != "" {
+			endTime, err = time.ParseInLocation("2006-01-02", endTimeStr, loc)
+			if err != nil {
+				log.Printf("Error parsing end time: %v", err)
+				return
+			}
 		}
-		// Set end time to the end of the day
-		endTime = endTime.Add(time.Hour*24 - time.Nanosecond)
+		// End dari FullCalendar eksklusif (hari setelah hari terakhir)
+		endTime = akhirHariEksklusif(startTime, endTime)
 	} else {
 		// Jika AllDay = false, parse dengan format RFC3339
 		startTime, err = time.ParseInLocation(time.RFC3339, startTimeStr, loc)
@@ -0,0 +1,582 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"project-its/initializers"
+	"project-its/models"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	statusBoxAktif    = "Aktif"
+	statusBoxPenuh    = "Penuh"
+	statusBoxNonaktif = "Nonaktif"
+)
+
+// errPindahArsip dipakai untuk kesalahan validasi pemindahan arsip yang dikembalikan sebagai 400
+type errPindahArsip struct{ pesan string }
+
+func (e errPindahArsip) Error() string { return e.pesan }
+
+type boxArsipRequest struct {
+	NoBox      *string `json:"no_box"`
+	Gedung     *string `json:"gedung"`
+	Ruang      *string `json:"ruang"`
+	Rak        *string `json:"rak"`
+	Ambalan    *string `json:"ambalan"`
+	Kapasitas  *int    `json:"kapasitas"`
+	Status     *string `json:"status"`
+	Keterangan *string `json:"keterangan"`
+}
+
+type pindahArsipRequest struct {
+	BoxID      *uint   `json:"box_id"`
+	Keterangan *string `json:"keterangan"`
+}
+
+// boxArsipDetail menambahkan jumlah isi dan lokasi lengkap ke data box
+type boxArsipDetail struct {
+	models.BoxArsip
+	JumlahArsip int64  `json:"jumlah_arsip"`
+	Lokasi      string `json:"lokasi"`
+}
+
+func BoxArsipIndex(c *gin.Context) {
+	var boxes []models.BoxArsip
+	if err := initializers.DB.Order("no_box asc").Find(&boxes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Hitung isi setiap box dalam satu query
+	type jumlahIsi struct {
+		BoxID  uint
+		Jumlah int64
+	}
+	var isi []jumlahIsi
+	initializers.DB.Model(&models.Arsip{}).Select("box_id, count(*) as jumlah").Where("box_id IS NOT NULL").Group("box_id").Scan(&isi)
+	jumlahPerBox := make(map[uint]int64)
+	for _, row := range isi {
+		jumlahPerBox[row.BoxID] = row.Jumlah
+	}
+
+	details := []boxArsipDetail{}
+	for _, box := range boxes {
+		details = append(details, boxArsipDetail{BoxArsip: box, JumlahArsip: jumlahPerBox[box.ID], Lokasi: lokasiBox(box)})
+	}
+	c.JSON(http.StatusOK, gin.H{"box": details})
+}
+
+func BoxArsipShow(c *gin.Context) {
+	var box models.BoxArsip
+	if err := initializers.DB.First(&box, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Box tidak ditemukan"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"box": newBoxArsipDetail(initializers.DB, box)})
+}
+
+func BoxArsipCreate(c *gin.Context) {
+	var requestBody boxArsipRequest
+	if err := c.BindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+	if requestBody.NoBox == nil || *requestBody.NoBox == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no_box harus diisi"})
+		return
+	}
+
+	box := models.BoxArsip{
+		NoBox:      *requestBody.NoBox,
+		Gedung:     requestBody.Gedung,
+		Ruang:      requestBody.Ruang,
+		Rak:        requestBody.Rak,
+		Ambalan:    requestBody.Ambalan,
+		Status:     statusBoxAktif,
+		Keterangan: requestBody.Keterangan,
+		CreateBy:   c.MustGet("username").(string),
+	}
+	if requestBody.Kapasitas != nil {
+		if *requestBody.Kapasitas < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Kapasitas tidak boleh negatif"})
+			return
+		}
+		box.Kapasitas = *requestBody.Kapasitas
+	}
+
+	if err := initializers.DB.Create(&box).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat box, no box mungkin sudah dipakai"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"box": box})
+}
+
+func BoxArsipUpdate(c *gin.Context) {
+	var requestBody boxArsipRequest
+	if err := c.BindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+
+	var box models.BoxArsip
+	if err := initializers.DB.First(&box, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Box tidak ditemukan"})
+		return
+	}
+
+	if requestBody.NoBox != nil && *requestBody.NoBox != "" {
+		box.NoBox = *requestBody.NoBox
+	}
+	if requestBody.Gedung != nil {
+		box.Gedung = requestBody.Gedung
+	}
+	if requestBody.Ruang != nil {
+		box.Ruang = requestBody.Ruang
+	}
+	if requestBody.Rak != nil {
+		box.Rak = requestBody.Rak
+	}
+	if requestBody.Ambalan != nil {
+		box.Ambalan = requestBody.Ambalan
+	}
+	if requestBody.Kapasitas != nil {
+		if *requestBody.Kapasitas < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Kapasitas tidak boleh negatif"})
+			return
+		}
+		box.Kapasitas = *requestBody.Kapasitas
+	}
+	if requestBody.Status != nil {
+		switch *requestBody.Status {
+		case statusBoxAktif, statusBoxPenuh, statusBoxNonaktif:
+			box.Status = *requestBody.Status
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Status box tidak valid"})
+			return
+		}
+	}
+	if requestBody.Keterangan != nil {
+		box.Keterangan = requestBody.Keterangan
+	}
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&box).Error; err != nil {
+			return err
+		}
+		// No box disalin ke arsip agar kolom No Box lama tetap sesuai
+		if err := tx.Model(&models.Arsip{}).Where("box_id = ?", box.ID).Update("no_box", box.NoBox).Error; err != nil {
+			return err
+		}
+		return refreshStatusBox(tx, box.ID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan box"})
+		return
+	}
+
+	initializers.DB.First(&box, box.ID)
+	c.JSON(http.StatusOK, gin.H{"box": box})
+}
+
+func BoxArsipDelete(c *gin.Context) {
+	var box models.BoxArsip
+	if err := initializers.DB.First(&box, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Box tidak ditemukan"})
+		return
+	}
+
+	var jumlah int64
+	initializers.DB.Model(&models.Arsip{}).Where("box_id = ?", box.ID).Count(&jumlah)
+	if jumlah > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Box masih berisi %d arsip", jumlah)})
+		return
+	}
+
+	if err := initializers.DB.Delete(&box).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghapus box"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Box deleted successfully"})
+}
+
+// BoxArsipIsi menjawab "apa saja isi box Y"
+func BoxArsipIsi(c *gin.Context) {
+	var box models.BoxArsip
+	if err := initializers.DB.First(&box, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Box tidak ditemukan"})
+		return
+	}
+
+	var arsip []models.Arsip
+	if err := initializers.DB.Where("box_id = ?", box.ID).Order("no_arsip asc").Find(&arsip).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"box":   newBoxArsipDetail(initializers.DB, box),
+		"arsip": arsip,
+	})
+}
+
+// ArsipLokasi menjawab "di mana dokumen X" beserta riwayat perpindahannya
+func ArsipLokasi(c *gin.Context) {
+	var arsip models.Arsip
+	if err := initializers.DB.First(&arsip, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Arsip not found"})
+		return
+	}
+
+	var box *boxArsipDetail
+	if arsip.BoxID != nil {
+		var b models.BoxArsip
+		if err := initializers.DB.First(&b, *arsip.BoxID).Error; err == nil {
+			detail := newBoxArsipDetail(initializers.DB, b)
+			box = &detail
+		}
+	}
+
+	var mutasi []models.MutasiArsip
+	initializers.DB.Where("arsip_id = ?", arsip.ID).Order("id desc").Find(&mutasi)
+
+	c.JSON(http.StatusOK, gin.H{
+		"arsip":  &arsip,
+		"box":    box,
+		"mutasi": mutasi,
+	})
+}
+
+// ArsipPindah memindahkan arsip ke box lain dan mencatat riwayatnya
+func ArsipPindah(c *gin.Context) {
+	var requestBody pindahArsipRequest
+	if err := c.BindJSON(&requestBody); err != nil || requestBody.BoxID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "box_id harus diisi"})
+		return
+	}
+
+	var arsip models.Arsip
+	if err := initializers.DB.First(&arsip, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Arsip not found"})
+		return
+	}
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		return pindahkanArsip(tx, &arsip, *requestBody.BoxID, requestBody.Keterangan, c.MustGet("username").(string))
+	})
+	if err != nil {
+		respondPindahArsipError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"arsip": &arsip})
+}
+
+// pindahkanArsip memasukkan arsip ke box tujuan, mencatat mutasi dan memperbarui status kedua box
+func pindahkanArsip(tx *gorm.DB, arsip *models.Arsip, keBoxID uint, keterangan *string, username string) error {
+	// Baris box dikunci agar dua pemindahan bersamaan tidak sama-sama lolos cek kapasitas
+	var box models.BoxArsip
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&box, keBoxID).Error; err != nil {
+		return errPindahArsip{"box tujuan tidak ditemukan"}
+	}
+	if box.Status == statusBoxNonaktif {
+		return errPindahArsip{fmt.Sprintf("box %s sedang nonaktif", box.NoBox)}
+	}
+	if arsip.StatusRetensi == statusRetensiMusnah {
+		return errPindahArsip{"arsip sudah dimusnahkan"}
+	}
+	if arsip.BoxID != nil && *arsip.BoxID == box.ID {
+		return errPindahArsip{fmt.Sprintf("arsip sudah berada di box %s", box.NoBox)}
+	}
+
+	var jumlah int64
+	tx.Model(&models.Arsip{}).Where("box_id = ?", box.ID).Count(&jumlah)
+	if box.Kapasitas > 0 && jumlah >= int64(box.Kapasitas) {
+		return errPindahArsip{fmt.Sprintf("box %s sudah penuh (%d/%d)", box.NoBox, jumlah, box.Kapasitas)}
+	}
+
+	dariBoxID := arsip.BoxID
+	arsip.BoxID = &box.ID
+	arsip.NoBox = &box.NoBox
+	if err := tx.Model(arsip).Updates(map[string]interface{}{"box_id": box.ID, "no_box": box.NoBox}).Error; err != nil {
+		return err
+	}
+
+	mutasi := models.MutasiArsip{
+		ArsipID:    arsip.ID,
+		DariBoxID:  dariBoxID,
+		KeBoxID:    &box.ID,
+		Keterangan: keterangan,
+		CreateBy:   username,
+	}
+	if err := tx.Create(&mutasi).Error; err != nil {
+		return err
+	}
+
+	if err := refreshStatusBox(tx, box.ID); err != nil {
+		return err
+	}
+	if dariBoxID != nil {
+		return refreshStatusBox(tx, *dariBoxID)
+	}
+	return nil
+}
+
+func respondPindahArsipError(c *gin.Context, err error) {
+	var errValidasi errPindahArsip
+	if errors.As(err, &errValidasi) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errValidasi.pesan})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// keluarkanArsipDariBox melepas arsip dari box-nya (mis. karena dimusnahkan) dan mencatat mutasinya
+func keluarkanArsipDariBox(tx *gorm.DB, arsip *models.Arsip, keterangan *string, username string) error {
+	if arsip.BoxID == nil {
+		return nil
+	}
+
+	dariBoxID := *arsip.BoxID
+	arsip.BoxID = nil
+	arsip.NoBox = nil
+	if err := tx.Model(arsip).Updates(map[string]interface{}{"box_id": nil, "no_box": nil}).Error; err != nil {
+		return err
+	}
+
+	mutasi := models.MutasiArsip{
+		ArsipID:    arsip.ID,
+		DariBoxID:  &dariBoxID,
+		Keterangan: keterangan,
+		CreateBy:   username,
+	}
+	if err := tx.Create(&mutasi).Error; err != nil {
+		return err
+	}
+	return refreshStatusBox(tx, dariBoxID)
+}
+
+// refreshStatusBox menandai box Penuh/Aktif sesuai isinya, box Nonaktif dibiarkan
+func refreshStatusBox(tx *gorm.DB, boxID uint) error {
+	var box models.BoxArsip
+	if err := tx.First(&box, boxID).Error; err != nil {
+		return err
+	}
+	if box.Status == statusBoxNonaktif {
+		return nil
+	}
+
+	var jumlah int64
+	tx.Model(&models.Arsip{}).Where("box_id = ?", box.ID).Count(&jumlah)
+	status := statusBoxAktif
+	if box.Kapasitas > 0 && jumlah >= int64(box.Kapasitas) {
+		status = statusBoxPenuh
+	}
+	if status == box.Status {
+		return nil
+	}
+	return tx.Model(&box).Update("status", status).Error
+}
+
+func newBoxArsipDetail(db *gorm.DB, box models.BoxArsip) boxArsipDetail {
+	var jumlah int64
+	db.Model(&models.Arsip{}).Where("box_id = ?", box.ID).Count(&jumlah)
+	return boxArsipDetail{BoxArsip: box, JumlahArsip: jumlah, Lokasi: lokasiBox(box)}
+}
+
+// lokasiBox menggabungkan gedung, ruang, rak dan ambalan menjadi satu teks lokasi
+func lokasiBox(box models.BoxArsip) string {
+	var parts []string
+	if box.Gedung != nil && *box.Gedung != "" {
+		parts = append(parts, "Gedung "+*box.Gedung)
+	}
+	if box.Ruang != nil && *box.Ruang != "" {
+		parts = append(parts, "Ruang "+*box.Ruang)
+	}
+	if box.Rak != nil && *box.Rak != "" {
+		parts = append(parts, "Rak "+*box.Rak)
+	}
+	if box.Ambalan != nil && *box.Ambalan != "" {
+		parts = append(parts, "Ambalan "+*box.Ambalan)
+	}
+	return strings.Join(parts, " / ")
+}
+
+// ExportBoxArsipHandler mengekspor daftar isi satu box dengan style export arsip
+func ExportBoxArsipHandler(c *gin.Context) {
+	var box models.BoxArsip
+	if err := initializers.DB.First(&box, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Box tidak ditemukan"})
+		return
+	}
+
+	var arsips []models.Arsip
+	initializers.DB.Where("box_id = ?", box.ID).Order("no_arsip asc").Find(&arsips)
+
+	f := excelize.NewFile()
+	sheetName := "ISI BOX"
+	f.NewSheet(sheetName)
+	f.DeleteSheet("Sheet1")
+
+	styleHeader, err := newArsipHeaderStyle(f)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Error creating style: %v", err)
+		return
+	}
+	styleAll, err := newArsipDataStyle(f)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Error creating style: %v", err)
+		return
+	}
+
+	kapasitas := "Tidak dibatasi"
+	if box.Kapasitas > 0 {
+		kapasitas = fmt.Sprintf("%d / %d", len(arsips), box.Kapasitas)
+	}
+	info := [][]string{
+		{"No Box", box.NoBox},
+		{"Lokasi", lokasiBox(box)},
+		{"Terisi", kapasitas},
+		{"Status", box.Status},
+	}
+	for i, row := range info {
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", i+1), row[0])
+		f.SetCellValue(sheetName, fmt.Sprintf("B%d", i+1), row[1])
+		f.SetCellStyle(sheetName, fmt.Sprintf("A%d", i+1), fmt.Sprintf("A%d", i+1), styleHeader)
+	}
+
+	writeArsipTable(f, sheetName, len(info)+2, arsips, styleHeader, styleAll)
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Error saving file: %v", err)
+		return
+	}
+	c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=box_%s.xlsx", strings.ReplaceAll(box.NoBox, "/", "-")))
+	c.Writer.Write(buf.Bytes())
+}
+
+// ExportLokasiArsipHandler mengekspor lokasi satu arsip beserta riwayat perpindahannya
+func ExportLokasiArsipHandler(c *gin.Context) {
+	var arsip models.Arsip
+	if err := initializers.DB.First(&arsip, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Arsip not found"})
+		return
+	}
+
+	boxes := make(map[uint]models.BoxArsip)
+	var allBoxes []models.BoxArsip
+	initializers.DB.Unscoped().Find(&allBoxes)
+	for _, b := range allBoxes {
+		boxes[b.ID] = b
+	}
+
+	var mutasi []models.MutasiArsip
+	initializers.DB.Where("arsip_id = ?", arsip.ID).Order("id asc").Find(&mutasi)
+
+	f := excelize.NewFile()
+	sheetName := "LOKASI ARSIP"
+	f.NewSheet(sheetName)
+	f.DeleteSheet("Sheet1")
+
+	styleHeader, err := newArsipHeaderStyle(f)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Error creating style: %v", err)
+		return
+	}
+	styleAll, err := newArsipDataStyle(f)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Error creating style: %v", err)
+		return
+	}
+
+	row := writeArsipTable(f, sheetName, 1, []models.Arsip{arsip}, styleHeader, styleAll) + 1
+
+	// Lokasi box saat ini
+	f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), "Gedung")
+	f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), "Ruang")
+	f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), "Rak")
+	f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), "Ambalan")
+	f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), "No Box")
+	f.SetCellStyle(sheetName, fmt.Sprintf("A%d", row), fmt.Sprintf("E%d", row), styleHeader)
+	row++
+	if arsip.BoxID != nil {
+		box := boxes[*arsip.BoxID]
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), derefString(box.Gedung))
+		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), derefString(box.Ruang))
+		f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), derefString(box.Rak))
+		f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), derefString(box.Ambalan))
+		f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), box.NoBox)
+	} else {
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), "Belum ditempatkan di box")
+	}
+	f.SetCellStyle(sheetName, fmt.Sprintf("A%d", row), fmt.Sprintf("E%d", row), styleAll)
+	row += 2
+
+	// Riwayat perpindahan
+	f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), "Tanggal")
+	f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), "Dari Box")
+	f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), "Ke Box")
+	f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), "Keterangan")
+	f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), "Oleh")
+	f.SetCellStyle(sheetName, fmt.Sprintf("A%d", row), fmt.Sprintf("E%d", row), styleHeader)
+	for _, m := range mutasi {
+		row++
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), models.FormatTanggal(m.CreatedAt, "2006-01-02 15:04"))
+		if m.DariBoxID != nil {
+			f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), boxes[*m.DariBoxID].NoBox)
+		}
+		if m.KeBoxID != nil {
+			f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), boxes[*m.KeBoxID].NoBox)
+		}
+		f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), derefString(m.Keterangan))
+		f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), m.CreateBy)
+		f.SetCellStyle(sheetName, fmt.Sprintf("A%d", row), fmt.Sprintf("E%d", row), styleAll)
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Error saving file: %v", err)
+		return
+	}
+	c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=lokasi_arsip_%d.xlsx", arsip.ID))
+	c.Writer.Write(buf.Bytes())
+}
+
+// writeArsipTable menulis header dan baris arsip dengan kolom yang sama seperti sheet ARSIP,
+// mengembalikan nomor baris terakhir yang ditulis
+func writeArsipTable(f *excelize.File, sheetName string, startRow int, arsips []models.Arsip, styleHeader, styleAll int) int {
+	headers := []string{"No Arsip", "Jenis Dokumen", "No Dokumen", "Perihal", "No Box", "Keterangan", "Tanggal Dokumen", "Tanggal Penyerahan"}
+	for i, header := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, startRow)
+		f.SetCellValue(sheetName, cell, header)
+	}
+	f.SetCellStyle(sheetName, fmt.Sprintf("A%d", startRow), fmt.Sprintf("H%d", startRow), styleHeader)
+	f.SetColWidth(sheetName, "A", "H", 20)
+	f.SetRowHeight(sheetName, startRow, 20)
+
+	rowNum := startRow
+	for _, arsip := range arsips {
+		rowNum++
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", rowNum), derefString(arsip.NoArsip))
+		f.SetCellValue(sheetName, fmt.Sprintf("B%d", rowNum), derefString(arsip.JenisDokumen))
+		f.SetCellValue(sheetName, fmt.Sprintf("C%d", rowNum), derefString(arsip.NoDokumen))
+		f.SetCellValue(sheetName, fmt.Sprintf("D%d", rowNum), derefString(arsip.Perihal))
+		f.SetCellValue(sheetName, fmt.Sprintf("E%d", rowNum), derefString(arsip.NoBox))
+		f.SetCellValue(sheetName, fmt.Sprintf("F%d", rowNum), derefString(arsip.Keterangan))
+		f.SetCellValue(sheetName, fmt.Sprintf("G%d", rowNum), models.FormatTanggal(arsip.TanggalDokumen, "2006-01-02"))
+		f.SetCellValue(sheetName, fmt.Sprintf("H%d", rowNum), models.FormatTanggal(arsip.TanggalPenyerahan, "2006-01-02"))
+	}
+	if rowNum > startRow {
+		f.SetCellStyle(sheetName, fmt.Sprintf("A%d", startRow+1), fmt.Sprintf("H%d", rowNum), styleAll)
+	}
+	return rowNum
+}
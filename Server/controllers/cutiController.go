@@ -0,0 +1,712 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"project-its/initializers"
+	"project-its/middleware"
+	"project-its/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Status pengajuan cuti
+const (
+	statusCutiDiajukan   = "Diajukan"
+	statusCutiDisetujui  = "Disetujui"
+	statusCutiDitolak    = "Ditolak"
+	statusCutiDibatalkan = "Dibatalkan"
+)
+
+// errPengajuanCuti dipakai untuk kesalahan validasi yang dikembalikan sebagai 400
+type errPengajuanCuti struct{ pesan string }
+
+func (e errPengajuanCuti) Error() string { return e.pesan }
+
+type JenisCutiRequest struct {
+	Kode         *string `json:"kode"`
+	Nama         *string `json:"nama"`
+	KuotaTahunan *int    `json:"kuota_tahunan"`
+	Color        *string `json:"color"`
+	Aktif        *bool   `json:"aktif"`
+}
+
+type KuotaCutiRequest struct {
+	UserID      *uint   `json:"user_id"`
+	JenisCutiID *uint   `json:"jenis_cuti_id"`
+	Tahun       *int    `json:"tahun"`
+	Kuota       *int    `json:"kuota"`
+	Keterangan  *string `json:"keterangan"`
+}
+
+type PengajuanCutiRequest struct {
+	JenisCutiID    *uint   `json:"jenis_cuti_id"`
+	TanggalMulai   *string `json:"tanggal_mulai"`
+	TanggalSelesai *string `json:"tanggal_selesai"`
+	Alasan         *string `json:"alasan"`
+}
+
+type KeputusanCutiRequest struct {
+	Catatan *string `json:"catatan"`
+}
+
+type saldoCuti struct {
+	JenisCutiID uint   `json:"jenis_cuti_id"`
+	Kode        string `json:"kode"`
+	Nama        string `json:"nama"`
+	Dibatasi    bool   `json:"dibatasi"` // false bila jenis cuti tidak memotong kuota
+	Kuota       int    `json:"kuota"`
+	Terpakai    int    `json:"terpakai"`
+	Diajukan    int    `json:"diajukan"`
+	Sisa        int    `json:"sisa"`
+}
+
+func JenisCutiIndex(c *gin.Context) {
+	var jenis []models.JenisCuti
+	query := initializers.DB.Order("id asc")
+	if c.Query("aktif") == "true" {
+		query = query.Where("aktif = ?", true)
+	}
+	if err := query.Find(&jenis).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"jenis_cuti": jenis})
+}
+
+func JenisCutiCreate(c *gin.Context) {
+	var req JenisCutiRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Kode == nil || strings.TrimSpace(*req.Kode) == "" || req.Nama == nil || strings.TrimSpace(*req.Nama) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Kode dan nama jenis cuti wajib diisi"})
+		return
+	}
+
+	jenis := models.JenisCuti{
+		Kode:  strings.ToUpper(strings.TrimSpace(*req.Kode)),
+		Nama:  strings.TrimSpace(*req.Nama),
+		Aktif: true,
+	}
+	if req.KuotaTahunan != nil {
+		jenis.KuotaTahunan = *req.KuotaTahunan
+	}
+	if req.Color != nil {
+		jenis.Color = *req.Color
+	}
+	if req.Aktif != nil {
+		jenis.Aktif = *req.Aktif
+	}
+	if jenis.KuotaTahunan < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Kuota tahunan tidak boleh negatif"})
+		return
+	}
+
+	if err := initializers.DB.Create(&jenis).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"jenis_cuti": jenis})
+}
+
+func JenisCutiUpdate(c *gin.Context) {
+	var req JenisCutiRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var jenis models.JenisCuti
+	if err := initializers.DB.First(&jenis, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Jenis cuti tidak ditemukan"})
+		return
+	}
+
+	if req.Kode != nil && strings.TrimSpace(*req.Kode) != "" {
+		jenis.Kode = strings.ToUpper(strings.TrimSpace(*req.Kode))
+	}
+	if req.Nama != nil && strings.TrimSpace(*req.Nama) != "" {
+		jenis.Nama = strings.TrimSpace(*req.Nama)
+	}
+	if req.KuotaTahunan != nil {
+		if *req.KuotaTahunan < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Kuota tahunan tidak boleh negatif"})
+			return
+		}
+		jenis.KuotaTahunan = *req.KuotaTahunan
+	}
+	if req.Color != nil {
+		jenis.Color = *req.Color
+	}
+	if req.Aktif != nil {
+		jenis.Aktif = *req.Aktif
+	}
+
+	if err := initializers.DB.Save(&jenis).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"jenis_cuti": jenis})
+}
+
+// JenisCutiDelete hanya untuk jenis yang belum pernah dipakai, selebihnya cukup dinonaktifkan
+func JenisCutiDelete(c *gin.Context) {
+	var jenis models.JenisCuti
+	if err := initializers.DB.First(&jenis, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Jenis cuti tidak ditemukan"})
+		return
+	}
+
+	var dipakai int64
+	initializers.DB.Model(&models.PengajuanCuti{}).Where("jenis_cuti_id = ?", jenis.ID).Count(&dipakai)
+	if dipakai > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Jenis cuti sudah dipakai pada pengajuan, nonaktifkan saja"})
+		return
+	}
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("jenis_cuti_id = ?", jenis.ID).Delete(&models.KuotaCuti{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&jenis).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Jenis cuti deleted"})
+}
+
+// KuotaCutiIndex menampilkan kuota khusus per user, filter ?tahun= dan ?user_id=
+func KuotaCutiIndex(c *gin.Context) {
+	var kuota []models.KuotaCuti
+	query := initializers.DB.Scopes(scopeCutiTerlihat(c, false)).Order("tahun desc, user_id asc, jenis_cuti_id asc")
+	if tahun := c.Query("tahun"); tahun != "" {
+		query = query.Where("tahun = ?", tahun)
+	}
+	if userID := c.Query("user_id"); userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	if err := query.Find(&kuota).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"kuota_cuti": kuota})
+}
+
+// KuotaCutiSet membuat atau mengganti kuota user untuk satu jenis cuti pada satu tahun
+func KuotaCutiSet(c *gin.Context) {
+	var req KuotaCutiRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.UserID == nil || req.JenisCutiID == nil || req.Tahun == nil || req.Kuota == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id, jenis_cuti_id, tahun dan kuota wajib diisi"})
+		return
+	}
+	if *req.Kuota < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Kuota tidak boleh negatif"})
+		return
+	}
+	if err := initializers.DB.First(&models.User{}, *req.UserID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "User tidak ditemukan"})
+		return
+	}
+	if err := initializers.DB.First(&models.JenisCuti{}, *req.JenisCutiID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Jenis cuti tidak ditemukan"})
+		return
+	}
+
+	var kuota models.KuotaCuti
+	err := initializers.DB.
+		Where("user_id = ? AND jenis_cuti_id = ? AND tahun = ?", *req.UserID, *req.JenisCutiID, *req.Tahun).
+		First(&kuota).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	kuota.UserID = *req.UserID
+	kuota.JenisCutiID = *req.JenisCutiID
+	kuota.Tahun = *req.Tahun
+	kuota.Kuota = *req.Kuota
+	kuota.Keterangan = req.Keterangan
+	kuota.UpdateBy = c.MustGet("username").(string)
+
+	if err := initializers.DB.Save(&kuota).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"kuota_cuti": kuota})
+}
+
+// PengajuanCutiIndex filter ?status=, ?user_id=, ?tahun=, dan ?persetujuan=true untuk antrian atasan yang login
+func PengajuanCutiIndex(c *gin.Context) {
+	var pengajuan []models.PengajuanCuti
+	query := initializers.DB.Scopes(scopeCutiTerlihat(c, true)).Order("tanggal_mulai desc, id desc")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if userID := c.Query("user_id"); userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	if tahun, err := strconv.Atoi(c.Query("tahun")); err == nil {
+		dari, sampai := rentangTahunCuti(tahun)
+		query = query.Where("tanggal_mulai >= ? AND tanggal_mulai < ?", dari, sampai)
+	}
+	if c.Query("persetujuan") == "true" {
+		query = query.Where("status = ? AND atasan_id = ?", statusCutiDiajukan, c.MustGet("userID").(uint))
+	}
+	if err := query.Find(&pengajuan).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"pengajuan_cuti": pengajuan})
+}
+
+func PengajuanCutiShow(c *gin.Context) {
+	var pengajuan models.PengajuanCuti
+	if err := initializers.DB.First(&pengajuan, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pengajuan cuti tidak ditemukan"})
+		return
+	}
+	atasanPengajuan := pengajuan.AtasanID != nil && *pengajuan.AtasanID == c.MustGet("userID").(uint)
+	if !atasanPengajuan && !bolehLihatCutiUser(c, pengajuan.UserID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Anda tidak berhak melihat pengajuan cuti ini"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"pengajuan_cuti": &pengajuan})
+}
+
+// PengajuanCutiCreate mengajukan cuti untuk user yang login
+func PengajuanCutiCreate(c *gin.Context) {
+	var req PengajuanCutiRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.JenisCutiID == nil || req.TanggalMulai == nil || req.TanggalSelesai == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Jenis cuti, tanggal mulai dan tanggal selesai wajib diisi"})
+		return
+	}
+	mulai, err := time.Parse("2006-01-02", *req.TanggalMulai)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format tanggal mulai harus YYYY-MM-DD"})
+		return
+	}
+	selesai, err := time.Parse("2006-01-02", *req.TanggalSelesai)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format tanggal selesai harus YYYY-MM-DD"})
+		return
+	}
+
+	var pengajuan models.PengajuanCuti
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		// Kunci baris user agar dua pengajuan bersamaan tidak melewati kuota
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, c.MustGet("userID").(uint)).Error; err != nil {
+			return errPengajuanCuti{"User tidak ditemukan"}
+		}
+
+		var jenis models.JenisCuti
+		if err := tx.First(&jenis, *req.JenisCutiID).Error; err != nil || !jenis.Aktif {
+			return errPengajuanCuti{"Jenis cuti tidak ditemukan atau tidak aktif"}
+		}
+
+		jumlah, err := validasiPengajuanCuti(tx, user.ID, 0, mulai, selesai)
+		if err != nil {
+			return err
+		}
+
+		saldo, err := hitungSaldoCuti(tx, user.ID, jenis, mulai.Year())
+		if err != nil {
+			return err
+		}
+		if err := cekKuotaCuti(saldo, mulai.Year(), jumlah); err != nil {
+			return err
+		}
+
+		pengajuan = models.PengajuanCuti{
+			UserID:         user.ID,
+			Pemohon:        user.Username,
+			JenisCutiID:    jenis.ID,
+			TanggalMulai:   &mulai,
+			TanggalSelesai: &selesai,
+			JumlahHari:     jumlah,
+			Alasan:         req.Alasan,
+			Status:         statusCutiDiajukan,
+			AtasanID:       user.AtasanID,
+		}
+		return tx.Create(&pengajuan).Error
+	})
+	if err != nil {
+		respondPengajuanCutiError(c, err)
+		return
+	}
+
+	judul := fmt.Sprintf("Pengajuan %d hari cuti dari %s menunggu persetujuan", pengajuan.JumlahHari, pengajuan.Pemohon)
+	if pengajuan.AtasanID != nil {
+		createNotificationUntuk(judul, time.Now(), "PengajuanCuti", pengajuan.AtasanID)
+	} else {
+		createNotificationRole(judul, time.Now(), "PengajuanCuti", roleAdmin)
+	}
+	c.JSON(http.StatusCreated, gin.H{"pengajuan_cuti": &pengajuan})
+}
+
+// PengajuanCutiSetujui menyetujui pengajuan dan menaruhnya di kalender cuti
+func PengajuanCutiSetujui(c *gin.Context) {
+	var req KeputusanCutiRequest
+	_ = c.ShouldBindJSON(&req) // catatan opsional
+
+	var pengajuan models.PengajuanCuti
+	var jadwal models.JadwalCuti
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := findPengajuanCutiDiajukan(tx, c, &pengajuan); err != nil {
+			return err
+		}
+
+		var jenis models.JenisCuti
+		if err := tx.First(&jenis, pengajuan.JenisCutiID).Error; err != nil {
+			return err
+		}
+
+		jadwal = models.JadwalCuti{
+			Title:  fmt.Sprintf("%s - %s", jenis.Nama, pengajuan.Pemohon),
+			Start:  pengajuan.TanggalMulai.Format("2006-01-02"),
+			End:    pengajuan.TanggalSelesai.AddDate(0, 0, 1).Format("2006-01-02"), // end all day eksklusif seperti FullCalendar
+			AllDay: true,
+			Color:  jenis.Color,
+		}
+		if err := tx.Create(&jadwal).Error; err != nil {
+			return err
+		}
+
+		putuskanPengajuanCuti(c, &pengajuan, statusCutiDisetujui, req.Catatan)
+		pengajuan.JadwalCutiID = &jadwal.ID
+		return tx.Save(&pengajuan).Error
+	})
+	if err != nil {
+		respondPengajuanCutiError(c, err)
+		return
+	}
+
+	mulai := time.Date(pengajuan.TanggalMulai.Year(), pengajuan.TanggalMulai.Month(), pengajuan.TanggalMulai.Day(), 0, 0, 0, 0, jakartaLocation())
+	SetNotificationEvent(jadwal.Title, mulai, "JadwalCuti", sumberJadwalCuti, jadwal.ID)
+	createNotificationUntuk(fmt.Sprintf("Pengajuan cuti %s tanggal %s disetujui", pengajuan.Pemohon, jadwal.Start), time.Now(), "PengajuanCuti", &pengajuan.UserID)
+	emitWebhook(eventPengajuanCutiDiputus, &pengajuan)
+	c.JSON(http.StatusOK, gin.H{"pengajuan_cuti": &pengajuan})
+}
+
+func PengajuanCutiTolak(c *gin.Context) {
+	var req KeputusanCutiRequest
+	_ = c.ShouldBindJSON(&req)
+
+	var pengajuan models.PengajuanCuti
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := findPengajuanCutiDiajukan(tx, c, &pengajuan); err != nil {
+			return err
+		}
+		putuskanPengajuanCuti(c, &pengajuan, statusCutiDitolak, req.Catatan)
+		return tx.Save(&pengajuan).Error
+	})
+	if err != nil {
+		respondPengajuanCutiError(c, err)
+		return
+	}
+
+	createNotificationUntuk(fmt.Sprintf("Pengajuan cuti %s tanggal %s ditolak", pengajuan.Pemohon, pengajuan.TanggalMulai.Format("2006-01-02")), time.Now(), "PengajuanCuti", &pengajuan.UserID)
+	emitWebhook(eventPengajuanCutiDiputus, &pengajuan)
+	c.JSON(http.StatusOK, gin.H{"pengajuan_cuti": &pengajuan})
+}
+
+// PengajuanCutiBatalkan dipakai pemohon; cuti yang sudah disetujui dihapus dari kalender dan kuotanya kembali
+func PengajuanCutiBatalkan(c *gin.Context) {
+	var pengajuan models.PengajuanCuti
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&pengajuan, c.Param("id")).Error; err != nil {
+			return errPengajuanCuti{"Pengajuan cuti tidak ditemukan"}
+		}
+		if pengajuan.UserID != c.MustGet("userID").(uint) {
+			return errPengajuanCuti{"Hanya pemohon yang dapat membatalkan pengajuan"}
+		}
+		if pengajuan.Status != statusCutiDiajukan && pengajuan.Status != statusCutiDisetujui {
+			return errPengajuanCuti{fmt.Sprintf("Pengajuan dengan status %s tidak dapat dibatalkan", pengajuan.Status)}
+		}
+		// Cuti disetujui yang sudah berjalan tidak bisa dibatalkan sepihak, kuotanya sudah terpakai
+		if pengajuan.Status == statusCutiDisetujui && !cutiBelumDimulai(pengajuan, time.Now()) {
+			return errPengajuanCuti{"Cuti yang sudah disetujui dan sudah dimulai tidak dapat dibatalkan"}
+		}
+
+		if pengajuan.JadwalCutiID != nil {
+			if err := tx.Delete(&models.JadwalCuti{}, *pengajuan.JadwalCutiID).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("sumber = ? AND event_id = ?", sumberJadwalCuti, *pengajuan.JadwalCutiID).Delete(&models.PengecualianJadwal{}).Error; err != nil {
+				return err
+			}
+			if err := batalkanPengingatEvent(tx, sumberJadwalCuti, *pengajuan.JadwalCutiID); err != nil {
+				return err
+			}
+			pengajuan.JadwalCutiID = nil
+		}
+		pengajuan.Status = statusCutiDibatalkan
+		return tx.Save(&pengajuan).Error
+	})
+	if err != nil {
+		respondPengajuanCutiError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"pengajuan_cuti": &pengajuan})
+}
+
+// SaldoCutiSaya menampilkan saldo cuti user yang login, ?tahun= default tahun berjalan
+func SaldoCutiSaya(c *gin.Context) {
+	respondSaldoCuti(c, c.MustGet("userID").(uint))
+}
+
+func SaldoCutiUser(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id tidak valid"})
+		return
+	}
+	if !bolehLihatCutiUser(c, uint(userID)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Anda tidak berhak melihat saldo cuti user ini"})
+		return
+	}
+	respondSaldoCuti(c, uint(userID))
+}
+
+func respondSaldoCuti(c *gin.Context, userID uint) {
+	tahun := time.Now().In(jakartaLocation()).Year()
+	if t, err := strconv.Atoi(c.Query("tahun")); err == nil {
+		tahun = t
+	}
+
+	var user models.User
+	if err := initializers.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User tidak ditemukan"})
+		return
+	}
+
+	var jenis []models.JenisCuti
+	if err := initializers.DB.Where("aktif = ?", true).Order("id asc").Find(&jenis).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	saldo := []saldoCuti{}
+	for _, j := range jenis {
+		s, err := hitungSaldoCuti(initializers.DB, user.ID, j, tahun)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		saldo = append(saldo, s)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user_id":  user.ID,
+		"username": user.Username,
+		"tahun":    tahun,
+		"saldo":    saldo,
+	})
+}
+
+// validasiPengajuanCuti mengecek rentang tanggal dan bentrok dengan pengajuan lain, lalu mengembalikan jumlah hari kerja
+func validasiPengajuanCuti(tx *gorm.DB, userID, kecualiID uint, mulai, selesai time.Time) (int, error) {
+	if err := cekRentangCuti(mulai, selesai); err != nil {
+		return 0, err
+	}
+	jumlah, err := hitungHariKerja(tx, mulai, selesai)
+	if err != nil {
+		return 0, err
+	}
+	if jumlah == 0 {
+		return 0, errPengajuanCuti{"Rentang tanggal tidak mengandung hari kerja"}
+	}
+
+	var bentrok int64
+	if err := tx.Model(&models.PengajuanCuti{}).
+		Where("user_id = ? AND id <> ?", userID, kecualiID).
+		Where("status IN ?", []string{statusCutiDiajukan, statusCutiDisetujui}).
+		Where("tanggal_mulai <= ? AND tanggal_selesai >= ?", selesai, mulai).
+		Count(&bentrok).Error; err != nil {
+		return 0, err
+	}
+	if bentrok > 0 {
+		return 0, errPengajuanCuti{"Tanggal cuti bentrok dengan pengajuan lain yang masih aktif"}
+	}
+	return jumlah, nil
+}
+
+// hitungSaldoCuti menghitung kuota, pemakaian dan pengajuan yang masih menunggu untuk satu jenis cuti.
+// Pengajuan yang menunggu ikut mengurangi sisa supaya tidak bisa diajukan melebihi kuota.
+func hitungSaldoCuti(tx *gorm.DB, userID uint, jenis models.JenisCuti, tahun int) (saldoCuti, error) {
+	saldo := saldoCuti{
+		JenisCutiID: jenis.ID,
+		Kode:        jenis.Kode,
+		Nama:        jenis.Nama,
+		Dibatasi:    jenis.KuotaTahunan > 0,
+		Kuota:       jenis.KuotaTahunan,
+	}
+
+	var kuota models.KuotaCuti
+	err := tx.Where("user_id = ? AND jenis_cuti_id = ? AND tahun = ?", userID, jenis.ID, tahun).First(&kuota).Error
+	if err == nil {
+		saldo.Dibatasi = true
+		saldo.Kuota = kuota.Kuota
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return saldo, err
+	}
+
+	dari, sampai := rentangTahunCuti(tahun)
+	var rekap []struct {
+		Status string
+		Jumlah int
+	}
+	if err := tx.Model(&models.PengajuanCuti{}).
+		Select("status, COALESCE(SUM(jumlah_hari), 0) AS jumlah").
+		Where("user_id = ? AND jenis_cuti_id = ?", userID, jenis.ID).
+		Where("tanggal_mulai >= ? AND tanggal_mulai < ?", dari, sampai).
+		Where("status IN ?", []string{statusCutiDiajukan, statusCutiDisetujui}).
+		Group("status").
+		Scan(&rekap).Error; err != nil {
+		return saldo, err
+	}
+	for _, r := range rekap {
+		if r.Status == statusCutiDisetujui {
+			saldo.Terpakai = r.Jumlah
+		} else {
+			saldo.Diajukan = r.Jumlah
+		}
+	}
+
+	if saldo.Dibatasi {
+		saldo.Sisa = saldo.Kuota - saldo.Terpakai - saldo.Diajukan
+	}
+	return saldo, nil
+}
+
+// cekRentangCuti: kuota dihitung per tahun, jadi satu pengajuan tidak boleh melewati pergantian tahun
+func cekRentangCuti(mulai, selesai time.Time) error {
+	if selesai.Before(mulai) {
+		return errPengajuanCuti{"Tanggal selesai tidak boleh sebelum tanggal mulai"}
+	}
+	if mulai.Year() != selesai.Year() {
+		return errPengajuanCuti{"Pengajuan cuti lintas tahun harus dipecah per tahun"}
+	}
+	return nil
+}
+
+// cekKuotaCuti menolak pengajuan yang melebihi sisa kuota; jenis cuti tanpa kuota selalu lolos
+func cekKuotaCuti(saldo saldoCuti, tahun, jumlah int) error {
+	if saldo.Dibatasi && jumlah > saldo.Sisa {
+		return errPengajuanCuti{fmt.Sprintf("Sisa kuota %s tahun %d tinggal %d hari, pengajuan %d hari", saldo.Nama, tahun, saldo.Sisa, jumlah)}
+	}
+	return nil
+}
+
+func rentangTahunCuti(tahun int) (time.Time, time.Time) {
+	dari := time.Date(tahun, time.January, 1, 0, 0, 0, 0, time.UTC)
+	return dari, dari.AddDate(1, 0, 0)
+}
+
+// findPengajuanCutiDiajukan mengunci pengajuan yang masih menunggu dan memastikan user yang login berhak memutuskan.
+// Bila pemohon belum punya atasan, pengajuan diputuskan oleh admin.
+func findPengajuanCutiDiajukan(tx *gorm.DB, c *gin.Context, pengajuan *models.PengajuanCuti) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(pengajuan, c.Param("id")).Error; err != nil {
+		return errPengajuanCuti{"Pengajuan cuti tidak ditemukan"}
+	}
+	if pengajuan.Status != statusCutiDiajukan {
+		return errPengajuanCuti{fmt.Sprintf("Pengajuan sudah berstatus %s", pengajuan.Status)}
+	}
+
+	if pesan := cekPemutusPengajuan(c, pengajuan.UserID, pengajuan.AtasanID); pesan != "" {
+		return errPengajuanCuti{pesan}
+	}
+	return nil
+}
+
+// bolehLihatCutiUser: data cuti seorang user terlihat oleh user itu sendiri, atasan langsungnya, dan admin
+func bolehLihatCutiUser(c *gin.Context, userID uint) bool {
+	saya := c.MustGet("userID").(uint)
+	if userID == saya || middleware.HasRole(c, roleAdmin) {
+		return true
+	}
+	var jumlah int64
+	initializers.DB.Model(&models.User{}).Where("id = ? AND atasan_id = ?", userID, saya).Count(&jumlah)
+	return jumlah > 0
+}
+
+// scopeCutiTerlihat membatasi kuota dan pengajuan cuti ke milik sendiri dan bawahan langsung; admin melihat semua.
+// Pengajuan juga terlihat oleh atasan yang tercatat saat diajukan (atasanPengajuan), walau atasan user sudah berganti.
+func scopeCutiTerlihat(c *gin.Context, atasanPengajuan bool) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if middleware.HasRole(c, roleAdmin) {
+			return db
+		}
+		saya := c.MustGet("userID").(uint)
+		bawahan := initializers.DB.Model(&models.User{}).Select("id").Where("atasan_id = ?", saya)
+		if atasanPengajuan {
+			return db.Where("user_id = ? OR user_id IN (?) OR atasan_id = ?", saya, bawahan, saya)
+		}
+		return db.Where("user_id = ? OR user_id IN (?)", saya, bawahan)
+	}
+}
+
+// cutiBelumDimulai bernilai true bila tanggal mulai cuti masih setelah hari ini (WIB)
+func cutiBelumDimulai(pengajuan models.PengajuanCuti, now time.Time) bool {
+	if pengajuan.TanggalMulai == nil {
+		return false
+	}
+	n := now.In(jakartaLocation())
+	hariIni := time.Date(n.Year(), n.Month(), n.Day(), 0, 0, 0, 0, time.UTC)
+	return truncateToDate(*pengajuan.TanggalMulai).After(hariIni)
+}
+
+// cekPemutusPengajuan dipakai pengajuan yang diputuskan atasan (cuti, penyelesaian perdin): pemohon tidak
+// boleh memutuskan sendiri, dan bila pemohon tidak punya atasan hanya admin yang boleh. Kembalian kosong berarti boleh.
+func cekPemutusPengajuan(c *gin.Context, pemohonID uint, atasanID *uint) string {
+	userID := c.MustGet("userID").(uint)
+	if pemohonID == userID {
+		return "Pengajuan tidak dapat diputuskan oleh pemohon sendiri"
+	}
+	if atasanID == nil {
+		if !middleware.HasRole(c, roleAdmin) {
+			return "Pemohon belum memiliki atasan, pengajuan hanya dapat diputuskan oleh admin"
+		}
+		return ""
+	}
+	if *atasanID != userID {
+		return "Hanya atasan pemohon yang dapat memutuskan pengajuan ini"
+	}
+	return ""
+}
+
+func putuskanPengajuanCuti(c *gin.Context, pengajuan *models.PengajuanCuti, status string, catatan *string) {
+	now := time.Now()
+	oleh := c.MustGet("username").(string)
+	pengajuan.Status = status
+	pengajuan.DiputuskanOleh = &oleh
+	pengajuan.TanggalKeputusan = &now
+	pengajuan.CatatanAtasan = catatan
+}
+
+func respondPengajuanCutiError(c *gin.Context, err error) {
+	var errValidasi errPengajuanCuti
+	if errors.As(err, &errValidasi) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errValidasi.pesan})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
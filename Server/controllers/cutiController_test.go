@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"net/http/httptest"
+	"project-its/models"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func tglCuti(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestCekRentangCuti(t *testing.T) {
+	tests := []struct {
+		nama    string
+		mulai   time.Time
+		selesai time.Time
+		ok      bool
+	}{
+		{"satu hari", tglCuti(2024, time.March, 4), tglCuti(2024, time.March, 4), true},
+		{"akhir tahun sampai 31 Desember", tglCuti(2024, time.December, 23), tglCuti(2024, time.December, 31), true},
+		{"awal tahun mulai 1 Januari", tglCuti(2025, time.January, 1), tglCuti(2025, time.January, 3), true},
+		{"melewati pergantian tahun", tglCuti(2024, time.December, 30), tglCuti(2025, time.January, 2), false},
+		{"selesai sebelum mulai", tglCuti(2024, time.March, 5), tglCuti(2024, time.March, 4), false},
+	}
+
+	for _, tt := range tests {
+		err := cekRentangCuti(tt.mulai, tt.selesai)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: cekRentangCuti = %v, ingin lolos %v", tt.nama, err, tt.ok)
+		}
+		if _, validasi := err.(errPengajuanCuti); err != nil && !validasi {
+			t.Errorf("%s: kesalahan %T bukan errPengajuanCuti", tt.nama, err)
+		}
+	}
+}
+
+func TestCekKuotaCuti(t *testing.T) {
+	tahunan := saldoCuti{Nama: "Cuti Tahunan", Dibatasi: true, Kuota: 12, Terpakai: 8, Diajukan: 2, Sisa: 2}
+	tests := []struct {
+		nama   string
+		saldo  saldoCuti
+		jumlah int
+		ok     bool
+	}{
+		{"pas sisa kuota", tahunan, 2, true},
+		{"pengajuan menunggu ikut mengurangi sisa", tahunan, 3, false},
+		{"kuota habis", saldoCuti{Nama: "Cuti Khusus", Dibatasi: true, Kuota: 3, Terpakai: 3}, 1, false},
+		{"jenis tanpa kuota", saldoCuti{Nama: "Cuti Sakit"}, 30, true},
+	}
+
+	for _, tt := range tests {
+		if err := cekKuotaCuti(tt.saldo, 2024, tt.jumlah); (err == nil) != tt.ok {
+			t.Errorf("%s: cekKuotaCuti = %v, ingin lolos %v", tt.nama, err, tt.ok)
+		}
+	}
+}
+
+func TestRentangTahunCuti(t *testing.T) {
+	dari, sampai := rentangTahunCuti(2024)
+	tests := []struct {
+		tanggal time.Time
+		want    bool
+	}{
+		{tglCuti(2024, time.January, 1), true},
+		{tglCuti(2024, time.December, 31), true},
+		{tglCuti(2023, time.December, 31), false},
+		{tglCuti(2025, time.January, 1), false},
+	}
+
+	for _, tt := range tests {
+		if got := !tt.tanggal.Before(dari) && tt.tanggal.Before(sampai); got != tt.want {
+			t.Errorf("%s masuk kuota 2024 = %v, ingin %v", tt.tanggal.Format("2006-01-02"), got, tt.want)
+		}
+	}
+}
+
+func TestCutiBelumDimulai(t *testing.T) {
+	mulai := tglCuti(2024, time.March, 5)
+	pengajuan := models.PengajuanCuti{TanggalMulai: &mulai}
+	tests := []struct {
+		nama string
+		now  time.Time
+		want bool
+	}{
+		{"sehari sebelumnya", time.Date(2024, time.March, 4, 9, 0, 0, 0, time.UTC), true},
+		{"sudah tanggal mulai di WIB walau UTC masih kemarin", time.Date(2024, time.March, 4, 18, 0, 0, 0, time.UTC), false},
+		{"hari mulai", time.Date(2024, time.March, 5, 3, 0, 0, 0, time.UTC), false},
+		{"sudah berjalan", time.Date(2024, time.March, 6, 3, 0, 0, 0, time.UTC), false},
+	}
+
+	for _, tt := range tests {
+		if got := cutiBelumDimulai(pengajuan, tt.now); got != tt.want {
+			t.Errorf("%s: cutiBelumDimulai = %v, ingin %v", tt.nama, got, tt.want)
+		}
+	}
+	if cutiBelumDimulai(models.PengajuanCuti{}, time.Now()) {
+		t.Errorf("pengajuan tanpa tanggal mulai dianggap belum dimulai")
+	}
+}
+
+func TestCekPemutusPengajuan(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	atasan := uint(2)
+	tests := []struct {
+		nama      string
+		userID    uint
+		role      string
+		pemohonID uint
+		atasanID  *uint
+		boleh     bool
+	}{
+		{"atasan pemohon", 2, "user", 1, &atasan, true},
+		{"pemohon sendiri", 1, "user", 1, &atasan, false},
+		{"admin memutuskan pengajuannya sendiri", 1, roleAdmin, 1, nil, false},
+		{"bukan atasan pemohon", 3, "user", 1, &atasan, false},
+		{"admin bukan atasan pemohon", 3, roleAdmin, 1, &atasan, false},
+		{"pemohon tanpa atasan diputus admin", 3, roleAdmin, 1, nil, true},
+		{"pemohon tanpa atasan bukan admin", 3, "user", 1, nil, false},
+	}
+
+	for _, tt := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Set("userID", tt.userID)
+		c.Set("role", tt.role)
+		if pesan := cekPemutusPengajuan(c, tt.pemohonID, tt.atasanID); (pesan == "") != tt.boleh {
+			t.Errorf("%s: cekPemutusPengajuan = %q, ingin boleh %v", tt.nama, pesan, tt.boleh)
+		}
+	}
+}
@@ -0,0 +1,581 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"project-its/initializers"
+	"project-its/middleware"
+	"project-its/models"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+const (
+	statusDisposisiDikirim  = "Dikirim"
+	statusDisposisiDiterima = "Diterima"
+	statusDisposisiDiproses = "Diproses"
+	statusDisposisiSelesai  = "Selesai"
+)
+
+// tujuan disposisi bisa berupa divisi atau user tertentu
+type disposisiTujuan struct {
+	Div    *string `json:"div"`
+	UserID *uint   `json:"user_id"`
+}
+
+type DisposisiRequest struct {
+	Tujuan     []disposisiTujuan `json:"tujuan"`
+	Instruksi  *string           `json:"instruksi"`
+	BatasWaktu *string           `json:"batas_waktu"`
+}
+
+type disposisiProgressRequest struct {
+	TindakLanjut *string `json:"tindak_lanjut"`
+	Laporan      *string `json:"laporan"`
+}
+
+// disposisiNode dipakai untuk menampilkan rantai disposisi beserta penerusannya
+type disposisiNode struct {
+	Disposisi *models.Disposisi `json:"disposisi"`
+	Teruskan  []disposisiNode   `json:"teruskan"`
+}
+
+func DisposisiIndex(c *gin.Context) {
+	id := c.Param("id")
+
+	var suratMasuk models.SuratMasuk
+	if err := initializers.DB.First(&suratMasuk, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Surat masuk tidak ditemukan"})
+		return
+	}
+
+	var disposisi []models.Disposisi
+	if err := initializers.DB.Where("surat_masuk_id = ?", suratMasuk.ID).Order("id asc").Find(&disposisi).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"SuratMasuk": &suratMasuk,
+		"disposisi":  buildDisposisiTree(disposisi),
+	})
+}
+
+// DisposisiInbox menampilkan disposisi yang ditujukan ke user yang sedang login atau ke divisinya
+func DisposisiInbox(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	query := initializers.DB.Where("tujuan_user_id = ?", userID)
+	if div := divisiUser(userID); div != "" {
+		query = query.Or("tujuan_user_id IS NULL AND LOWER(TRIM(tujuan_div)) = LOWER(?)", div)
+	}
+	var disposisi []models.Disposisi
+	if err := query.Order("id desc").Find(&disposisi).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"disposisi": disposisi})
+}
+
+func DisposisiShow(c *gin.Context) {
+	id := c.Param("id")
+
+	var disposisi models.Disposisi
+	if err := initializers.DB.First(&disposisi, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Disposisi tidak ditemukan"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"disposisi": &disposisi})
+}
+
+func DisposisiCreate(c *gin.Context) {
+	var requestBody DisposisiRequest
+	if err := c.BindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	id := c.Param("id")
+	var suratMasuk models.SuratMasuk
+	if err := initializers.DB.First(&suratMasuk, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Surat masuk tidak ditemukan"})
+		return
+	}
+	// Disposisi pertama diberikan oleh kepala divisi tujuan surat, atau admin
+	if !middleware.HasRole(c, roleAdmin) && !kepalaDivisi(c.MustGet("userID").(uint), derefString(suratMasuk.DestinyDiv)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Hanya kepala divisi tujuan surat atau admin yang dapat membuat disposisi"})
+		return
+	}
+
+	disposisi, status, err := buatDisposisi(suratMasuk, nil, requestBody, c.MustGet("username").(string))
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"disposisi": disposisi})
+}
+
+// DisposisiTeruskan meneruskan disposisi yang sudah diterima ke divisi/user lain
+func DisposisiTeruskan(c *gin.Context) {
+	var requestBody DisposisiRequest
+	if err := c.BindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	parent, ok := findDisposisiPenerima(c)
+	if !ok {
+		return
+	}
+	if parent.Status != statusDisposisiDiterima && parent.Status != statusDisposisiDiproses {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Disposisi harus diterima terlebih dahulu sebelum diteruskan"})
+		return
+	}
+
+	var suratMasuk models.SuratMasuk
+	if err := initializers.DB.First(&suratMasuk, parent.SuratMasukID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Surat masuk tidak ditemukan"})
+		return
+	}
+
+	disposisi, status, err := buatDisposisi(suratMasuk, &parent.ID, requestBody, c.MustGet("username").(string))
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"disposisi": disposisi})
+}
+
+func DisposisiTerima(c *gin.Context) {
+	disposisi, ok := findDisposisiPenerima(c)
+	if !ok {
+		return
+	}
+	if disposisi.Status != statusDisposisiDikirim {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Disposisi sudah diterima"})
+		return
+	}
+
+	now := time.Now()
+	disposisi.Status = statusDisposisiDiterima
+	disposisi.TanggalDiterima = &now
+	if err := initializers.DB.Save(&disposisi).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan disposisi"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"disposisi": &disposisi})
+}
+
+func DisposisiTindakLanjut(c *gin.Context) {
+	var requestBody disposisiProgressRequest
+	if err := c.BindJSON(&requestBody); err != nil || requestBody.TindakLanjut == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "tindak_lanjut harus diisi"})
+		return
+	}
+
+	disposisi, ok := findDisposisiPenerima(c)
+	if !ok {
+		return
+	}
+	if disposisi.Status != statusDisposisiDiterima && disposisi.Status != statusDisposisiDiproses {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Disposisi tidak dapat ditindaklanjuti pada status " + disposisi.Status})
+		return
+	}
+
+	disposisi.Status = statusDisposisiDiproses
+	disposisi.TindakLanjut = requestBody.TindakLanjut
+	if err := initializers.DB.Save(&disposisi).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan disposisi"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"disposisi": &disposisi})
+}
+
+// DisposisiLaporan mencatat laporan balik penerima dan menutup disposisi
+func DisposisiLaporan(c *gin.Context) {
+	var requestBody disposisiProgressRequest
+	if err := c.BindJSON(&requestBody); err != nil || requestBody.Laporan == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "laporan harus diisi"})
+		return
+	}
+
+	disposisi, ok := findDisposisiPenerima(c)
+	if !ok {
+		return
+	}
+	if disposisi.Status != statusDisposisiDiterima && disposisi.Status != statusDisposisiDiproses {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Disposisi tidak dapat dilaporkan pada status " + disposisi.Status})
+		return
+	}
+
+	now := time.Now()
+	disposisi.Status = statusDisposisiSelesai
+	disposisi.Laporan = requestBody.Laporan
+	disposisi.TanggalSelesai = &now
+	if requestBody.TindakLanjut != nil {
+		disposisi.TindakLanjut = requestBody.TindakLanjut
+	}
+	if err := initializers.DB.Save(&disposisi).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan disposisi"})
+		return
+	}
+
+	// Laporan dikirim ke pemberi disposisi
+	if pemberi := userIDUsername(disposisi.Dari); pemberi != nil {
+		createNotificationUntuk(fmt.Sprintf("Laporan disposisi dari %s: %s", disposisiTujuanLabel(disposisi, nil), *disposisi.Laporan), now, "Disposisi", pemberi)
+	}
+	c.JSON(http.StatusOK, gin.H{"disposisi": &disposisi})
+}
+
+func DisposisiDelete(c *gin.Context) {
+	id := c.Param("id")
+
+	var disposisi models.Disposisi
+	if err := initializers.DB.First(&disposisi, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Disposisi tidak ditemukan"})
+		return
+	}
+	if disposisi.Dari != c.MustGet("username").(string) && !middleware.HasRole(c, roleAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Hanya pemberi disposisi atau admin yang dapat menghapus"})
+		return
+	}
+	if disposisi.Status != statusDisposisiDikirim {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Disposisi yang sudah diterima tidak dapat dihapus"})
+		return
+	}
+
+	if err := initializers.DB.Delete(&disposisi).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghapus disposisi"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// buatDisposisi membuat satu disposisi untuk setiap tujuan dalam satu transaksi
+func buatDisposisi(suratMasuk models.SuratMasuk, parentID *uint, requestBody DisposisiRequest, username string) ([]models.Disposisi, int, error) {
+	if len(requestBody.Tujuan) == 0 {
+		return nil, http.StatusBadRequest, fmt.Errorf("tujuan disposisi harus diisi")
+	}
+
+	var batasWaktu *time.Time
+	if requestBody.BatasWaktu != nil && *requestBody.BatasWaktu != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", *requestBody.BatasWaktu, jakartaLocation())
+		if err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("Invalid date format: %v", err)
+		}
+		batasWaktu = &parsed
+	}
+
+	var disposisi []models.Disposisi
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		for _, tujuan := range requestBody.Tujuan {
+			if (tujuan.Div == nil || *tujuan.Div == "") && tujuan.UserID == nil {
+				return fmt.Errorf("setiap tujuan harus berisi div atau user_id")
+			}
+			if tujuan.UserID != nil {
+				var user models.User
+				if err := tx.First(&user, *tujuan.UserID).Error; err != nil {
+					return fmt.Errorf("user %d tidak ditemukan", *tujuan.UserID)
+				}
+			}
+
+			d := models.Disposisi{
+				SuratMasukID: suratMasuk.ID,
+				ParentID:     parentID,
+				Dari:         username,
+				TujuanDiv:    tujuan.Div,
+				TujuanUserID: tujuan.UserID,
+				Instruksi:    requestBody.Instruksi,
+				BatasWaktu:   batasWaktu,
+				Status:       statusDisposisiDikirim,
+				CreateBy:     username,
+			}
+			if err := tx.Create(&d).Error; err != nil {
+				return err
+			}
+			disposisi = append(disposisi, d)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error creating disposisi: %v", err)
+		return nil, http.StatusBadRequest, err
+	}
+
+	for _, d := range disposisi {
+		title := fmt.Sprintf("Disposisi %s untuk %s", derefString(suratMasuk.NoSurat), disposisiTujuanLabel(d, nil))
+		for _, penerima := range penerimaDisposisi(d) {
+			penerima := penerima
+			if d.BatasWaktu != nil {
+				SetNotificationUntuk(title, *d.BatasWaktu, "Disposisi", &penerima)
+			} else {
+				createNotificationUntuk(title, time.Now(), "Disposisi", &penerima)
+			}
+		}
+	}
+
+	return disposisi, http.StatusCreated, nil
+}
+
+// findDisposisiPenerima mengambil disposisi dari parameter id dan memastikan user yang login adalah penerimanya
+func findDisposisiPenerima(c *gin.Context) (models.Disposisi, bool) {
+	var disposisi models.Disposisi
+	if err := initializers.DB.First(&disposisi, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Disposisi tidak ditemukan"})
+		return disposisi, false
+	}
+
+	userID := c.MustGet("userID").(uint)
+	ditujukan := false
+	if disposisi.TujuanUserID != nil {
+		ditujukan = *disposisi.TujuanUserID == userID
+	} else if disposisi.TujuanDiv != nil {
+		div := divisiUser(userID)
+		ditujukan = div != "" && strings.EqualFold(div, strings.TrimSpace(*disposisi.TujuanDiv))
+	}
+	if !ditujukan {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Disposisi ini tidak ditujukan kepada Anda"})
+		return disposisi, false
+	}
+	return disposisi, true
+}
+
+// divisiUser mengambil divisi user dari database, bukan dari token, agar perpindahan divisi langsung berlaku
+func divisiUser(userID uint) string {
+	var user models.User
+	if err := initializers.DB.Select("div").First(&user, userID).Error; err != nil {
+		return ""
+	}
+	return strings.TrimSpace(user.Div)
+}
+
+// kepalaDivisi bernilai true bila user anggota divisi div dan tidak beratasan di divisi yang sama,
+// yaitu puncak rantai atasan divisi tersebut
+func kepalaDivisi(userID uint, div string) bool {
+	div = strings.TrimSpace(div)
+	var user models.User
+	if div == "" || initializers.DB.Select("id", "div", "atasan_id").First(&user, userID).Error != nil {
+		return false
+	}
+	if !strings.EqualFold(strings.TrimSpace(user.Div), div) {
+		return false
+	}
+	if user.AtasanID == nil {
+		return true
+	}
+	var atasan models.User
+	if err := initializers.DB.Select("id", "div").First(&atasan, *user.AtasanID).Error; err != nil {
+		return true
+	}
+	return !strings.EqualFold(strings.TrimSpace(atasan.Div), div)
+}
+
+// penerimaDisposisi mengembalikan user yang menerima disposisi: user tujuan, atau semua anggota divisi tujuan
+func penerimaDisposisi(d models.Disposisi) []uint {
+	if d.TujuanUserID != nil {
+		return []uint{*d.TujuanUserID}
+	}
+	if d.TujuanDiv == nil {
+		return nil
+	}
+	return anggotaDivisi(*d.TujuanDiv)
+}
+
+// anggotaDivisi mengembalikan id semua user di divisi div (tanpa membedakan huruf besar)
+func anggotaDivisi(div string) []uint {
+	var ids []uint
+	if div = strings.TrimSpace(div); div != "" {
+		initializers.DB.Model(&models.User{}).Where("LOWER(TRIM(div)) = LOWER(?)", div).Pluck("id", &ids)
+	}
+	return ids
+}
+
+func buildDisposisiTree(disposisi []models.Disposisi) []disposisiNode {
+	children := make(map[uint][]*models.Disposisi)
+	var roots []*models.Disposisi
+	for i := range disposisi {
+		d := &disposisi[i]
+		if d.ParentID == nil {
+			roots = append(roots, d)
+		} else {
+			children[*d.ParentID] = append(children[*d.ParentID], d)
+		}
+	}
+
+	var build func(list []*models.Disposisi) []disposisiNode
+	build = func(list []*models.Disposisi) []disposisiNode {
+		nodes := []disposisiNode{}
+		for _, d := range list {
+			nodes = append(nodes, disposisiNode{Disposisi: d, Teruskan: build(children[d.ID])})
+		}
+		return nodes
+	}
+	return build(roots)
+}
+
+// disposisiTujuanLabel menghasilkan nama tujuan disposisi, users boleh nil
+func disposisiTujuanLabel(d models.Disposisi, users map[uint]string) string {
+	var parts []string
+	if d.TujuanDiv != nil && *d.TujuanDiv != "" {
+		parts = append(parts, *d.TujuanDiv)
+	}
+	if d.TujuanUserID != nil {
+		if name, ok := users[*d.TujuanUserID]; ok {
+			parts = append(parts, name)
+		} else {
+			parts = append(parts, fmt.Sprintf("User #%d", *d.TujuanUserID))
+		}
+	}
+	return strings.Join(parts, " / ")
+}
+
+func jakartaLocation() *time.Location {
+	loc, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		log.Printf("Error loading location: %v", err)
+		return time.Local
+	}
+	return loc
+}
+
+// ExportDisposisiHandler menghasilkan lembar disposisi untuk satu surat masuk
+func ExportDisposisiHandler(c *gin.Context) {
+	id := c.Param("id")
+
+	var suratMasuk models.SuratMasuk
+	if err := initializers.DB.First(&suratMasuk, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Surat masuk tidak ditemukan"})
+		return
+	}
+
+	var disposisi []models.Disposisi
+	initializers.DB.Where("surat_masuk_id = ?", suratMasuk.ID).Order("id asc").Find(&disposisi)
+
+	var users []models.User
+	initializers.DB.Find(&users)
+	userNames := make(map[uint]string)
+	for _, user := range users {
+		userNames[user.ID] = user.Username
+	}
+
+	f := excelize.NewFile()
+	sheetName := "LEMBAR DISPOSISI"
+	f.NewSheet(sheetName)
+	f.DeleteSheet("Sheet1")
+
+	styleTitle, err := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true, Size: 16},
+		Alignment: &excelize.Alignment{Horizontal: "center", Vertical: "center"},
+	})
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Error creating style: %v", err)
+		return
+	}
+	styleHeader, err := f.NewStyle(&excelize.Style{
+		Fill: excelize.Fill{Type: "pattern", Color: []string{"#4F81BD"}, Pattern: 1},
+		Font: &excelize.Font{Bold: true, Size: 12, Color: "FFFFFF"},
+		Border: []excelize.Border{
+			{Type: "left", Color: "000000", Style: 1},
+			{Type: "top", Color: "000000", Style: 1},
+			{Type: "bottom", Color: "000000", Style: 1},
+			{Type: "right", Color: "000000", Style: 1},
+		},
+		Alignment: &excelize.Alignment{Horizontal: "center", Vertical: "center", WrapText: true},
+	})
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Error creating style: %v", err)
+		return
+	}
+	styleLabel, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Error creating style: %v", err)
+		return
+	}
+	styleData, err := f.NewStyle(&excelize.Style{
+		Border: []excelize.Border{
+			{Type: "left", Color: "000000", Style: 1},
+			{Type: "top", Color: "000000", Style: 1},
+			{Type: "bottom", Color: "000000", Style: 1},
+			{Type: "right", Color: "000000", Style: 1},
+		},
+		Alignment: &excelize.Alignment{Vertical: "top", WrapText: true},
+	})
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Error creating style: %v", err)
+		return
+	}
+
+	f.SetCellValue(sheetName, "A1", "LEMBAR DISPOSISI")
+	f.MergeCell(sheetName, "A1", "I1")
+	f.SetCellStyle(sheetName, "A1", "I1", styleTitle)
+	f.SetRowHeight(sheetName, 1, 30)
+
+	info := [][]string{
+		{"No Surat", derefString(suratMasuk.NoSurat)},
+		{"Perihal", derefString(suratMasuk.Title)},
+		{"Tanggal Surat", models.FormatTanggal(suratMasuk.Tanggal, "2 January 2006")},
+		{"Divisi Terkait", derefString(suratMasuk.RelatedDiv)},
+		{"Tujuan", derefString(suratMasuk.DestinyDiv)},
+	}
+	for i, row := range info {
+		rowNum := i + 3
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", rowNum), row[0])
+		f.SetCellValue(sheetName, fmt.Sprintf("C%d", rowNum), ": "+row[1])
+		f.SetCellStyle(sheetName, fmt.Sprintf("A%d", rowNum), fmt.Sprintf("A%d", rowNum), styleLabel)
+	}
+
+	headerRow := len(info) + 4
+	headers := []string{"No", "Dari", "Kepada", "Instruksi", "Batas Waktu", "Status", "Tgl Diterima", "Tindak Lanjut", "Laporan"}
+	for i, header := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, headerRow)
+		f.SetCellValue(sheetName, cell, header)
+	}
+	f.SetCellStyle(sheetName, fmt.Sprintf("A%d", headerRow), fmt.Sprintf("I%d", headerRow), styleHeader)
+	f.SetRowHeight(sheetName, headerRow, 20)
+
+	rowNum := headerRow + 1
+	var writeNodes func(nodes []disposisiNode, prefix string)
+	writeNodes = func(nodes []disposisiNode, prefix string) {
+		for i, node := range nodes {
+			d := node.Disposisi
+			nomor := fmt.Sprintf("%s%d", prefix, i+1)
+			values := []interface{}{
+				nomor,
+				d.Dari,
+				disposisiTujuanLabel(*d, userNames),
+				derefString(d.Instruksi),
+				models.FormatTanggal(d.BatasWaktu, "2 January 2006"),
+				d.Status,
+				models.FormatTanggal(d.TanggalDiterima, "2 January 2006"),
+				derefString(d.TindakLanjut),
+				derefString(d.Laporan),
+			}
+			f.SetSheetRow(sheetName, fmt.Sprintf("A%d", rowNum), &values)
+			f.SetCellStyle(sheetName, fmt.Sprintf("A%d", rowNum), fmt.Sprintf("I%d", rowNum), styleData)
+			rowNum++
+			writeNodes(node.Teruskan, nomor+".")
+		}
+	}
+	writeNodes(buildDisposisiTree(disposisi), "")
+
+	f.SetColWidth(sheetName, "A", "A", 8)
+	f.SetColWidth(sheetName, "B", "C", 20)
+	f.SetColWidth(sheetName, "D", "D", 40)
+	f.SetColWidth(sheetName, "E", "G", 15)
+	f.SetColWidth(sheetName, "H", "I", 40)
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Error saving file: %v", err)
+		return
+	}
+
+	c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=lembar_disposisi_%d.xlsx", suratMasuk.ID))
+	c.Writer.Write(buf.Bytes())
+}
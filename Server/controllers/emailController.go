@@ -0,0 +1,537 @@
+package controllers
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"project-its/initializers"
+	"project-its/models"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	statusEmailMenunggu = "Menunggu"
+	statusEmailDiproses = "Diproses"
+	statusEmailTerkirim = "Terkirim"
+	statusEmailGagal    = "Gagal"
+
+	maksPercobaanEmail = 8
+	batchEmail         = 20
+	leaseEmail         = 5 * time.Minute
+	timeoutSMTP        = 30 * time.Second
+)
+
+// konfigurasiSMTP dibaca dari env, SMTP_HOST kosong berarti kanal email nonaktif
+type konfigurasiSMTP struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+	TLS      string // none, starttls, tls
+}
+
+func muatKonfigurasiSMTP() konfigurasiSMTP {
+	cfg := konfigurasiSMTP{
+		Host:     strings.TrimSpace(os.Getenv("SMTP_HOST")),
+		Port:     strings.TrimSpace(os.Getenv("SMTP_PORT")),
+		Username: strings.TrimSpace(os.Getenv("SMTP_USERNAME")),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     strings.TrimSpace(os.Getenv("SMTP_FROM")),
+		TLS:      strings.ToLower(strings.TrimSpace(os.Getenv("SMTP_TLS"))),
+	}
+	if cfg.Port == "" {
+		cfg.Port = "25"
+	}
+	if cfg.TLS == "" {
+		cfg.TLS = "none"
+	}
+	if cfg.From == "" {
+		cfg.From = cfg.Username
+	}
+	return cfg
+}
+
+func (cfg konfigurasiSMTP) aktif() bool {
+	return cfg.Host != ""
+}
+
+// templateEmailKategori mengatur subjek dan kalimat pembuka email per kategori notifikasi
+type templateEmailKategori struct {
+	Label   string
+	Pembuka string
+}
+
+var templateEmailPerKategori = map[string]templateEmailKategori{
+	"JadwalCuti":               {"Jadwal Cuti", "Berikut jadwal cuti yang tercatat di kalender cuti."},
+	"BookingRapat":             {"Booking Ruang Rapat", "Berikut booking ruang rapat yang tercatat."},
+	"JadwalRapat":              {"Jadwal Rapat", "Berikut jadwal rapat yang tercatat."},
+	"TimelineWallpaperDesktop": {"Timeline Wallpaper Desktop", "Berikut jadwal pada timeline wallpaper desktop."},
+	"TimelineProject":          {"Timeline Project", "Berikut jadwal pada timeline project."},
+	kategoriRingkasanHarian:    {"Ringkasan Harian", "Berikut ringkasan kegiatan dan hal yang perlu Anda tindak lanjuti hari ini."},
+	"PengajuanCuti":            {"Persetujuan Cuti", "Ada pembaruan pada pengajuan cuti."},
+	"PenyelesaianPerdin":       {"Penyelesaian Perdin", "Ada pembaruan pada penyelesaian perjalanan dinas."},
+	"PeminjamanArsip":          {"Peminjaman Arsip", "Ada pembaruan pada peminjaman arsip."},
+	"PemusnahanArsip":          {"Pemusnahan Arsip", "Ada usulan pemusnahan arsip yang perlu ditinjau."},
+	"Disposisi":                {"Disposisi", "Ada pembaruan pada disposisi surat."},
+	"SuratMasukOverdue":        {"Surat Masuk Lewat Batas", "Surat masuk berikut sudah melewati batas waktu respon."},
+	"PeminjamanArsipOverdue":   {"Peminjaman Arsip Lewat Batas", "Peminjaman arsip berikut sudah melewati batas pengembalian."},
+	"MeetingOverdue":           {"Task Meeting Lewat Batas", "Task meeting berikut sudah melewati batas waktu."},
+	"RetensiArsip":             {"Retensi Arsip", "Arsip berikut sudah mencapai batas retensi."},
+}
+
+func templateEmailUntuk(category string) templateEmailKategori {
+	if t, ok := templateEmailPerKategori[category]; ok {
+		return t
+	}
+	return templateEmailKategori{Label: category, Pembuka: "Ada notifikasi baru untuk Anda."}
+}
+
+// dataEmailNotifikasi adalah data yang dipakai template subjek dan isi email
+type dataEmailNotifikasi struct {
+	Label     string
+	Pembuka   string
+	Title     string
+	Category  string
+	Waktu     string
+	Isi       string
+	Pengingat string // kosong bila bukan email pengingat, mis. "1 jam"
+}
+
+var templateSubjekEmail = texttemplate.Must(texttemplate.New("subjek").Parse(
+	`{{if .Pengingat}}[Pengingat {{.Pengingat}} lagi] {{else}}[{{.Label}}] {{end}}{{.Title}}`))
+
+var templateTeksEmail = texttemplate.Must(texttemplate.New("teks").Parse(`{{if .Pengingat}}Pengingat: kegiatan berikut dimulai {{.Pengingat}} lagi.{{else}}{{.Pembuka}}{{end}}
+
+{{.Label}}
+{{.Title}}
+{{if .Waktu}}Waktu: {{.Waktu}}
+{{end}}{{if .Isi}}
+{{.Isi}}
+{{end}}
+Email ini dikirim otomatis, mohon tidak dibalas.
+`))
+
+var templateHTMLEmail = htmltemplate.Must(htmltemplate.New("html").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: Arial, sans-serif; color: #222;">
+  <p>{{if .Pengingat}}Pengingat: kegiatan berikut dimulai <strong>{{.Pengingat}}</strong> lagi.{{else}}{{.Pembuka}}{{end}}</p>
+  <table cellpadding="6" style="border-collapse: collapse; border: 1px solid #ccc;">
+    <tr><td style="background: #f2f2f2;"><strong>{{.Label}}</strong></td></tr>
+    <tr><td>{{.Title}}</td></tr>
+    {{if .Waktu}}<tr><td>Waktu: {{.Waktu}}</td></tr>{{end}}
+  </table>
+  {{if .Isi}}<pre style="font-family: Arial, sans-serif; white-space: pre-wrap;">{{.Isi}}</pre>{{end}}
+  <p style="font-size: 12px; color: #888;">Email ini dikirim otomatis, mohon tidak dibalas.</p>
+</body>
+</html>
+`))
+
+// renderEmailNotifikasi menghasilkan subjek, isi teks, dan isi HTML untuk satu notifikasi
+func renderEmailNotifikasi(n models.Notification, pengingat string) (string, string, string, error) {
+	t := templateEmailUntuk(n.Category)
+	data := dataEmailNotifikasi{
+		Label:     t.Label,
+		Pembuka:   t.Pembuka,
+		Title:     n.Title,
+		Category:  n.Category,
+		Isi:       derefString(n.Isi),
+		Pengingat: pengingat,
+	}
+	if !n.Start.IsZero() {
+		data.Waktu = n.Start.In(jakartaLocation()).Format("02 January 2006 15:04") + " WIB"
+	}
+
+	var subjek, teks, html bytes.Buffer
+	if err := templateSubjekEmail.Execute(&subjek, data); err != nil {
+		return "", "", "", err
+	}
+	if err := templateTeksEmail.Execute(&teks, data); err != nil {
+		return "", "", "", err
+	}
+	if err := templateHTMLEmail.Execute(&html, data); err != nil {
+		return "", "", "", err
+	}
+	return subjek.String(), teks.String(), html.String(), nil
+}
+
+// alamatPenerimaNotifikasi mengambil email penerima notifikasi yang kanal email-nya langsung untuk kategori ini
+func alamatPenerimaNotifikasi(tx *gorm.DB, n models.Notification) ([]string, error) {
+	users, err := penggunaPenerimaNotifikasi(tx, n)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0, len(users))
+	for _, u := range users {
+		ids = append(ids, u.ID)
+	}
+	prefs, err := muatPreferensi(tx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var alamat []string
+	sudah := map[string]bool{}
+	for _, u := range users {
+		email := strings.TrimSpace(u.Email)
+		if email == "" || sudah[strings.ToLower(email)] || prefs[u.ID].mode(kanalEmail, n.Category) != modeLangsung {
+			continue
+		}
+		sudah[strings.ToLower(email)] = true
+		alamat = append(alamat, email)
+	}
+	return alamat, nil
+}
+
+// antreEmailNotifikasi memasukkan email notifikasi ke antrean; kunci dedup membuat pemanggilan ulang aman
+func antreEmailNotifikasi(tx *gorm.DB, n models.Notification, pengingat *models.PengingatNotifikasi) error {
+	if !muatKonfigurasiSMTP().aktif() {
+		return nil
+	}
+
+	alamat, err := alamatPenerimaNotifikasi(tx, n)
+	if err != nil || len(alamat) == 0 {
+		return err
+	}
+
+	labelPengingat := ""
+	kunci := fmt.Sprintf("notifikasi:%d", n.ID)
+	var notificationID, pengingatID *uint
+	if pengingat != nil {
+		labelPengingat = labelOffsetPengingat(pengingat.OffsetMenit)
+		kunci = fmt.Sprintf("pengingat:%d", pengingat.ID)
+		pengingatID = &pengingat.ID
+		if pengingat.NotificationID != 0 {
+			notificationID = &pengingat.NotificationID
+		}
+	} else if n.ID != 0 {
+		notificationID = &n.ID
+	}
+
+	subjek, teks, html, err := renderEmailNotifikasi(n, labelPengingat)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	email := make([]models.EmailKeluar, 0, len(alamat))
+	for _, kepada := range alamat {
+		email = append(email, models.EmailKeluar{
+			KunciDedup:     kunci + ":" + strings.ToLower(kepada),
+			NotificationID: notificationID,
+			PengingatID:    pengingatID,
+			Category:       n.Category,
+			Kepada:         kepada,
+			Subjek:         subjek,
+			IsiTeks:        teks,
+			IsiHTML:        html,
+			JadwalKirim:    now,
+			Status:         statusEmailMenunggu,
+			MaksPercobaan:  maksPercobaanEmail,
+		})
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&email, 200).Error
+}
+
+// kirimPengingatEmail adalah kanal pengingat yang mengantrekan email ke penerima notifikasi
+func kirimPengingatEmail(p models.PengingatNotifikasi) error {
+	n := models.Notification{
+		Title:    p.Title,
+		Start:    p.WaktuEvent,
+		Category: p.Category,
+		UserID:   p.UserID,
+		Role:     p.Role,
+	}
+	return antreEmailNotifikasi(initializers.DB, n, &p)
+}
+
+// ProsesEmailKeluar mengirim email yang sudah jatuh tempo dengan pola klaim yang sama dengan worker pengingat
+func ProsesEmailKeluar() {
+	cfg := muatKonfigurasiSMTP()
+	if !cfg.aktif() {
+		return
+	}
+	for {
+		email, err := klaimEmailKeluar()
+		if err != nil {
+			log.Printf("Error claiming outgoing emails: %v", err)
+			return
+		}
+		for _, e := range email {
+			selesaikanEmailKeluar(e, kirimEmailSMTP(cfg, e))
+		}
+		if len(email) < batchEmail {
+			return
+		}
+	}
+}
+
+func klaimEmailKeluar() ([]models.EmailKeluar, error) {
+	var email []models.EmailKeluar
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("(status = ? AND jadwal_kirim <= ?) OR (status = ? AND dikunci_sampai < ?)",
+				statusEmailMenunggu, now, statusEmailDiproses, now).
+			Order("jadwal_kirim").Limit(batchEmail).
+			Find(&email).Error; err != nil {
+			return err
+		}
+		if len(email) == 0 {
+			return nil
+		}
+		ids := make([]uint, 0, len(email))
+		for _, e := range email {
+			ids = append(ids, e.ID)
+		}
+		return tx.Model(&models.EmailKeluar{}).Where("id IN ?", ids).
+			Updates(map[string]interface{}{
+				"status":         statusEmailDiproses,
+				"dikunci_oleh":   workerPengingatID,
+				"dikunci_sampai": now.Add(leaseEmail),
+			}).Error
+	})
+	return email, err
+}
+
+func selesaikanEmailKeluar(e models.EmailKeluar, errKirim error) {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"percobaan":      e.Percobaan + 1,
+		"dikunci_oleh":   nil,
+		"dikunci_sampai": nil,
+	}
+	if errKirim == nil {
+		updates["status"] = statusEmailTerkirim
+		updates["terkirim_pada"] = now
+		updates["error_terakhir"] = nil
+	} else {
+		log.Printf("Error sending email %d to %s: %v", e.ID, e.Kepada, errKirim)
+		updates["error_terakhir"] = errKirim.Error()
+		if e.Percobaan+1 >= e.MaksPercobaan {
+			updates["status"] = statusEmailGagal
+		} else {
+			updates["status"] = statusEmailMenunggu
+			updates["jadwal_kirim"] = now.Add(backoffAntrean(e.Percobaan + 1))
+		}
+	}
+	if err := initializers.DB.Model(&models.EmailKeluar{}).
+		Where("id = ? AND status = ? AND dikunci_oleh = ?", e.ID, statusEmailDiproses, workerPengingatID).
+		Updates(updates).Error; err != nil {
+		log.Printf("Error updating email %d: %v", e.ID, err)
+	}
+}
+
+// susunPesanEmail menyusun pesan MIME multipart/alternative berisi versi teks dan HTML
+func susunPesanEmail(from string, e models.EmailKeluar) ([]byte, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, bagian := range []struct{ contentType, isi string }{
+		{"text/plain; charset=UTF-8", e.IsiTeks},
+		{"text/html; charset=UTF-8", e.IsiHTML},
+	} {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", bagian.contentType)
+		header.Set("Content-Transfer-Encoding", "quoted-printable")
+		pw, err := mw.CreatePart(header)
+		if err != nil {
+			return nil, err
+		}
+		qw := quotedprintable.NewWriter(pw)
+		if _, err := qw.Write([]byte(bagian.isi)); err != nil {
+			return nil, err
+		}
+		if err := qw.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", e.Kepada)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", e.Subjek))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "Message-ID: <email-%d-%d@%s>\r\n", e.ID, time.Now().UnixNano(), domainEmail(from))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", mw.Boundary())
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
+
+func domainEmail(alamat string) string {
+	if i := strings.LastIndex(alamat, "@"); i >= 0 {
+		return strings.Trim(alamat[i+1:], "> ")
+	}
+	return "localhost"
+}
+
+// kirimEmailSMTP mengirim satu email; SMTP_TLS=tls untuk TLS langsung (465), starttls untuk upgrade (587),
+// none untuk SMTP catcher lokal
+func kirimEmailSMTP(cfg konfigurasiSMTP, e models.EmailKeluar) error {
+	if cfg.From == "" {
+		return errors.New("SMTP_FROM belum diatur")
+	}
+	if strings.ContainsAny(e.Kepada+cfg.From, "\r\n") {
+		return errors.New("alamat email tidak valid")
+	}
+	msg, err := susunPesanEmail(cfg.From, e)
+	if err != nil {
+		return err
+	}
+
+	addr := net.JoinHostPort(cfg.Host, cfg.Port)
+	dialer := &net.Dialer{Timeout: timeoutSMTP}
+	var conn net.Conn
+	if cfg.TLS == "tls" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: cfg.Host})
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	_ = conn.SetDeadline(time.Now().Add(2 * timeoutSMTP))
+
+	client, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if cfg.TLS == "starttls" {
+		if err := client.StartTLS(&tls.Config{ServerName: cfg.Host}); err != nil {
+			return err
+		}
+	}
+	if cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(cfg.From); err != nil {
+		return err
+	}
+	if err := client.Rcpt(e.Kepada); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// GetEmailKeluar menampilkan antrean email, bisa disaring dengan ?status= dan ?kepada=
+func GetEmailKeluar(c *gin.Context) {
+	query := initializers.DB.Model(&models.EmailKeluar{}).Omit("isi_html", "isi_teks")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if kepada := c.Query("kepada"); kepada != "" {
+		query = query.Where("kepada = ?", kepada)
+	}
+
+	var email []models.EmailKeluar
+	if err := query.Order("created_at desc").Limit(500).Find(&email).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, email)
+}
+
+// UlangiEmailKeluar menjadwalkan ulang email yang gagal
+func UlangiEmailKeluar(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID tidak valid"})
+		return
+	}
+
+	var email models.EmailKeluar
+	if err := initializers.DB.First(&email, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Email tidak ditemukan"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if email.Status != statusEmailGagal {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Hanya email berstatus Gagal yang dapat dikirim ulang"})
+		return
+	}
+
+	if err := initializers.DB.Model(&email).Updates(map[string]interface{}{
+		"status":         statusEmailMenunggu,
+		"percobaan":      0,
+		"jadwal_kirim":   time.Now(),
+		"error_terakhir": nil,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	initializers.DB.First(&email, id)
+	c.JSON(http.StatusOK, email)
+}
+
+// KirimEmailUji mengirim email uji langsung (tanpa antrean) untuk memeriksa konfigurasi SMTP,
+// misalnya terhadap SMTP catcher lokal. Body: {"kepada": "...", "category": "JadwalCuti"}
+func KirimEmailUji(c *gin.Context) {
+	var req struct {
+		Kepada   string `json:"kepada"`
+		Category string `json:"category"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Kepada) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Alamat kepada wajib diisi"})
+		return
+	}
+	cfg := muatKonfigurasiSMTP()
+	if !cfg.aktif() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "SMTP_HOST belum diatur"})
+		return
+	}
+	if req.Category == "" {
+		req.Category = "JadwalCuti"
+	}
+
+	n := models.Notification{Title: "Email uji notifikasi", Start: time.Now(), Category: req.Category}
+	subjek, teks, html, err := renderEmailNotifikasi(n, "")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	email := models.EmailKeluar{Kepada: strings.TrimSpace(req.Kepada), Category: req.Category, Subjek: subjek, IsiTeks: teks, IsiHTML: html}
+	if err := kirimEmailSMTP(cfg, email); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Email uji terkirim", "kepada": email.Kepada, "subjek": subjek})
+}
@@ -7,7 +7,6 @@ import (
 	"path/filepath"
 	"project-its/initializers"
 	"project-its/models"
-	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -739,6 +738,12 @@ func ExportAllSheets(c *gin.Context) {
 					return
 				}
 
+				// Anggaran ditulis sebagai angka agar bisa dijumlah di Excel
+				styleAnggaran, err := newAnggaranStyle(f)
+				if err != nil {
+					return
+				}
+
 				// Loop through projects
 				for _, project := range projects {
 					// Dereference pointers if not nil
@@ -785,24 +790,7 @@ func ExportAllSheets(c *gin.Context) {
 						f.SetCellValue("PROJECT", fmt.Sprintf("E%d", rowIndex), bulan)
 						f.SetCellValue("PROJECT", fmt.Sprintf("F%d", rowIndex), sumberPendanaan)
 
-						if project.Anggaran != nil {
-							anggaran, err := strconv.ParseInt(*project.Anggaran, 10, 64)
-							if err != nil {
-								return
-							}
-							formattedAnggaran := fmt.Sprintf("Rp. %d", anggaran)
-							f.SetCellValue("PROJECT", fmt.Sprintf("G%d", rowIndex), formattedAnggaran)
-							styleAnggaran, err := f.NewStyle(&excelize.Style{
-								NumFmt: 3,
-							})
-							if err != nil {
-								return
-							}
-							err = f.SetCellStyle("PROJECT", fmt.Sprintf("G%d", rowIndex), fmt.Sprintf("G%d", rowIndex), styleAnggaran)
-							if err != nil {
-								return
-							}
-						}
+						f.SetCellValue("PROJECT", fmt.Sprintf("G%d", rowIndex), amountCellValue(project.Anggaran))
 
 						f.SetCellValue("PROJECT", fmt.Sprintf("H%d", rowIndex), noIzin)
 						f.SetCellValue("PROJECT", fmt.Sprintf("I%d", rowIndex), tanggalIzin)
@@ -812,6 +800,10 @@ func ExportAllSheets(c *gin.Context) {
 						if err != nil {
 							return
 						}
+						err = f.SetCellStyle("PROJECT", fmt.Sprintf("G%d", rowIndex), fmt.Sprintf("G%d", rowIndex), styleAnggaran)
+						if err != nil {
+							return
+						}
 						rowIndex++
 						lastRowSAG = rowIndex
 					}
@@ -826,24 +818,7 @@ func ExportAllSheets(c *gin.Context) {
 						f.SetCellValue("PROJECT", fmt.Sprintf("E%d", rowISO), bulan)
 						f.SetCellValue("PROJECT", fmt.Sprintf("F%d", rowISO), sumberPendanaan)
 
-						if project.Anggaran != nil {
-							anggaran, err := strconv.ParseInt(*project.Anggaran, 10, 64)
-							if err != nil {
-								return
-							}
-							formattedAnggaran := fmt.Sprintf("Rp. %d", anggaran)
-							f.SetCellValue("PROJECT", fmt.Sprintf("G%d", rowISO), formattedAnggaran)
-							styleAnggaran, err := f.NewStyle(&excelize.Style{
-								NumFmt: 3,
-							})
-							if err != nil {
-								return
-							}
-							err = f.SetCellStyle("PROJECT", fmt.Sprintf("G%d", rowISO), fmt.Sprintf("G%d", rowISO), styleAnggaran)
-							if err != nil {
-								return
-							}
-						}
+						f.SetCellValue("PROJECT", fmt.Sprintf("G%d", rowISO), amountCellValue(project.Anggaran))
 
 						f.SetCellValue("PROJECT", fmt.Sprintf("H%d", rowISO), noIzin)
 						f.SetCellValue("PROJECT", fmt.Sprintf("I%d", rowISO), tanggalIzin)
@@ -853,6 +828,10 @@ func ExportAllSheets(c *gin.Context) {
 						if err != nil {
 							return
 						}
+						err = f.SetCellStyle("PROJECT", fmt.Sprintf("G%d", rowISO), fmt.Sprintf("G%d", rowISO), styleAnggaran)
+						if err != nil {
+							return
+						}
 						rowIndex++
 					}
 				}
@@ -1050,7 +1029,7 @@ func ExportAllSheets(c *gin.Context) {
 				meetingSheetName := "MEETING"
 				for i, meeting := range meetings {
 					tanggalTargetString := meeting.TanggalTarget.Format("2006-01-02")
-					tanggalActualString := meeting.TanggalActual.Format("2006-01-02")
+					tanggalActualString := models.FormatTanggal(meeting.TanggalActual, "2006-01-02")
 					rowNum := i + 2 // Start from the second row (first row is header)
 
 					// Check for nil pointers and use the actual values
@@ -1422,7 +1401,7 @@ func UpdateAllSheets(c *gin.Context) {
 				f.SetCellValue(sheetName, fmt.Sprintf("D%d", rowNum), project.DivInisiasi)
 				f.SetCellValue(sheetName, fmt.Sprintf("E%d", rowNum), project.Bulan.Format("02-01-2006")) // Write month as text
 				f.SetCellValue(sheetName, fmt.Sprintf("F%d", rowNum), project.SumberPendanaan)
-				f.SetCellValue(sheetName, fmt.Sprintf("G%d", rowNum), project.Anggaran)
+				f.SetCellValue(sheetName, fmt.Sprintf("G%d", rowNum), amountCellValue(project.Anggaran))
 				f.SetCellValue(sheetName, fmt.Sprintf("H%d", rowNum), project.NoIzin)
 				f.SetCellValue(sheetName, fmt.Sprintf("I%d", rowNum), project.TanggalIzin.Format("02-01-2006"))
 				f.SetCellValue(sheetName, fmt.Sprintf("J%d", rowNum), project.TanggalTor.Format("02-01-2006"))
@@ -1479,7 +1458,7 @@ func UpdateAllSheets(c *gin.Context) {
 				}
 				f.SetCellValue(sheetName, fmt.Sprintf("E%d", rowNum), meeting.Pic)
 				f.SetCellValue(sheetName, fmt.Sprintf("F%d", rowNum), meeting.TanggalTarget.Format("2006-01-02"))
-				f.SetCellValue(sheetName, fmt.Sprintf("G%d", rowNum), meeting.TanggalActual.Format("2006-01-02"))
+				f.SetCellValue(sheetName, fmt.Sprintf("G%d", rowNum), models.FormatTanggal(meeting.TanggalActual, "2006-01-02"))
 
 				f.SetColWidth(sheetName, "A", "G", 20)
 			case "MEETING SCHEDULE":
@@ -0,0 +1,383 @@
+package controllers
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"project-its/initializers"
+	"project-its/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	skalaGanttHari   = "hari"
+	skalaGanttMinggu = "minggu"
+
+	warnaGanttDefault = "2596BE" // sama dengan warna bawaan event di kalender
+	maksKolomGantt    = 400
+)
+
+// barisGantt adalah satu resource pada lembar gantt beserta kedalamannya di hierarki
+type barisGantt struct {
+	resource models.ResourceProject
+	depth    int
+}
+
+// ExportTimelineProjectGantt menghasilkan workbook gantt untuk timeline project.
+// Query: dari & sampai (2006-01-02), skala=hari|minggu, project_id (opsional), arsip=true
+func ExportTimelineProjectGantt(c *gin.Context) {
+	skala := c.DefaultQuery("skala", skalaGanttHari)
+	if skala != skalaGanttHari && skala != skalaGanttMinggu {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Skala harus 'hari' atau 'minggu'"})
+		return
+	}
+
+	resourceQuery := scopeTimelineProjectAktif(c, initializers.DB)
+	eventQuery := scopeTimelineProjectAktif(c, initializers.DB)
+	if projectID := c.Query("project_id"); projectID != "" {
+		resourceQuery = resourceQuery.Where("project_id = ?", projectID)
+		eventQuery = eventQuery.Where("project_id = ?", projectID)
+	}
+
+	var resources []models.ResourceProject
+	if err := resourceQuery.Order("id").Find(&resources).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var events []models.TimelineProject
+	if err := eventQuery.Order("start").Find(&events).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	dari, sampai, err := rentangGantt(c.Query("dari"), c.Query("sampai"), events)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	kolom := kolomGantt(dari, sampai, skala)
+	if len(kolom) > maksKolomGantt {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Rentang terlalu panjang (%d kolom), persempit tanggal atau gunakan skala minggu", len(kolom))})
+		return
+	}
+
+	f := excelize.NewFile()
+	sheet := "Gantt Project"
+	f.SetSheetName("Sheet1", sheet)
+
+	if err := tulisGanttProject(f, sheet, dari, sampai, skala, kolom, resources, events); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var buffer bytes.Buffer
+	if err := f.Write(&buffer); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fileName := fmt.Sprintf("GanttProject_%s_%s.xlsx", dari.Format("20060102"), sampai.Format("20060102"))
+	c.Header("Content-Disposition", "attachment; filename="+fileName)
+	c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Header("Content-Length", strconv.Itoa(len(buffer.Bytes())))
+	c.Writer.Write(buffer.Bytes())
+}
+
+func tulisGanttProject(f *excelize.File, sheet string, dari, sampai time.Time, skala string, kolom []time.Time, resources []models.ResourceProject, events []models.TimelineProject) error {
+	headerStyle, err := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true},
+		Fill:      excelize.Fill{Type: "pattern", Color: []string{"#DDEBF7"}, Pattern: 1},
+		Alignment: &excelize.Alignment{Horizontal: "center", Vertical: "center", WrapText: true},
+		Border: []excelize.Border{
+			{Type: "left", Color: "000000", Style: 1},
+			{Type: "top", Color: "000000", Style: 1},
+			{Type: "bottom", Color: "000000", Style: 1},
+			{Type: "right", Color: "000000", Style: 1},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	gridStyle, err := f.NewStyle(&excelize.Style{
+		Border: []excelize.Border{
+			{Type: "left", Color: "D9D9D9", Style: 1},
+			{Type: "top", Color: "D9D9D9", Style: 1},
+			{Type: "bottom", Color: "D9D9D9", Style: 1},
+			{Type: "right", Color: "D9D9D9", Style: 1},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Baris 1 judul, baris 2 bulan, baris 3 tanggal/minggu, data mulai baris 4
+	f.SetCellValue(sheet, "A1", fmt.Sprintf("Timeline Project %s s/d %s", dari.Format("02-01-2006"), sampai.Format("02-01-2006")))
+	f.SetCellValue(sheet, "A2", "Resource")
+	f.MergeCell(sheet, "A2", "A3")
+	f.SetColWidth(sheet, "A", "A", 40)
+
+	lastCol, _ := excelize.ColumnNumberToName(len(kolom) + 1)
+	f.SetColWidth(sheet, "B", lastCol, 5)
+	if skala == skalaGanttMinggu {
+		f.SetColWidth(sheet, "B", lastCol, 8)
+	}
+
+	bulanMulai := 0
+	for i, k := range kolom {
+		col, _ := excelize.ColumnNumberToName(i + 2)
+		if skala == skalaGanttHari {
+			f.SetCellValue(sheet, col+"3", k.Day())
+		} else {
+			_, minggu := k.ISOWeek()
+			f.SetCellValue(sheet, col+"3", fmt.Sprintf("W%02d\n%s", minggu, k.Format("02/01")))
+		}
+		// Gabungkan sel bulan setiap kali bulan berganti
+		if i == len(kolom)-1 || kolom[i+1].Month() != k.Month() {
+			awal, _ := excelize.ColumnNumberToName(bulanMulai + 2)
+			f.SetCellValue(sheet, awal+"2", k.Format("January 2006"))
+			if awal != col {
+				f.MergeCell(sheet, awal+"2", col+"2")
+			}
+			bulanMulai = i + 1
+		}
+	}
+	f.SetCellStyle(sheet, "A2", lastCol+"3", headerStyle)
+	if skala == skalaGanttMinggu {
+		f.SetRowHeight(sheet, 3, 30)
+	}
+
+	baris := urutkanResourceGantt(resources)
+	rowByResource := make(map[uint]int)
+	row := 4
+	for _, b := range baris {
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", row), strings.Repeat("    ", b.depth)+b.resource.Name)
+		rowByResource[b.resource.ID] = row
+		row++
+	}
+
+	// Event yang resource-nya sudah tidak ada dikumpulkan di satu baris terpisah
+	barisTanpaResource := 0
+	for _, event := range events {
+		if _, ok := rowByResource[uint(event.ResourceId)]; !ok {
+			barisTanpaResource = row
+			f.SetCellValue(sheet, fmt.Sprintf("A%d", row), "(Tanpa Resource)")
+			row++
+			break
+		}
+	}
+	lastRow := row - 1
+	if lastRow >= 4 {
+		f.SetCellStyle(sheet, "A4", fmt.Sprintf("%s%d", lastCol, lastRow), gridStyle)
+	}
+
+	barStyles := make(map[string]int)
+	for _, event := range events {
+		mulai, selesai, ok := rentangEventGantt(event)
+		if !ok {
+			continue
+		}
+		r, ok := rowByResource[uint(event.ResourceId)]
+		if !ok {
+			r = barisTanpaResource
+		}
+
+		warna := warnaGantt(event.BgColor)
+		style, ok := barStyles[warna]
+		if !ok {
+			style, err = f.NewStyle(&excelize.Style{
+				Fill:      excelize.Fill{Type: "pattern", Color: []string{"#" + warna}, Pattern: 1},
+				Font:      &excelize.Font{Color: "#FFFFFF", Size: 9},
+				Alignment: &excelize.Alignment{Vertical: "center"},
+			})
+			if err != nil {
+				return err
+			}
+			barStyles[warna] = style
+		}
+
+		judulDitulis := false
+		for i, k := range kolom {
+			akhirKolom := k.AddDate(0, 0, 1)
+			if skala == skalaGanttMinggu {
+				akhirKolom = k.AddDate(0, 0, 7)
+			}
+			if !mulai.Before(akhirKolom) || !selesai.After(k) {
+				continue
+			}
+			cell, _ := excelize.CoordinatesToCellName(i+2, r)
+			f.SetCellStyle(sheet, cell, cell, style)
+			if !judulDitulis {
+				f.SetCellValue(sheet, cell, event.Title)
+				judulDitulis = true
+			}
+		}
+	}
+
+	f.SetPanes(sheet, &excelize.Panes{
+		Freeze:      true,
+		XSplit:      1,
+		YSplit:      3,
+		TopLeftCell: "B4",
+		ActivePane:  "bottomRight",
+	})
+	return nil
+}
+
+// urutkanResourceGantt menyusun resource secara depth-first mengikuti ParentID
+func urutkanResourceGantt(resources []models.ResourceProject) []barisGantt {
+	ada := make(map[uint]bool)
+	for _, r := range resources {
+		ada[r.ID] = true
+	}
+	anak := make(map[uint][]models.ResourceProject)
+	var akar []models.ResourceProject
+	for _, r := range resources {
+		if r.ParentID == 0 || !ada[r.ParentID] {
+			akar = append(akar, r)
+			continue
+		}
+		anak[r.ParentID] = append(anak[r.ParentID], r)
+	}
+
+	var hasil []barisGantt
+	dikunjungi := make(map[uint]bool)
+	var telusuri func(r models.ResourceProject, depth int)
+	telusuri = func(r models.ResourceProject, depth int) {
+		if dikunjungi[r.ID] {
+			return
+		}
+		dikunjungi[r.ID] = true
+		hasil = append(hasil, barisGantt{resource: r, depth: depth})
+		for _, a := range anak[r.ID] {
+			telusuri(a, depth+1)
+		}
+	}
+	for _, r := range akar {
+		telusuri(r, 0)
+	}
+	// Resource yang saling menunjuk sebagai parent tidak punya akar; tetap tampilkan
+	for _, r := range resources {
+		telusuri(r, 0)
+	}
+	return hasil
+}
+
+// rentangGantt menentukan rentang tanggal; default mengikuti event, atau bulan berjalan bila kosong
+func rentangGantt(dariStr, sampaiStr string, events []models.TimelineProject) (time.Time, time.Time, error) {
+	var dari, sampai time.Time
+	if dariStr != "" {
+		t, err := time.Parse("2006-01-02", dariStr)
+		if err != nil {
+			return dari, sampai, fmt.Errorf("Format tanggal 'dari' harus YYYY-MM-DD")
+		}
+		dari = t
+	}
+	if sampaiStr != "" {
+		t, err := time.Parse("2006-01-02", sampaiStr)
+		if err != nil {
+			return dari, sampai, fmt.Errorf("Format tanggal 'sampai' harus YYYY-MM-DD")
+		}
+		sampai = t
+	}
+
+	if dari.IsZero() || sampai.IsZero() {
+		var minMulai, maksSelesai time.Time
+		for _, event := range events {
+			mulai, selesai, ok := rentangEventGantt(event)
+			if !ok {
+				continue
+			}
+			if minMulai.IsZero() || mulai.Before(minMulai) {
+				minMulai = mulai
+			}
+			if maksSelesai.IsZero() || selesai.After(maksSelesai) {
+				maksSelesai = selesai
+			}
+		}
+		if minMulai.IsZero() {
+			now := time.Now().In(jakartaLocation())
+			minMulai = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+			maksSelesai = minMulai.AddDate(0, 1, 0)
+		}
+		if dari.IsZero() {
+			dari = minMulai
+		}
+		if sampai.IsZero() {
+			sampai = maksSelesai.AddDate(0, 0, -1)
+		}
+	}
+
+	if sampai.Before(dari) {
+		return dari, sampai, fmt.Errorf("Tanggal 'sampai' tidak boleh sebelum 'dari'")
+	}
+	return dari, sampai, nil
+}
+
+// kolomGantt mengembalikan tanggal awal setiap kolom; skala minggu dimulai hari Senin
+func kolomGantt(dari, sampai time.Time, skala string) []time.Time {
+	var kolom []time.Time
+	if skala == skalaGanttMinggu {
+		offset := (int(dari.Weekday()) + 6) % 7
+		for k := dari.AddDate(0, 0, -offset); !k.After(sampai); k = k.AddDate(0, 0, 7) {
+			kolom = append(kolom, k)
+			if len(kolom) > maksKolomGantt {
+				break
+			}
+		}
+		return kolom
+	}
+	for k := dari; !k.After(sampai); k = k.AddDate(0, 0, 1) {
+		kolom = append(kolom, k)
+		if len(kolom) > maksKolomGantt {
+			break
+		}
+	}
+	return kolom
+}
+
+// rentangEventGantt mengembalikan rentang hari [mulai, selesai) sebuah event.
+// Event yang berakhir tepat tengah malam tidak dihitung menempati hari tersebut.
+func rentangEventGantt(event models.TimelineProject) (time.Time, time.Time, bool) {
+	start, err := time.Parse("2006-01-02 15:04:05", event.Start)
+	if err != nil {
+		log.Printf("Error parsing start date for event %s: %v", event.Title, err)
+		return start, start, false
+	}
+	end, err := time.Parse("2006-01-02 15:04:05", event.End)
+	if err != nil {
+		log.Printf("Error parsing end date for event %s: %v", event.Title, err)
+		return start, start, false
+	}
+
+	mulai := truncateToDate(start)
+	selesai := truncateToDate(end)
+	if !end.Equal(selesai) || !selesai.After(mulai) {
+		selesai = selesai.AddDate(0, 0, 1)
+	}
+	if selesai.Before(mulai) {
+		selesai = mulai.AddDate(0, 0, 1)
+	}
+	return mulai, selesai, true
+}
+
+// warnaGantt menormalkan BgColor event menjadi heksadesimal 6 digit tanpa '#'
+func warnaGantt(bgColor string) string {
+	warna := strings.ToUpper(strings.TrimPrefix(strings.TrimSpace(bgColor), "#"))
+	if len(warna) == 3 {
+		warna = string([]byte{warna[0], warna[0], warna[1], warna[1], warna[2], warna[2]})
+	}
+	if len(warna) != 6 {
+		return warnaGanttDefault
+	}
+	if _, err := strconv.ParseUint(warna, 16, 32); err != nil {
+		return warnaGanttDefault
+	}
+	return warna
+}
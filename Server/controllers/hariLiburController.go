@@ -0,0 +1,444 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"project-its/initializers"
+	"project-its/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+// Jenis hari libur; keduanya sama-sama bukan hari kerja
+const (
+	jenisLiburNasional = "Libur Nasional"
+	jenisCutiBersama   = "Cuti Bersama"
+)
+
+// batas iterasi tambahHariKerja agar data libur yang keliru tidak membuat loop tanpa akhir
+const maksHariTambahKerja = 3660
+
+type HariLiburRequest struct {
+	Tanggal *string `json:"tanggal"`
+	Nama    *string `json:"nama"`
+	Jenis   *string `json:"jenis"`
+}
+
+// kalenderKerja menyimpan daftar libur yang sudah dimuat supaya perhitungan berulang tidak query ulang
+type kalenderKerja struct {
+	libur map[string]models.HariLibur
+}
+
+// muatKalenderKerja memuat seluruh hari libur; jumlahnya kecil (puluhan per tahun) sehingga aman dimuat sekaligus.
+// Bila query gagal, kalender tetap dikembalikan (hanya Sabtu/Minggu yang libur) bersama error-nya;
+// perhitungan tenggat sebaiknya menghentikan proses, sedangkan export cukup mencatat error.
+func muatKalenderKerja(tx *gorm.DB) (*kalenderKerja, error) {
+	kalender := &kalenderKerja{libur: make(map[string]models.HariLibur)}
+	var daftar []models.HariLibur
+	if err := tx.Find(&daftar).Error; err != nil {
+		return kalender, fmt.Errorf("gagal memuat hari libur: %w", err)
+	}
+
+	for _, h := range daftar {
+		if h.Tanggal != nil {
+			kalender.libur[h.Tanggal.Format("2006-01-02")] = h
+		}
+	}
+	return kalender, nil
+}
+
+// hariLibur mengembalikan data libur pada tanggal t, bila ada
+func (k *kalenderKerja) hariLibur(t time.Time) (models.HariLibur, bool) {
+	if k == nil {
+		return models.HariLibur{}, false
+	}
+	h, ok := k.libur[t.Format("2006-01-02")]
+	return h, ok
+}
+
+// isHariKerja bernilai false untuk Sabtu, Minggu, libur nasional dan cuti bersama
+func (k *kalenderKerja) isHariKerja(t time.Time) bool {
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		return false
+	}
+	_, libur := k.hariLibur(t)
+	return !libur
+}
+
+// hitungHariKerja menghitung hari kerja dari mulai sampai selesai (inklusif)
+func (k *kalenderKerja) hitungHariKerja(mulai, selesai time.Time) int {
+	jumlah := 0
+	for d := truncateToDate(mulai); !d.After(truncateToDate(selesai)); d = d.AddDate(0, 0, 1) {
+		if k.isHariKerja(d) {
+			jumlah++
+		}
+	}
+	return jumlah
+}
+
+// tambahHariKerja mengembalikan tanggal setelah n hari kerja dihitung sejak hari berikutnya dari mulai
+func (k *kalenderKerja) tambahHariKerja(mulai time.Time, n int) time.Time {
+	d := truncateToDate(mulai)
+	for i := 0; n > 0 && i < maksHariTambahKerja; i++ {
+		d = d.AddDate(0, 0, 1)
+		if k.isHariKerja(d) {
+			n--
+		}
+	}
+	return d
+}
+
+// hariKerjaBerikutnya mengembalikan t bila hari kerja, atau hari kerja pertama setelahnya.
+// Dipakai untuk tenggat yang jatuh pada akhir pekan atau hari libur.
+func (k *kalenderKerja) hariKerjaBerikutnya(t time.Time) time.Time {
+	for i := 0; !k.isHariKerja(t) && i < maksHariTambahKerja; i++ {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t
+}
+
+// hitungHariKerja memuat hari libur lalu menghitung hari kerja dari mulai sampai selesai (inklusif)
+func hitungHariKerja(tx *gorm.DB, mulai, selesai time.Time) (int, error) {
+	kalender, err := muatKalenderKerja(tx)
+	if err != nil {
+		return 0, err
+	}
+	return kalender.hitungHariKerja(mulai, selesai), nil
+}
+
+// HariLiburIndex menampilkan hari libur, filter ?tahun= dan ?jenis=
+func HariLiburIndex(c *gin.Context) {
+	var libur []models.HariLibur
+	query := initializers.DB.Order("tanggal asc")
+	if tahun, err := strconv.Atoi(c.Query("tahun")); err == nil {
+		dari := time.Date(tahun, time.January, 1, 0, 0, 0, 0, time.UTC)
+		query = query.Where("tanggal >= ? AND tanggal < ?", dari, dari.AddDate(1, 0, 0))
+	}
+	if jenis := c.Query("jenis"); jenis != "" {
+		query = query.Where("jenis = ?", normalisasiJenisLibur(jenis))
+	}
+	if err := query.Find(&libur).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"hari_libur": libur})
+}
+
+func HariLiburCreate(c *gin.Context) {
+	var req HariLiburRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Tanggal == nil || req.Nama == nil || strings.TrimSpace(*req.Nama) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tanggal dan nama hari libur wajib diisi"})
+		return
+	}
+	tanggal, err := time.Parse("2006-01-02", *req.Tanggal)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format tanggal harus YYYY-MM-DD"})
+		return
+	}
+
+	libur := models.HariLibur{
+		Tanggal:  &tanggal,
+		Nama:     strings.TrimSpace(*req.Nama),
+		Jenis:    normalisasiJenisLibur(derefString(req.Jenis)),
+		CreateBy: c.MustGet("username").(string),
+	}
+	if err := initializers.DB.Create(&libur).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan hari libur, tanggal mungkin sudah terdaftar"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"hari_libur": &libur})
+}
+
+func HariLiburUpdate(c *gin.Context) {
+	var req HariLiburRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var libur models.HariLibur
+	if err := initializers.DB.First(&libur, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Hari libur tidak ditemukan"})
+		return
+	}
+
+	if req.Tanggal != nil {
+		tanggal, err := time.Parse("2006-01-02", *req.Tanggal)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Format tanggal harus YYYY-MM-DD"})
+			return
+		}
+		libur.Tanggal = &tanggal
+	}
+	if req.Nama != nil && strings.TrimSpace(*req.Nama) != "" {
+		libur.Nama = strings.TrimSpace(*req.Nama)
+	}
+	if req.Jenis != nil {
+		libur.Jenis = normalisasiJenisLibur(*req.Jenis)
+	}
+
+	if err := initializers.DB.Save(&libur).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan hari libur, tanggal mungkin sudah terdaftar"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"hari_libur": &libur})
+}
+
+func HariLiburDelete(c *gin.Context) {
+	if err := initializers.DB.Where("id = ?", c.Param("id")).Delete(&models.HariLibur{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ImportHariLibur membaca file .xlsx (sheet pertama) atau .csv dengan kolom Tanggal, Nama, Jenis.
+// Baris pertama dianggap header; tanggal yang sudah terdaftar diperbarui.
+func ImportHariLibur(c *gin.Context) {
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File wajib diunggah"})
+		return
+	}
+	defer file.Close()
+
+	var rows [][]string
+	switch strings.ToLower(filepath.Ext(header.Filename)) {
+	case ".csv":
+		reader := csv.NewReader(file)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		rows, err = reader.ReadAll()
+	case ".xlsx":
+		rows, err = bacaBarisExcel(file)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format file harus .xlsx atau .csv"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("File tidak dapat dibaca: %v", err)})
+		return
+	}
+
+	username := c.MustGet("username").(string)
+	baru, diperbarui := 0, 0
+	gagal := []string{}
+
+	for i, row := range rows {
+		if i == 0 {
+			continue
+		}
+		if len(row) < 2 || strings.TrimSpace(row[0]) == "" {
+			continue
+		}
+
+		tanggal, err := parseTanggalHariLibur(row[0])
+		if err != nil {
+			gagal = append(gagal, fmt.Sprintf("Baris %d: tanggal %q tidak valid", i+1, row[0]))
+			continue
+		}
+		nama := strings.TrimSpace(row[1])
+		if nama == "" {
+			gagal = append(gagal, fmt.Sprintf("Baris %d: nama hari libur kosong", i+1))
+			continue
+		}
+		jenis := jenisLiburNasional
+		if len(row) > 2 {
+			jenis = normalisasiJenisLibur(row[2])
+		}
+
+		var libur models.HariLibur
+		err = initializers.DB.Where("tanggal = ?", tanggal).First(&libur).Error
+		switch {
+		case err == nil:
+			libur.Nama = nama
+			libur.Jenis = jenis
+			if err := initializers.DB.Save(&libur).Error; err != nil {
+				gagal = append(gagal, fmt.Sprintf("Baris %d: %v", i+1, err))
+				continue
+			}
+			diperbarui++
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			libur = models.HariLibur{Tanggal: &tanggal, Nama: nama, Jenis: jenis, CreateBy: username}
+			if err := initializers.DB.Create(&libur).Error; err != nil {
+				gagal = append(gagal, fmt.Sprintf("Baris %d: %v", i+1, err))
+				continue
+			}
+			baru++
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"baru":       baru,
+		"diperbarui": diperbarui,
+		"gagal":      gagal,
+	})
+}
+
+// HitungHariKerja menghitung hari kerja ?dari=&sampai= atau tanggal jatuh tempo ?mulai=&hari=
+func HitungHariKerja(c *gin.Context) {
+	kalender, err := muatKalenderKerja(initializers.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if c.Query("mulai") != "" {
+		mulai, err := time.Parse("2006-01-02", c.Query("mulai"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Format mulai harus YYYY-MM-DD"})
+			return
+		}
+		hari, err := strconv.Atoi(c.Query("hari"))
+		if err != nil || hari < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "hari harus berupa angka positif"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"mulai":   mulai.Format("2006-01-02"),
+			"hari":    hari,
+			"tanggal": kalender.tambahHariKerja(mulai, hari).Format("2006-01-02"),
+		})
+		return
+	}
+
+	dari, err := time.Parse("2006-01-02", c.Query("dari"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format dari harus YYYY-MM-DD"})
+		return
+	}
+	sampai, err := time.Parse("2006-01-02", c.Query("sampai"))
+	if err != nil || sampai.Before(dari) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format sampai harus YYYY-MM-DD dan tidak sebelum dari"})
+		return
+	}
+
+	libur := []models.HariLibur{}
+	for d := dari; !d.After(sampai); d = d.AddDate(0, 0, 1) {
+		if h, ok := kalender.hariLibur(d); ok {
+			libur = append(libur, h)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"dari":       dari.Format("2006-01-02"),
+		"sampai":     sampai.Format("2006-01-02"),
+		"hari_kerja": kalender.hitungHariKerja(dari, sampai),
+		"hari_libur": libur,
+	})
+}
+
+// tandaiHariLiburBulan mewarnai sel tanggal libur pada kalender bulanan hasil setMonthData*
+// dan menuliskan nama libur di sel kegiatan bila sel itu masih kosong.
+func tandaiHariLiburBulan(f *excelize.File, sheet string, monthTime time.Time, rowOffset, colOffset int, kalender *kalenderKerja) {
+	if kalender == nil || len(kalender.libur) == 0 {
+		return
+	}
+
+	border := []excelize.Border{
+		{Type: "top", Style: 1, Color: "DADEE0"},
+		{Type: "left", Style: 1, Color: "DADEE0"},
+		{Type: "right", Style: 1, Color: "DADEE0"},
+	}
+	liburDataStyle, err := f.NewStyle(&excelize.Style{
+		Border: border,
+		Font:   &excelize.Font{Color: "C0392B", Bold: true},
+		Fill:   excelize.Fill{Type: "pattern", Color: []string{"FDEDEC"}, Pattern: 1},
+	})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	liburBlankStyle, err := f.NewStyle(&excelize.Style{
+		Border: []excelize.Border{
+			{Type: "left", Style: 1, Color: "DADEE0"},
+			{Type: "right", Style: 1, Color: "DADEE0"},
+			{Type: "bottom", Style: 1, Color: "DADEE0"},
+		},
+		Font:      &excelize.Font{Color: "C0392B", Size: 8},
+		Fill:      excelize.Fill{Type: "pattern", Color: []string{"FDEDEC"}, Pattern: 1},
+		Alignment: &excelize.Alignment{WrapText: true, Vertical: "top"},
+	})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	firstDay := int(monthTime.Weekday())
+	daysInMonth := time.Date(monthTime.Year(), monthTime.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	for day := 1; day <= daysInMonth; day++ {
+		tanggal := time.Date(monthTime.Year(), monthTime.Month(), day, 0, 0, 0, 0, time.UTC)
+		libur, ok := kalender.hariLibur(tanggal)
+		if !ok {
+			continue
+		}
+
+		posisi := firstDay + day - 1
+		col := string(rune('B' + colOffset + posisi%7))
+		row := 4 + rowOffset + (posisi/7)*2
+
+		dateCell := fmt.Sprintf("%s%d", col, row)
+		eventCell := fmt.Sprintf("%s%d", col, row+1)
+		if err := f.SetCellStyle(sheet, dateCell, dateCell, liburDataStyle); err != nil {
+			fmt.Println(err)
+			return
+		}
+		if err := f.SetCellStyle(sheet, eventCell, eventCell, liburBlankStyle); err != nil {
+			fmt.Println(err)
+			return
+		}
+		if isi, _ := f.GetCellValue(sheet, eventCell); isi == "" {
+			f.SetCellValue(sheet, eventCell, libur.Nama)
+		}
+	}
+}
+
+func bacaBarisExcel(file io.Reader) ([][]string, error) {
+	f, err := excelize.OpenReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, errors.New("file tidak memiliki sheet")
+	}
+	return f.GetRows(sheets[0])
+}
+
+// parseTanggalHariLibur menerima serial tanggal Excel maupun format teks yang dikenali parseDate
+func parseTanggalHariLibur(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if serial, err := strconv.Atoi(s); err == nil {
+		return excelDateToTimeSuratMasuk(serial)
+	}
+	t, err := parseDate(s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return truncateToDate(t), nil
+}
+
+func normalisasiJenisLibur(jenis string) string {
+	switch strings.ToLower(strings.TrimSpace(jenis)) {
+	case "cuti bersama", "cuti_bersama", "cb":
+		return jenisCutiBersama
+	}
+	return jenisLiburNasional
+}
@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"project-its/models"
+	"testing"
+	"time"
+)
+
+func tanggalUji(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// kalenderUji: 25 Desember 2024 (Rabu) libur nasional, 26 Desember 2024 (Kamis) cuti bersama
+func kalenderUji() *kalenderKerja {
+	natal := tanggalUji(2024, time.December, 25)
+	cutiBersama := tanggalUji(2024, time.December, 26)
+	return &kalenderKerja{libur: map[string]models.HariLibur{
+		"2024-12-25": {Tanggal: &natal, Nama: "Hari Raya Natal", Jenis: jenisLiburNasional},
+		"2024-12-26": {Tanggal: &cutiBersama, Nama: "Cuti Bersama Natal", Jenis: jenisCutiBersama},
+	}}
+}
+
+func TestKalenderKerjaHitungHariKerja(t *testing.T) {
+	tests := []struct {
+		nama     string
+		kalender *kalenderKerja
+		mulai    time.Time
+		selesai  time.Time
+		want     int
+	}{
+		{"minggu tanpa libur", kalenderUji(), tanggalUji(2024, time.December, 2), tanggalUji(2024, time.December, 8), 5},
+		{"libur nasional dan cuti bersama", kalenderUji(), tanggalUji(2024, time.December, 23), tanggalUji(2024, time.December, 27), 3},
+		{"satu hari kerja", kalenderUji(), tanggalUji(2024, time.December, 24), tanggalUji(2024, time.December, 24), 1},
+		{"hari sabtu", kalenderUji(), tanggalUji(2024, time.December, 28), tanggalUji(2024, time.December, 28), 0},
+		{"hari libur", kalenderUji(), tanggalUji(2024, time.December, 25), tanggalUji(2024, time.December, 25), 0},
+		{"selesai sebelum mulai", kalenderUji(), tanggalUji(2024, time.December, 27), tanggalUji(2024, time.December, 23), 0},
+		{"jam diabaikan", kalenderUji(), time.Date(2024, time.December, 2, 15, 0, 0, 0, time.UTC), time.Date(2024, time.December, 3, 8, 0, 0, 0, time.UTC), 2},
+		{"tanpa kalender hanya akhir pekan", nil, tanggalUji(2024, time.December, 23), tanggalUji(2024, time.December, 29), 5},
+	}
+
+	for _, tt := range tests {
+		if got := tt.kalender.hitungHariKerja(tt.mulai, tt.selesai); got != tt.want {
+			t.Errorf("%s: hitungHariKerja = %d, ingin %d", tt.nama, got, tt.want)
+		}
+	}
+}
+
+func TestKalenderKerjaTambahHariKerja(t *testing.T) {
+	tests := []struct {
+		nama  string
+		mulai time.Time
+		n     int
+		want  time.Time
+	}{
+		{"nol hari", tanggalUji(2024, time.December, 23), 0, tanggalUji(2024, time.December, 23)},
+		{"melewati akhir pekan", tanggalUji(2024, time.December, 20), 1, tanggalUji(2024, time.December, 23)},
+		{"melewati libur", tanggalUji(2024, time.December, 24), 1, tanggalUji(2024, time.December, 27)},
+		{"melewati libur dan akhir pekan", tanggalUji(2024, time.December, 23), 3, tanggalUji(2024, time.December, 30)},
+		{"jam diabaikan", time.Date(2024, time.December, 2, 16, 30, 0, 0, time.UTC), 2, tanggalUji(2024, time.December, 4)},
+	}
+
+	kalender := kalenderUji()
+	for _, tt := range tests {
+		if got := kalender.tambahHariKerja(tt.mulai, tt.n); !got.Equal(tt.want) {
+			t.Errorf("%s: tambahHariKerja = %v, ingin %v", tt.nama, got, tt.want)
+		}
+	}
+}
+
+func TestKalenderKerjaHariKerjaBerikutnya(t *testing.T) {
+	tests := []struct {
+		input time.Time
+		want  time.Time
+	}{
+		{tanggalUji(2024, time.December, 23), tanggalUji(2024, time.December, 23)},
+		{tanggalUji(2024, time.December, 25), tanggalUji(2024, time.December, 27)},
+		{tanggalUji(2024, time.December, 28), tanggalUji(2024, time.December, 30)},
+	}
+
+	kalender := kalenderUji()
+	for _, tt := range tests {
+		if got := kalender.hariKerjaBerikutnya(tt.input); !got.Equal(tt.want) {
+			t.Errorf("hariKerjaBerikutnya(%v) = %v, ingin %v", tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,390 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"project-its/initializers"
+	"project-its/models"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	prodIDKalender = "-//ITS//Project ITS Kalender//ID"
+	tzidKalender   = "Asia/Jakarta"
+)
+
+// kalenderFeed memetakan nama di URL ke judul kalender dan fungsi pengisinya. Isi feed mengikuti pemilik
+// token: jadwal rapat berlaku untuk semua, booking dan meeting milik/PIC user, cuti milik sendiri dan bawahan.
+var kalenderFeed = map[string]struct {
+	Nama  string
+	Tulis func(ics *icsWriter, user models.User)
+}{
+	"rapat":   {"Jadwal Rapat", tulisIcsJadwalRapat},
+	"booking": {"Booking Ruang Rapat", tulisIcsBookingRapat},
+	"cuti":    {"Jadwal Cuti", tulisIcsJadwalCuti},
+	"meeting": {"Meeting Schedule", tulisIcsMeetingSchedule},
+	"semua":   {"Kalender ITS", tulisIcsSemua},
+}
+
+var jamMeetingRegex = regexp.MustCompile(`(\d{1,2})[:.](\d{2})`)
+
+// FeedKalenderShow mengembalikan URL feed milik user login, token dibuat saat pertama kali diminta
+func FeedKalenderShow(c *gin.Context) {
+	feed, err := feedKalenderUser(c.MustGet("userID").(uint), false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"feed": feed, "url": urlFeedKalender(c, feed.Token)})
+}
+
+// FeedKalenderReset mengganti token sehingga URL lama tidak bisa dipakai lagi
+func FeedKalenderReset(c *gin.Context) {
+	feed, err := feedKalenderUser(c.MustGet("userID").(uint), true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"feed": feed, "url": urlFeedKalender(c, feed.Token)})
+}
+
+// FeedKalenderIcs melayani /ics/:token/:kalender tanpa cookie, token feed menggantikan login
+func FeedKalenderIcs(c *gin.Context) {
+	var feed models.FeedKalender
+	if err := initializers.DB.Where("token = ?", c.Param("token")).First(&feed).Error; err != nil {
+		c.String(http.StatusUnauthorized, "Token feed tidak valid")
+		return
+	}
+	var user models.User
+	if err := initializers.DB.First(&user, feed.UserID).Error; err != nil {
+		c.String(http.StatusUnauthorized, "Token feed tidak valid")
+		return
+	}
+
+	nama := strings.TrimSuffix(c.Param("kalender"), ".ics")
+	kalender, ok := kalenderFeed[nama]
+	if !ok {
+		c.String(http.StatusNotFound, "Kalender tidak ditemukan")
+		return
+	}
+
+	now := time.Now()
+	initializers.DB.Model(&feed).Update("terakhir_akses", now)
+
+	ics := newIcsWriter(kalender.Nama, now)
+	kalender.Tulis(ics, user)
+	ics.tutup()
+
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%s.ics", nama))
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(ics.String()))
+}
+
+func feedKalenderUser(userID uint, reset bool) (models.FeedKalender, error) {
+	var feed models.FeedKalender
+	err := initializers.DB.Where("user_id = ?", userID).First(&feed).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return feed, err
+	}
+	if err == nil && !reset {
+		return feed, nil
+	}
+
+	token, errToken := tokenFeedAcak()
+	if errToken != nil {
+		return feed, errToken
+	}
+	feed.UserID = userID
+	feed.Token = token
+	return feed, initializers.DB.Save(&feed).Error
+}
+
+func tokenFeedAcak() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func urlFeedKalender(c *gin.Context, token string) gin.H {
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	urls := gin.H{}
+	for nama := range kalenderFeed {
+		urls[nama] = fmt.Sprintf("%s://%s/ics/%s/%s.ics", scheme, c.Request.Host, token, nama)
+	}
+	return urls
+}
+
+func tulisIcsSemua(ics *icsWriter, user models.User) {
+	tulisIcsJadwalRapat(ics, user)
+	tulisIcsBookingRapat(ics, user)
+	tulisIcsJadwalCuti(ics, user)
+	tulisIcsMeetingSchedule(ics, user)
+}
+
+// Jadwal rapat tidak punya pemilik atau peserta, tampil di feed semua user
+func tulisIcsJadwalRapat(ics *icsWriter, user models.User) {
+	var events []models.JadwalRapat
+	if err := initializers.DB.Find(&events).Error; err != nil {
+		log.Printf("Error loading jadwal rapat for ics: %v", err)
+		return
+	}
+	var berulang []uint
+	for _, e := range events {
+		if e.RRule != "" {
+			berulang = append(berulang, e.ID)
+		}
+	}
+	pengecualian := muatPengecualian(sumberJadwalRapat, berulang)
+	for _, e := range events {
+		ics.eventKalender(fmt.Sprintf("rapat-%d", e.ID), e.Title, "", e.AllDay, e.Start, e.End, e.RRule, pengecualian[e.ID])
+	}
+}
+
+func tulisIcsBookingRapat(ics *icsWriter, user models.User) {
+	var events []models.BookingRapat
+	if err := initializers.DB.Where("LOWER(create_by) = LOWER(?)", user.Username).Find(&events).Error; err != nil {
+		log.Printf("Error loading booking rapat for ics: %v", err)
+		return
+	}
+	ruang := make(map[uint]string)
+	var semuaRuang []models.RuangRapat
+	initializers.DB.Find(&semuaRuang)
+	for _, r := range semuaRuang {
+		ruang[r.ID] = r.Nama
+	}
+
+	var berulang []uint
+	for _, e := range events {
+		if e.RRule != "" {
+			berulang = append(berulang, e.ID)
+		}
+	}
+	pengecualian := muatPengecualian(sumberBookingRapat, berulang)
+	for _, e := range events {
+		lokasi := ""
+		if e.RuangRapatID != nil {
+			lokasi = ruang[*e.RuangRapatID]
+		}
+		ics.eventKalender(fmt.Sprintf("booking-%d", e.ID), e.Title, lokasi, e.AllDay, e.Start, e.End, e.RRule, pengecualian[e.ID])
+	}
+}
+
+// Admin melihat semua cuti; user lain hanya cuti dari pengajuannya sendiri dan bawahannya
+func tulisIcsJadwalCuti(ics *icsWriter, user models.User) {
+	query := initializers.DB
+	if user.Role != roleAdmin {
+		query = query.Where("id IN (?)", initializers.DB.Model(&models.PengajuanCuti{}).
+			Select("jadwal_cuti_id").
+			Where("jadwal_cuti_id IS NOT NULL AND (user_id = ? OR atasan_id = ?)", user.ID, user.ID))
+	}
+	var events []models.JadwalCuti
+	if err := query.Find(&events).Error; err != nil {
+		log.Printf("Error loading jadwal cuti for ics: %v", err)
+		return
+	}
+	for _, e := range events {
+		ics.eventKalender(fmt.Sprintf("cuti-%d", e.ID), e.Title, "", e.AllDay, e.Start, e.End, "", nil)
+	}
+}
+
+// Meeting schedule menyimpan tanggal dan jam terpisah; tanpa jam yang bisa dibaca dianggap seharian.
+// Hanya meeting dengan PIC atau pembuat user pemilik feed.
+func tulisIcsMeetingSchedule(ics *icsWriter, user models.User) {
+	var meetings []models.MeetingSchedule
+	if err := initializers.DB.Where("tanggal IS NOT NULL").
+		Where("LOWER(pic) = LOWER(?) OR LOWER(create_by) = LOWER(?)", user.Username, user.Username).
+		Find(&meetings).Error; err != nil {
+		log.Printf("Error loading meeting schedule for ics: %v", err)
+		return
+	}
+	loc := jakartaLocation()
+	for _, m := range meetings {
+		uid := fmt.Sprintf("meeting-%d", m.ID)
+		tanggal := time.Date(m.Tanggal.Year(), m.Tanggal.Month(), m.Tanggal.Day(), 0, 0, 0, 0, loc)
+		mulai, adaJam := jamMeeting(tanggal, derefString(m.Waktu))
+		if !adaJam {
+			ics.event(uid, derefString(m.Perihal), derefString(m.Tempat), true, tanggal, tanggal.AddDate(0, 0, 1), "", "", nil)
+			continue
+		}
+		selesai, ok := jamMeeting(tanggal, derefString(m.Selesai))
+		if !ok || !selesai.After(mulai) {
+			selesai = mulai.Add(time.Hour)
+		}
+		ics.event(uid, derefString(m.Perihal), derefString(m.Tempat), false, mulai, selesai, "", "", nil)
+	}
+}
+
+func jamMeeting(tanggal time.Time, jam string) (time.Time, bool) {
+	cocok := jamMeetingRegex.FindStringSubmatch(jam)
+	if cocok == nil {
+		return tanggal, false
+	}
+	var h, m int
+	fmt.Sscanf(cocok[1]+" "+cocok[2], "%d %d", &h, &m)
+	if h > 23 || m > 59 {
+		return tanggal, false
+	}
+	return tanggal.Add(time.Duration(h)*time.Hour + time.Duration(m)*time.Minute), true
+}
+
+// icsWriter menyusun dokumen iCalendar (RFC 5545) dengan baris CRLF dan pelipatan 75 oktet
+type icsWriter struct {
+	b     strings.Builder
+	stamp string
+}
+
+func newIcsWriter(nama string, now time.Time) *icsWriter {
+	ics := &icsWriter{stamp: now.UTC().Format("20060102T150405Z")}
+	ics.baris("BEGIN:VCALENDAR")
+	ics.baris("VERSION:2.0")
+	ics.baris("PRODID:" + prodIDKalender)
+	ics.baris("CALSCALE:GREGORIAN")
+	ics.baris("METHOD:PUBLISH")
+	ics.baris("X-WR-CALNAME:" + escapeIcs(nama))
+	ics.baris("X-WR-TIMEZONE:" + tzidKalender)
+	// WIB tidak memakai daylight saving, cukup satu komponen STANDARD
+	ics.baris("BEGIN:VTIMEZONE")
+	ics.baris("TZID:" + tzidKalender)
+	ics.baris("BEGIN:STANDARD")
+	ics.baris("DTSTART:19700101T000000")
+	ics.baris("TZOFFSETFROM:+0700")
+	ics.baris("TZOFFSETTO:+0700")
+	ics.baris("TZNAME:WIB")
+	ics.baris("END:STANDARD")
+	ics.baris("END:VTIMEZONE")
+	return ics
+}
+
+func (ics *icsWriter) tutup() {
+	ics.baris("END:VCALENDAR")
+}
+
+func (ics *icsWriter) String() string {
+	return ics.b.String()
+}
+
+// eventKalender menulis event berformat Start/End string (seperti parseEventTime) beserta
+// aturan pengulangan; kejadian batal jadi EXDATE dan kejadian pindahan jadi VEVENT RECURRENCE-ID
+func (ics *icsWriter) eventKalender(uid, judul, lokasi string, allDay bool, start, end, rrule string, pengecualian []models.PengecualianJadwal) {
+	mulai, selesai, err := rentangEventKalender(allDay, start, end)
+	if err != nil {
+		log.Printf("Skip event %s di feed ics: %v", uid, err)
+		return
+	}
+	// End all day sudah eksklusif seperti DTEND iCalendar, lihat akhirHariEksklusif
+
+	if rrule == "" || validasiRRule(rrule) != nil {
+		ics.event(uid, judul, lokasi, allDay, mulai, selesai, "", "", nil)
+		return
+	}
+
+	var exdate []time.Time
+	var pindahan []models.PengecualianJadwal
+	for _, p := range pengecualian {
+		if p.Batal {
+			exdate = append(exdate, p.TanggalAsli)
+		} else {
+			pindahan = append(pindahan, p)
+		}
+	}
+	ics.event(uid, judul, lokasi, allDay, mulai, selesai, rruleIcs(rrule, allDay), "", exdate)
+
+	for _, p := range pindahan {
+		s, e, err := rentangEventKalender(allDay, p.Start, p.End)
+		if err != nil {
+			continue
+		}
+		judulPindahan := judul
+		if p.Title != nil {
+			judulPindahan = *p.Title
+		}
+		ics.event(uid, judulPindahan, lokasi, allDay, s, e, "", ics.waktu(allDay, "RECURRENCE-ID", p.TanggalAsli.In(jakartaLocation())), nil)
+	}
+}
+
+func (ics *icsWriter) event(uid, judul, lokasi string, allDay bool, mulai, selesai time.Time, rrule, recurrenceID string, exdate []time.Time) {
+	ics.baris("BEGIN:VEVENT")
+	ics.baris("UID:" + uid + "@project-its")
+	ics.baris("DTSTAMP:" + ics.stamp)
+	ics.baris(ics.waktu(allDay, "DTSTART", mulai))
+	ics.baris(ics.waktu(allDay, "DTEND", selesai))
+	if recurrenceID != "" {
+		ics.baris(recurrenceID)
+	}
+	if rrule != "" {
+		ics.baris("RRULE:" + rrule)
+	}
+	for _, t := range exdate {
+		ics.baris(ics.waktu(allDay, "EXDATE", t.In(jakartaLocation())))
+	}
+	ics.baris("SUMMARY:" + escapeIcs(judul))
+	if lokasi != "" {
+		ics.baris("LOCATION:" + escapeIcs(lokasi))
+	}
+	if allDay {
+		ics.baris("TRANSP:TRANSPARENT")
+	}
+	ics.baris("END:VEVENT")
+}
+
+// waktu menulis properti tanggal: VALUE=DATE untuk all day, selain itu waktu lokal dengan TZID
+func (ics *icsWriter) waktu(allDay bool, properti string, t time.Time) string {
+	if allDay {
+		return properti + ";VALUE=DATE:" + t.Format("20060102")
+	}
+	return properti + ";TZID=" + tzidKalender + ":" + t.In(jakartaLocation()).Format("20060102T150405")
+}
+
+// baris menulis satu content line; baris lebih dari 75 oktet dilipat tanpa memotong karakter UTF-8
+func (ics *icsWriter) baris(s string) {
+	batas := 75
+	for len(s) > batas {
+		potong := batas
+		for potong > 0 && (s[potong]&0xC0) == 0x80 {
+			potong--
+		}
+		ics.b.WriteString(s[:potong] + "\r\n ")
+		s = s[potong:]
+		batas = 74 // spasi pelipatan ikut terhitung
+	}
+	ics.b.WriteString(s + "\r\n")
+}
+
+// rruleIcs menyamakan tipe UNTIL dengan DTSTART seperti diwajibkan RFC 5545:
+// tanggal untuk all day, UTC untuk event berjam
+func rruleIcs(rrule string, allDay bool) string {
+	bagian := strings.Split(strings.TrimPrefix(strings.TrimSpace(rrule), "RRULE:"), ";")
+	for i, b := range bagian {
+		kv := strings.SplitN(b, "=", 2)
+		if len(kv) != 2 || strings.ToUpper(kv[0]) != "UNTIL" {
+			continue
+		}
+		until, err := parseUntilRRule(strings.ToUpper(kv[1]), jakartaLocation())
+		if err != nil {
+			continue
+		}
+		if allDay {
+			bagian[i] = "UNTIL=" + until.Format("20060102")
+		} else {
+			bagian[i] = "UNTIL=" + until.UTC().Format("20060102T150405Z")
+		}
+	}
+	return strings.Join(bagian, ";")
+}
+
+func escapeIcs(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
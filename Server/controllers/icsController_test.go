@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestIcsWriterBaris(t *testing.T) {
+	tests := []struct {
+		nama  string
+		input string
+		want  string
+	}{
+		{"pendek", "SUMMARY:Rapat", "SUMMARY:Rapat\r\n"},
+		{"tepat 75 oktet", strings.Repeat("a", 75), strings.Repeat("a", 75) + "\r\n"},
+		{"76 oktet dilipat", strings.Repeat("a", 76), strings.Repeat("a", 75) + "\r\n a\r\n"},
+		{
+			"baris lanjutan paling banyak 74 oktet",
+			strings.Repeat("a", 75+74+1),
+			strings.Repeat("a", 75) + "\r\n " + strings.Repeat("a", 74) + "\r\n a\r\n",
+		},
+		{
+			"karakter UTF-8 tidak terpotong",
+			strings.Repeat("a", 74) + "é",
+			strings.Repeat("a", 74) + "\r\n é\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			var ics icsWriter
+			ics.baris(tt.input)
+			if got := ics.b.String(); got != tt.want {
+				t.Errorf("baris(%q) = %q, ingin %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIcsWriterBarisBisaDibukaKembali(t *testing.T) {
+	inputs := []string{
+		"DESCRIPTION:" + strings.Repeat("Rapat koordinasi pengadaan ", 10),
+		"SUMMARY:" + strings.Repeat("Rapat ☕ di ruang 🏢 ", 12),
+		"LOCATION:" + strings.Repeat("é", 200),
+	}
+
+	for _, input := range inputs {
+		var ics icsWriter
+		ics.baris(input)
+		hasil := strings.TrimSuffix(ics.b.String(), "\r\n")
+		for _, line := range strings.Split(hasil, "\r\n") {
+			if len(line) > 75 {
+				t.Errorf("baris %q lebih dari 75 oktet", line)
+			}
+			if !utf8.ValidString(line) {
+				t.Errorf("baris %q memotong karakter UTF-8", line)
+			}
+		}
+		if got := strings.ReplaceAll(hasil, "\r\n ", ""); got != input {
+			t.Errorf("hasil dibuka kembali = %q, ingin %q", got, input)
+		}
+	}
+}
+
+func TestEscapeIcs(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"Rapat Bulanan", "Rapat Bulanan"},
+		{"Rapat; Evaluasi, Q1", `Rapat\; Evaluasi\, Q1`},
+		{`C:\data`, `C:\\data`},
+		{"baris satu\nbaris dua", `baris satu\nbaris dua`},
+		{"baris satu\r\nbaris dua", `baris satu\nbaris dua`},
+		{`\;`, `\\\;`},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := escapeIcs(tt.input); got != tt.want {
+			t.Errorf("escapeIcs(%q) = %q, ingin %q", tt.input, got, tt.want)
+		}
+	}
+}
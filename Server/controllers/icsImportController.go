@@ -0,0 +1,552 @@
+package controllers
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"project-its/initializers"
+	"project-its/models"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const sumberJadwalCuti = "JadwalCuti"
+
+// kalenderImport memetakan ?kalender= ke sumber event dan kategori notifikasinya
+var kalenderImport = map[string]string{
+	"rapat":   sumberJadwalRapat,
+	"booking": sumberBookingRapat,
+	"cuti":    sumberJadwalCuti,
+}
+
+// zonaWindows memetakan TZID bawaan Outlook/Exchange (nama zona Windows) ke zona IANA
+var zonaWindows = map[string]string{
+	"SE Asia Standard Time":        "Asia/Jakarta",
+	"Singapore Standard Time":      "Asia/Singapore",
+	"North Asia Standard Time":     "Asia/Krasnoyarsk",
+	"China Standard Time":          "Asia/Shanghai",
+	"Taipei Standard Time":         "Asia/Taipei",
+	"Tokyo Standard Time":          "Asia/Tokyo",
+	"Korea Standard Time":          "Asia/Seoul",
+	"India Standard Time":          "Asia/Kolkata",
+	"Arabian Standard Time":        "Asia/Dubai",
+	"W. Australia Standard Time":   "Australia/Perth",
+	"AUS Eastern Standard Time":    "Australia/Sydney",
+	"UTC":                          "UTC",
+	"GMT Standard Time":            "Europe/London",
+	"W. Europe Standard Time":      "Europe/Berlin",
+	"Romance Standard Time":        "Europe/Paris",
+	"Central Europe Standard Time": "Europe/Budapest",
+	"Eastern Standard Time":        "America/New_York",
+	"Central Standard Time":        "America/Chicago",
+	"Mountain Standard Time":       "America/Denver",
+	"Pacific Standard Time":        "America/Los_Angeles",
+}
+
+var durasiIcsRegex = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// icsEvent adalah satu VEVENT hasil parse file .ics
+type icsEvent struct {
+	UID          string
+	Summary      string
+	Lokasi       string
+	AllDay       bool
+	Mulai        time.Time
+	Selesai      time.Time
+	RRule        string
+	ExDate       []time.Time
+	RecurrenceID *time.Time
+}
+
+// itemImportIcs adalah baris preview/hasil import untuk satu UID
+type itemImportIcs struct {
+	UID          string `json:"uid"`
+	Title        string `json:"title"`
+	Start        string `json:"start"`
+	End          string `json:"end"`
+	AllDay       bool   `json:"allDay"`
+	RRule        string `json:"rrule"`
+	Pengecualian int    `json:"pengecualian"`
+	Aksi         string `json:"aksi"` // baru atau perbarui
+	EventID      uint   `json:"event_id,omitempty"`
+	Catatan      string `json:"catatan,omitempty"`
+
+	event        icsEvent
+	pengecualian []models.PengecualianJadwal
+}
+
+// ImportKalenderIcs menerima file .ics (form "file") untuk ?kalender=rapat|booking|cuti.
+// Dengan ?preview=true hanya mengembalikan hasil pemetaan tanpa menyimpan.
+func ImportKalenderIcs(c *gin.Context) {
+	sumber, ok := kalenderImport[c.Query("kalender")]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Kalender harus salah satu dari rapat, booking atau cuti"})
+		return
+	}
+
+	file, _, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File .ics diperlukan"})
+		return
+	}
+	defer file.Close()
+
+	events, peringatan, err := parseIcs(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	items, peringatanItem := susunItemImportIcs(sumber, events)
+	peringatan = append(peringatan, peringatanItem...)
+
+	if c.Query("preview") == "true" {
+		c.JSON(http.StatusOK, gin.H{"preview": true, "items": items, "peringatan": peringatan})
+		return
+	}
+
+	username := c.MustGet("username").(string)
+	color := c.PostForm("color")
+	var notifikasi []itemImportIcs
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		for i := range items {
+			baruAtauPindah, err := simpanItemImportIcs(tx, sumber, &items[i], color, username)
+			if err != nil {
+				return fmt.Errorf("Gagal import %s: %v", items[i].UID, err)
+			}
+			// Pengingat dari import sebelumnya memakai waktu lama
+			if baruAtauPindah {
+				if err := batalkanPengingatEvent(tx, sumber, items[i].EventID); err != nil {
+					return err
+				}
+			}
+			if baruAtauPindah && items[i].event.Mulai.After(time.Now()) {
+				notifikasi = append(notifikasi, items[i])
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Pengingat hanya untuk event yang belum lewat, dan tidak diulang bila waktunya tidak berubah
+	for _, item := range notifikasi {
+		SetNotificationEvent(item.Title, item.event.Mulai, sumber, sumber, item.EventID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"preview": false, "items": items, "peringatan": peringatan})
+}
+
+// susunItemImportIcs menggabungkan VEVENT per UID dan menandai mana yang sudah pernah diimport
+func susunItemImportIcs(sumber string, events []icsEvent) ([]itemImportIcs, []string) {
+	var peringatan []string
+	items := []itemImportIcs{}
+	indeks := make(map[string]int)
+
+	for _, e := range events {
+		if e.RecurrenceID != nil {
+			continue
+		}
+		if _, ada := indeks[e.UID]; ada {
+			peringatan = append(peringatan, fmt.Sprintf("UID %s muncul lebih dari sekali, hanya yang pertama dipakai", e.UID))
+			continue
+		}
+
+		item := itemImportIcs{
+			UID:    e.UID,
+			Title:  e.Summary,
+			AllDay: e.AllDay,
+			Aksi:   "baru",
+			event:  e,
+		}
+		item.Start, item.End = stringWaktuImportIcs(e.AllDay, e.Mulai, e.Selesai)
+
+		if e.RRule != "" {
+			switch {
+			case sumber == sumberJadwalCuti:
+				item.Catatan = "Jadwal cuti tidak mendukung pengulangan, hanya kejadian pertama yang diimport"
+			case validasiRRule(e.RRule) != nil:
+				item.Catatan = "RRULE tidak didukung, hanya kejadian pertama yang diimport"
+			default:
+				item.RRule = e.RRule
+				for _, ex := range e.ExDate {
+					item.pengecualian = append(item.pengecualian, models.PengecualianJadwal{TanggalAsli: ex, Batal: true})
+				}
+			}
+		}
+
+		var mapping models.ImportKalender
+		if err := initializers.DB.Where("sumber = ? AND uid = ?", sumber, e.UID).First(&mapping).Error; err == nil && eventImportAda(sumber, mapping.EventID) {
+			item.Aksi = "perbarui"
+			item.EventID = mapping.EventID
+		}
+
+		indeks[e.UID] = len(items)
+		items = append(items, item)
+	}
+
+	// VEVENT dengan RECURRENCE-ID adalah kejadian pindahan dari event berulang dengan UID yang sama
+	for _, e := range events {
+		if e.RecurrenceID == nil {
+			continue
+		}
+		i, ada := indeks[e.UID]
+		if !ada || items[i].RRule == "" {
+			peringatan = append(peringatan, fmt.Sprintf("Kejadian pindahan %s (%s) dilewati karena event induknya tidak berulang", e.UID, e.Summary))
+			continue
+		}
+		start, end := stringWaktuImportIcs(items[i].AllDay, e.Mulai, e.Selesai)
+		p := models.PengecualianJadwal{TanggalAsli: *e.RecurrenceID, Start: start, End: end}
+		if e.Summary != "" && e.Summary != items[i].Title {
+			judul := e.Summary
+			p.Title = &judul
+		}
+		items[i].pengecualian = append(items[i].pengecualian, p)
+	}
+	for i := range items {
+		items[i].Pengecualian = len(items[i].pengecualian)
+	}
+	return items, peringatan
+}
+
+// simpanItemImportIcs membuat atau memperbarui event; true bila event baru atau waktunya berubah
+func simpanItemImportIcs(tx *gorm.DB, sumber string, item *itemImportIcs, color, username string) (bool, error) {
+	berubah := item.Aksi == "baru"
+	var eventID uint
+
+	switch sumber {
+	case sumberJadwalRapat:
+		var event models.JadwalRapat
+		if item.EventID != 0 {
+			if err := tx.First(&event, item.EventID).Error; err != nil {
+				return false, err
+			}
+			berubah = berubah || event.Start != item.Start
+		}
+		event.Title, event.Start, event.End, event.AllDay, event.RRule = item.Title, item.Start, item.End, item.AllDay, item.RRule
+		if event.Color == "" {
+			event.Color = color
+		}
+		if err := tx.Save(&event).Error; err != nil {
+			return false, err
+		}
+		eventID = event.ID
+
+	case sumberBookingRapat:
+		var event models.BookingRapat
+		if item.EventID != 0 {
+			if err := tx.First(&event, item.EventID).Error; err != nil {
+				return false, err
+			}
+			berubah = berubah || event.Start != item.Start
+		}
+		event.Title, event.Start, event.End, event.AllDay, event.RRule = item.Title, item.Start, item.End, item.AllDay, item.RRule
+		if event.Color == "" {
+			event.Color = color
+		}
+		if event.CreateBy == "" {
+			event.CreateBy = username
+		}
+		// Dicek bentrok dan disimpan lewat jalur yang sama dengan form booking, termasuk kunci baris ruang
+		pengecualian := make([]models.PengecualianJadwal, len(item.pengecualian))
+		for i, p := range item.pengecualian {
+			p.CreateBy = username
+			pengecualian[i] = p
+		}
+		if err := simpanBookingRapatTx(tx, &event, true, pengecualian); err != nil {
+			return false, err
+		}
+		eventID = event.ID
+
+	case sumberJadwalCuti:
+		var event models.JadwalCuti
+		if item.EventID != 0 {
+			if err := tx.First(&event, item.EventID).Error; err != nil {
+				return false, err
+			}
+			berubah = berubah || event.Start != item.Start
+		}
+		event.Title, event.Start, event.End, event.AllDay = item.Title, item.Start, item.End, item.AllDay
+		if event.Color == "" {
+			event.Color = color
+		}
+		if err := tx.Save(&event).Error; err != nil {
+			return false, err
+		}
+		eventID = event.ID
+	}
+
+	// Pengecualian selalu diganti dengan isi file terbaru; booking sudah menggantinya saat disimpan
+	if sumber == sumberJadwalRapat {
+		if err := tx.Where("sumber = ? AND event_id = ?", sumber, eventID).Delete(&models.PengecualianJadwal{}).Error; err != nil {
+			return false, err
+		}
+		for _, p := range item.pengecualian {
+			p.Sumber = sumber
+			p.EventID = eventID
+			p.CreateBy = username
+			if err := tx.Create(&p).Error; err != nil {
+				return false, err
+			}
+		}
+	}
+
+	var mapping models.ImportKalender
+	tx.Where("sumber = ? AND uid = ?", sumber, item.UID).First(&mapping)
+	mapping.Sumber = sumber
+	mapping.UID = item.UID
+	mapping.EventID = eventID
+	mapping.CreateBy = username
+	if err := tx.Save(&mapping).Error; err != nil {
+		return false, err
+	}
+
+	item.EventID = eventID
+	return berubah, nil
+}
+
+func eventImportAda(sumber string, id uint) bool {
+	var jumlah int64
+	switch sumber {
+	case sumberJadwalRapat:
+		initializers.DB.Model(&models.JadwalRapat{}).Where("id = ?", id).Count(&jumlah)
+	case sumberBookingRapat:
+		initializers.DB.Model(&models.BookingRapat{}).Where("id = ?", id).Count(&jumlah)
+	case sumberJadwalCuti:
+		initializers.DB.Model(&models.JadwalCuti{}).Where("id = ?", id).Count(&jumlah)
+	}
+	return jumlah > 0
+}
+
+// stringWaktuImportIcs mengubah waktu VEVENT ke format Start/End yang dipakai modul kalender.
+// DTEND all day pada iCalendar eksklusif, sama dengan End all day di aplikasi (endStr FullCalendar).
+func stringWaktuImportIcs(allDay bool, mulai, selesai time.Time) (string, string) {
+	if allDay {
+		return formatWaktuEvent(true, mulai), formatWaktuEvent(true, akhirHariEksklusif(mulai, selesai))
+	}
+	return formatWaktuEvent(false, mulai.In(jakartaLocation())), formatWaktuEvent(false, selesai.In(jakartaLocation()))
+}
+
+// parseIcs membaca VEVENT dari dokumen iCalendar; VALARM dan komponen lain diabaikan
+func parseIcs(r io.Reader) ([]icsEvent, []string, error) {
+	baris, err := unfoldIcs(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(baris) == 0 || !strings.EqualFold(baris[0], "BEGIN:VCALENDAR") {
+		return nil, nil, fmt.Errorf("File bukan iCalendar yang valid")
+	}
+
+	var events []icsEvent
+	var peringatan []string
+	var sekarang *icsEvent
+	var durasi *time.Duration
+	adaSelesai := false
+	kedalaman := 0 // komponen bersarang di dalam VEVENT, misal VALARM
+
+	for nomor, b := range baris {
+		nama, param, nilai := parsePropertiIcs(b)
+		switch {
+		case nama == "BEGIN" && strings.EqualFold(nilai, "VEVENT"):
+			sekarang = &icsEvent{}
+			durasi = nil
+			adaSelesai = false
+			kedalaman = 0
+			continue
+		case sekarang == nil:
+			continue
+		case nama == "BEGIN":
+			kedalaman++
+			continue
+		case nama == "END" && kedalaman > 0:
+			kedalaman--
+			continue
+		case kedalaman > 0:
+			continue
+		case nama == "END" && strings.EqualFold(nilai, "VEVENT"):
+			e := *sekarang
+			sekarang = nil
+			if e.Mulai.IsZero() {
+				peringatan = append(peringatan, fmt.Sprintf("VEVENT %s tanpa DTSTART dilewati", e.Summary))
+				continue
+			}
+			if !adaSelesai {
+				switch {
+				case durasi != nil:
+					e.Selesai = e.Mulai.Add(*durasi)
+				case e.AllDay:
+					e.Selesai = e.Mulai.AddDate(0, 0, 1)
+				default:
+					e.Selesai = e.Mulai.Add(time.Hour)
+				}
+			}
+			if !e.Selesai.After(e.Mulai) && !e.AllDay {
+				e.Selesai = e.Mulai.Add(time.Hour)
+			}
+			if e.UID == "" {
+				// tanpa UID dipakai hash judul dan waktu mulai agar import ulang tetap terdeteksi
+				h := sha1.Sum([]byte(e.Summary + "|" + e.Mulai.UTC().Format(time.RFC3339)))
+				e.UID = "tanpa-uid-" + hex.EncodeToString(h[:8])
+			}
+			events = append(events, e)
+			continue
+		}
+
+		switch nama {
+		case "UID":
+			sekarang.UID = nilai
+		case "SUMMARY":
+			sekarang.Summary = unescapeIcs(nilai)
+		case "LOCATION":
+			sekarang.Lokasi = unescapeIcs(nilai)
+		case "RRULE":
+			sekarang.RRule = nilai
+		case "DTSTART":
+			t, allDay, err := parseWaktuIcs(param, nilai)
+			if err != nil {
+				return nil, nil, fmt.Errorf("DTSTART tidak valid pada baris %d: %v", nomor+1, err)
+			}
+			sekarang.Mulai, sekarang.AllDay = t, allDay
+		case "DTEND":
+			t, _, err := parseWaktuIcs(param, nilai)
+			if err != nil {
+				return nil, nil, fmt.Errorf("DTEND tidak valid pada baris %d: %v", nomor+1, err)
+			}
+			sekarang.Selesai = t
+			adaSelesai = true
+		case "DURATION":
+			d, err := parseDurasiIcs(nilai)
+			if err != nil {
+				return nil, nil, fmt.Errorf("DURATION tidak valid pada baris %d: %v", nomor+1, err)
+			}
+			durasi = &d
+		case "EXDATE":
+			for _, v := range strings.Split(nilai, ",") {
+				t, _, err := parseWaktuIcs(param, v)
+				if err == nil {
+					sekarang.ExDate = append(sekarang.ExDate, t)
+				}
+			}
+		case "RECURRENCE-ID":
+			t, _, err := parseWaktuIcs(param, nilai)
+			if err == nil {
+				sekarang.RecurrenceID = &t
+			}
+		}
+	}
+	return events, peringatan, nil
+}
+
+// unfoldIcs menyambung baris lanjutan (diawali spasi atau tab) ke baris sebelumnya
+func unfoldIcs(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var baris []string
+	for scanner.Scan() {
+		b := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(b, " ") || strings.HasPrefix(b, "\t")) && len(baris) > 0 {
+			baris[len(baris)-1] += b[1:]
+			continue
+		}
+		if strings.TrimSpace(b) == "" {
+			continue
+		}
+		baris = append(baris, strings.TrimPrefix(b, "\ufeff"))
+	}
+	return baris, scanner.Err()
+}
+
+// parsePropertiIcs memecah "NAMA;PARAM=X:nilai"; titik dua di dalam tanda kutip parameter tidak dihitung
+func parsePropertiIcs(b string) (string, map[string]string, string) {
+	dalamKutip := false
+	pemisah := -1
+	for i, r := range b {
+		if r == '"' {
+			dalamKutip = !dalamKutip
+		}
+		if r == ':' && !dalamKutip {
+			pemisah = i
+			break
+		}
+	}
+	if pemisah < 0 {
+		return strings.ToUpper(b), nil, ""
+	}
+
+	bagian := strings.Split(b[:pemisah], ";")
+	param := make(map[string]string)
+	for _, p := range bagian[1:] {
+		kv := strings.SplitN(p, "=", 2)
+		if len(kv) == 2 {
+			param[strings.ToUpper(kv[0])] = strings.Trim(kv[1], `"`)
+		}
+	}
+	return strings.ToUpper(bagian[0]), param, b[pemisah+1:]
+}
+
+// parseWaktuIcs menangani DATE, DATE-TIME UTC (Z), dengan TZID, maupun floating (dianggap WIB)
+func parseWaktuIcs(param map[string]string, nilai string) (time.Time, bool, error) {
+	nilai = strings.TrimSpace(nilai)
+	loc := jakartaLocation()
+	if tzid := param["TZID"]; tzid != "" {
+		l, err := lokasiTzidIcs(tzid)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		loc = l
+	}
+
+	if param["VALUE"] == "DATE" || len(nilai) == 8 {
+		t, err := time.ParseInLocation("20060102", nilai, jakartaLocation())
+		return t, true, err
+	}
+	if strings.HasSuffix(nilai, "Z") {
+		t, err := time.Parse("20060102T150405Z", nilai)
+		return t.In(jakartaLocation()), false, err
+	}
+	t, err := time.ParseInLocation("20060102T150405", nilai, loc)
+	return t.In(jakartaLocation()), false, err
+}
+
+// lokasiTzidIcs menerima nama zona IANA atau nama zona Windows; TZID lain ditolak daripada salah dianggap WIB
+func lokasiTzidIcs(tzid string) (*time.Location, error) {
+	if iana, ok := zonaWindows[tzid]; ok {
+		tzid = iana
+	}
+	loc, err := time.LoadLocation(tzid)
+	if err != nil {
+		return nil, fmt.Errorf("zona waktu %q tidak dikenal", tzid)
+	}
+	return loc, nil
+}
+
+func parseDurasiIcs(nilai string) (time.Duration, error) {
+	cocok := durasiIcsRegex.FindStringSubmatch(strings.TrimSpace(nilai))
+	if cocok == nil {
+		return 0, fmt.Errorf("format durasi %s", nilai)
+	}
+	angka := func(s string) time.Duration {
+		n, _ := strconv.Atoi(s)
+		return time.Duration(n)
+	}
+	d := angka(cocok[2])*7*24*time.Hour + angka(cocok[3])*24*time.Hour +
+		angka(cocok[4])*time.Hour + angka(cocok[5])*time.Minute + angka(cocok[6])*time.Second
+	if cocok[1] == "-" {
+		d = -d
+	}
+	return d, nil
+}
+
+func unescapeIcs(s string) string {
+	return strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n").Replace(s)
+}
@@ -53,12 +53,13 @@ func CreateEventCuti(c *gin.Context) {
 		return
 	}
 
-	SetNotification(event.Title, startTime, "JadwalCuti") // Panggil fungsi SetNotification
 	if err := initializers.DB.Create(&event).Error; err != nil {
 		log.Printf("Error creating event: %v", err) // Add this line
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	SetNotificationEvent(event.Title, startTime, "JadwalCuti", sumberJadwalCuti, event.ID) // Panggil fungsi SetNotification
+	emitWebhook(eventJadwalCutiDibuat, event)
 	c.JSON(http.StatusOK, event)
 }
 
@@ -72,6 +73,11 @@ func DeleteEventCuti(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	// Lepas tautan pengajuan cuti yang event kalendernya dihapus manual
+	initializers.DB.Model(&models.PengajuanCuti{}).Where("jadwal_cuti_id = ?", id).Update("jadwal_cuti_id", nil)
+	if err := batalkanPengingatEvent(initializers.DB, sumberJadwalCuti, id); err != nil {
+		log.Printf("Error cancelling reminders for jadwal cuti %s: %v", id, err)
+	}
 	c.Status(http.StatusNoContent)
 }
 
@@ -83,6 +89,12 @@ func ExportJadwalCutiToExcel(c *gin.Context) {
 		return
 	}
 
+	// Hari libur nasional dan cuti bersama diberi warna pada kalender
+	kalender, err := muatKalenderKerja(initializers.DB)
+	if err != nil {
+		log.Printf("Error loading hari libur for export: %v", err)
+	}
+
 	f := excelize.NewFile()
 	sheet := "Calendar 2024"
 	f.NewSheet(sheet)
@@ -96,7 +108,7 @@ func ExportJadwalCutiToExcel(c *gin.Context) {
 	rowOffset := 0
 	colOffset := 0
 	for i, month := range months {
-		setMonthDataCuti(f, sheet, month, rowOffset, colOffset, events)
+		setMonthDataCuti(f, sheet, month, rowOffset, colOffset, events, kalender)
 		colOffset += 9 // Sesuaikan offset untuk bulan berikutnya dalam baris yang sama
 		if (i+1)%3 == 0 {
 			rowOffset += 18 // Pindah ke baris berikutnya setiap 3 bulan
@@ -121,7 +133,7 @@ func ExportJadwalCutiToExcel(c *gin.Context) {
 	c.Writer.Write(buffer.Bytes())
 }
 
-func setMonthDataCuti(f *excelize.File, sheet, month string, rowOffset, colOffset int, events []models.JadwalCuti) {
+func setMonthDataCuti(f *excelize.File, sheet, month string, rowOffset, colOffset int, events []models.JadwalCuti, kalender *kalenderKerja) {
 	var (
 		monthStyle, titleStyle, dataStyle, blankStyle,
 		grayBlankStyle, grayDataStyle int
@@ -158,7 +170,7 @@ func setMonthDataCuti(f *excelize.File, sheet, month string, rowOffset, colOffse
 				endDate, _ := time.Parse("2006-01-02", event.End)
 				currentDate := time.Date(monthTime.Year(), monthTime.Month(), day, 0, 0, 0, 0, time.UTC)
 
-				if (currentDate.Equal(startDate) || currentDate.After(startDate)) && currentDate.Before(endDate.AddDate(0, 0, 1)) {
+				if (currentDate.Equal(startDate) || currentDate.After(startDate)) && currentDate.Before(akhirHariEksklusif(startDate, endDate)) {
 					eventDetails[d] = event.Title // Place event title in the same column as the date
 				}
 			}
@@ -298,6 +310,7 @@ func setMonthDataCuti(f *excelize.File, sheet, month string, rowOffset, colOffse
 		fmt.Println(err)
 		return
 	}
+	tandaiHariLiburBulan(f, sheet, monthTime, rowOffset, colOffset, kalender)
 	// hide gridlines for the worksheet
 	disable := false
 	if err := f.SetSheetView(sheet, 0, &excelize.ViewOptions{
@@ -0,0 +1,504 @@
+package controllers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"project-its/initializers"
+	"project-its/middleware"
+	"project-its/models"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+)
+
+// Status blok ketersediaan: away berarti tidak di kantor seharian, busy berarti ada jadwal pada jam tertentu
+const (
+	statusKetersediaanAway = "away"
+	statusKetersediaanBusy = "busy"
+)
+
+// batas rentang agar satu permintaan tidak memuat data bertahun-tahun
+const maksHariKetersediaan = 366
+
+type blokKetersediaan struct {
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	AllDay bool      `json:"allDay"`
+	Status string    `json:"status"`
+	Alasan []string  `json:"alasan"`
+	Sumber []string  `json:"sumber"`
+}
+
+type ketersediaanUser struct {
+	UserID            uint               `json:"user_id"`
+	Username          string             `json:"username"`
+	HariKerja         int                `json:"hari_kerja"`
+	HariTidakTersedia int                `json:"hari_tidak_tersedia"` // hari kerja yang tertutup blok away
+	Blok              []blokKetersediaan `json:"blok"`
+}
+
+// KetersediaanTim menggabungkan cuti, perdin dan rapat menjadi blok away/busy per user.
+// Query: ?dari=&sampai= (YYYY-MM-DD, default 7 hari mulai hari ini), lalu salah satu dari
+// ?user_id=1,2,3 atau ?atasan_id= (atasan beserta bawahan langsungnya); default user yang login.
+// Selain admin hanya boleh melihat dirinya dan bawahan langsungnya; jenis cuti orang lain disamarkan.
+func KetersediaanTim(c *gin.Context) {
+	users, dari, sampai, err := parameterKetersediaan(c)
+	if err != nil {
+		respondKetersediaanError(c, err)
+		return
+	}
+
+	hasil := hitungKetersediaan(users, dari, sampai, c.MustGet("userID").(uint), middleware.HasRole(c, roleAdmin))
+	c.JSON(http.StatusOK, gin.H{
+		"dari":         dari.Format("2006-01-02"),
+		"sampai":       sampai.AddDate(0, 0, -1).Format("2006-01-02"),
+		"ketersediaan": hasil,
+	})
+}
+
+// ExportKetersediaanTim mencetak ketersediaan ke kalender bulanan seperti export kalender lain,
+// ditambah sheet Detail berisi setiap blok.
+func ExportKetersediaanTim(c *gin.Context) {
+	users, dari, sampai, err := parameterKetersediaan(c)
+	if err != nil {
+		respondKetersediaanError(c, err)
+		return
+	}
+	hasil := hitungKetersediaan(users, dari, sampai, c.MustGet("userID").(uint), middleware.HasRole(c, roleAdmin))
+	kalender, err := muatKalenderKerja(initializers.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Satu entri per hari berisi semua user yang away/busy, agar sel kalender tidak saling menimpa
+	loc := jakartaLocation()
+	perHari := make(map[string][]string)
+	for _, u := range hasil {
+		for _, blok := range u.Blok {
+			for d := truncateHariKetersediaan(blok.Start, loc); d.Before(blok.End); d = d.AddDate(0, 0, 1) {
+				if d.Before(dari) || !d.Before(sampai) {
+					continue
+				}
+				kunci := d.Format("2006-01-02")
+				perHari[kunci] = append(perHari[kunci], fmt.Sprintf("%s: %s", u.Username, ringkasBlok(blok)))
+			}
+		}
+	}
+	events := []models.JadwalCuti{}
+	for tanggal, daftar := range perHari {
+		events = append(events, models.JadwalCuti{Title: strings.Join(daftar, ", "), Start: tanggal, End: tanggal, AllDay: true})
+	}
+
+	f := excelize.NewFile()
+	sheet := "Ketersediaan"
+	f.NewSheet(sheet)
+
+	rowOffset := 0
+	colOffset := 0
+	i := 0
+	for bulan := time.Date(dari.Year(), dari.Month(), 1, 0, 0, 0, 0, loc); bulan.Before(sampai); bulan = bulan.AddDate(0, 1, 0) {
+		setMonthDataCuti(f, sheet, bulan.Format("January 2006"), rowOffset, colOffset, events, kalender)
+		colOffset += 9 // Sesuaikan offset untuk bulan berikutnya dalam baris yang sama
+		if (i+1)%3 == 0 {
+			rowOffset += 18 // Pindah ke baris berikutnya setiap 3 bulan
+			colOffset = 0
+		}
+		i++
+	}
+
+	detail := "Detail"
+	f.NewSheet(detail)
+	f.SetSheetRow(detail, "A1", &[]interface{}{"Nama", "Status", "Mulai", "Selesai", "Alasan", "Sumber"})
+	row := 2
+	for _, u := range hasil {
+		for _, blok := range u.Blok {
+			layout := "2006-01-02 15:04"
+			selesai := blok.End
+			if blok.AllDay {
+				layout = "2006-01-02"
+				selesai = selesai.AddDate(0, 0, -1)
+			}
+			f.SetSheetRow(detail, fmt.Sprintf("A%d", row), &[]interface{}{
+				u.Username, blok.Status, blok.Start.Format(layout), selesai.Format(layout),
+				strings.Join(blok.Alasan, "; "), strings.Join(blok.Sumber, ", "),
+			})
+			row++
+		}
+	}
+	f.SetColWidth(detail, "A", "B", 15)
+	f.SetColWidth(detail, "C", "D", 18)
+	f.SetColWidth(detail, "E", "E", 50)
+	f.SetColWidth(detail, "F", "F", 30)
+
+	// Hapus sheet default
+	f.DeleteSheet("Sheet1")
+
+	var buffer bytes.Buffer
+	if err := f.Write(&buffer); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=Ketersediaan_%s_%s.xlsx", dari.Format("20060102"), sampai.AddDate(0, 0, -1).Format("20060102")))
+	c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Header("Content-Length", strconv.Itoa(len(buffer.Bytes())))
+	c.Writer.Write(buffer.Bytes())
+}
+
+// parameterKetersediaan mengembalikan daftar user dan rentang [dari, sampai) dalam zona Jakarta
+func parameterKetersediaan(c *gin.Context) ([]models.User, time.Time, time.Time, error) {
+	loc := jakartaLocation()
+	now := time.Now().In(loc)
+	dari := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	sampai := dari.AddDate(0, 0, 7)
+
+	if s := c.Query("dari"); s != "" {
+		t, err := time.ParseInLocation("2006-01-02", s, loc)
+		if err != nil {
+			return nil, dari, sampai, fmt.Errorf("Format dari harus YYYY-MM-DD")
+		}
+		dari = t
+		sampai = dari.AddDate(0, 0, 7)
+	}
+	if s := c.Query("sampai"); s != "" {
+		t, err := time.ParseInLocation("2006-01-02", s, loc)
+		if err != nil {
+			return nil, dari, sampai, fmt.Errorf("Format sampai harus YYYY-MM-DD")
+		}
+		sampai = t.AddDate(0, 0, 1)
+	}
+	if !sampai.After(dari) {
+		return nil, dari, sampai, fmt.Errorf("Tanggal sampai tidak boleh sebelum tanggal dari")
+	}
+	if sampai.Sub(dari) > maksHariKetersediaan*24*time.Hour {
+		return nil, dari, sampai, fmt.Errorf("Rentang maksimal %d hari", maksHariKetersediaan)
+	}
+
+	var users []models.User
+	userID := c.MustGet("userID").(uint)
+	admin := middleware.HasRole(c, roleAdmin)
+	query := initializers.DB.Order("username asc")
+	if !admin {
+		query = query.Where("id = ? OR atasan_id = ?", userID, userID)
+	}
+	diminta := 0
+	switch {
+	case c.Query("user_id") != "":
+		ids := []uint{}
+		for _, s := range strings.Split(c.Query("user_id"), ",") {
+			id, err := strconv.ParseUint(strings.TrimSpace(s), 10, 64)
+			if err != nil {
+				return nil, dari, sampai, fmt.Errorf("user_id tidak valid: %s", s)
+			}
+			ids = append(ids, uint(id))
+		}
+		query = query.Where("id IN ?", ids)
+		diminta = len(ids)
+	case c.Query("atasan_id") != "":
+		atasanID, err := strconv.ParseUint(c.Query("atasan_id"), 10, 64)
+		if err != nil {
+			return nil, dari, sampai, fmt.Errorf("atasan_id tidak valid")
+		}
+		if !admin && uint(atasanID) != userID {
+			return nil, dari, sampai, errAksesKetersediaan
+		}
+		query = query.Where("id = ? OR atasan_id = ?", atasanID, atasanID)
+	default:
+		query = query.Where("id = ?", userID)
+	}
+	if err := query.Find(&users).Error; err != nil {
+		return nil, dari, sampai, err
+	}
+	if !admin && len(users) < diminta {
+		return nil, dari, sampai, errAksesKetersediaan
+	}
+	if len(users) == 0 {
+		return nil, dari, sampai, fmt.Errorf("User tidak ditemukan")
+	}
+	return users, dari, sampai, nil
+}
+
+var errAksesKetersediaan = errors.New("Hanya dapat melihat ketersediaan sendiri atau bawahan langsung")
+
+func respondKetersediaanError(c *gin.Context, err error) {
+	if errors.Is(err, errAksesKetersediaan) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
+
+// hitungKetersediaan mengumpulkan blok dari setiap sumber lalu menggabungkan blok yang bertumpuk.
+// Hanya sumber yang tertaut ke user yang dihitung: pengajuan cuti, peserta/pembuat perdin, pembuat booking
+// dan PIC meeting schedule (username). Jadwal rapat dan jadwal cuti manual tidak punya pemilik sehingga dilewati.
+// Jenis cuti hanya terlihat oleh pemiliknya dan admin (penampilID, admin).
+func hitungKetersediaan(users []models.User, dari, sampai time.Time, penampilID uint, admin bool) []ketersediaanUser {
+	loc := jakartaLocation()
+	kalender, err := muatKalenderKerja(initializers.DB)
+	if err != nil {
+		log.Printf("Error loading hari libur for ketersediaan: %v", err)
+	}
+	blok := make(map[uint][]blokKetersediaan)
+	tambah := func(userID uint, b blokKetersediaan) {
+		if b.Start.Before(sampai) && b.End.After(dari) {
+			blok[userID] = append(blok[userID], b)
+		}
+	}
+
+	ids := make([]uint, len(users))
+	for i, u := range users {
+		ids[i] = u.ID
+	}
+
+	// Pengajuan cuti (disetujui maupun menunggu)
+	var pengajuan []models.PengajuanCuti
+	initializers.DB.
+		Where("user_id IN ? AND status IN ?", ids, []string{statusCutiDiajukan, statusCutiDisetujui}).
+		Where("tanggal_mulai < ? AND tanggal_selesai >= ?", sampai, dari.AddDate(0, 0, -1)).
+		Find(&pengajuan)
+	jenisCuti := make(map[uint]string)
+	var semuaJenis []models.JenisCuti
+	initializers.DB.Find(&semuaJenis)
+	for _, j := range semuaJenis {
+		jenisCuti[j.ID] = j.Nama
+	}
+	for _, p := range pengajuan {
+		alasan := jenisCuti[p.JenisCutiID]
+		if p.UserID != penampilID && !admin {
+			alasan = "Cuti"
+		}
+		if p.Status == statusCutiDiajukan {
+			alasan += " (menunggu persetujuan)"
+		}
+		tambah(p.UserID, blokKetersediaan{
+			Start:  tanggalKetersediaan(*p.TanggalMulai, loc),
+			End:    tanggalKetersediaan(*p.TanggalSelesai, loc).AddDate(0, 0, 1),
+			AllDay: true,
+			Status: statusKetersediaanAway,
+			Alasan: []string{alasan},
+			Sumber: []string{fmt.Sprintf("PengajuanCuti#%d", p.ID)},
+		})
+	}
+
+	// Perdin: peserta selama tanggal berangkat sampai kembali; perdin lama tanpa peserta
+	// dianggap milik pembuatnya pada tanggal perdin
+	var perdin []models.Perdin
+	initializers.DB.
+		Where("(tanggal_berangkat IS NOT NULL AND tanggal_berangkat < ? AND COALESCE(tanggal_kembali, tanggal_berangkat) >= ?) OR (tanggal_berangkat IS NULL AND tanggal >= ? AND tanggal < ?)",
+			sampai, dari.AddDate(0, 0, -1), dari.AddDate(0, 0, -1), sampai).
+		Find(&perdin)
+	perdinIDs := make([]uint, len(perdin))
+	for i, p := range perdin {
+		perdinIDs[i] = p.ID
+	}
+	pesertaPerdin := make(map[uint][]uint)
+	if len(perdinIDs) > 0 {
+		var peserta []models.PerdinPeserta
+		initializers.DB.Where("perdin_id IN ?", perdinIDs).Find(&peserta)
+		for _, p := range peserta {
+			pesertaPerdin[p.PerdinID] = append(pesertaPerdin[p.PerdinID], p.UserID)
+		}
+	}
+	for _, p := range perdin {
+		mulai, kembali := p.TanggalBerangkat, p.TanggalKembali
+		if mulai == nil {
+			mulai = p.Tanggal
+		}
+		if kembali == nil {
+			kembali = mulai
+		}
+		if mulai == nil {
+			continue
+		}
+		alasan := "Perjalanan dinas " + derefString(p.NoPerdin)
+		if p.Tujuan != nil && *p.Tujuan != "" {
+			alasan += " ke " + *p.Tujuan
+		}
+		b := blokKetersediaan{
+			Start:  tanggalKetersediaan(*mulai, loc),
+			End:    tanggalKetersediaan(*kembali, loc).AddDate(0, 0, 1),
+			AllDay: true,
+			Status: statusKetersediaanAway,
+			Alasan: []string{alasan},
+			Sumber: []string{fmt.Sprintf("Perdin#%d", p.ID)},
+		}
+		for _, u := range users {
+			ikut := strings.EqualFold(p.CreateBy, u.Username)
+			if daftar, ok := pesertaPerdin[p.ID]; ok {
+				ikut = false
+				for _, id := range daftar {
+					if id == u.ID {
+						ikut = true
+						break
+					}
+				}
+			}
+			if ikut {
+				tambah(u.ID, b)
+			}
+		}
+	}
+
+	// Booking ruang rapat oleh user yang memesan
+	var booking []models.BookingRapat
+	initializers.DB.Find(&booking)
+	for _, e := range expandBookingRapat(booking, dari, sampai, true) {
+		mulai, selesai, err := rentangEventKalender(e.AllDay, e.Start, e.End)
+		if err != nil {
+			continue
+		}
+		for _, u := range users {
+			if strings.EqualFold(e.CreateBy, u.Username) {
+				tambah(u.ID, blokJadwal(mulai, selesai, e.AllDay, loc, "Booking ruang: "+e.Title, fmt.Sprintf("BookingRapat#%d", e.ID)))
+			}
+		}
+	}
+
+	// Meeting schedule dengan PIC; tanpa jam yang bisa dibaca dianggap seharian
+	var meetings []models.MeetingSchedule
+	initializers.DB.Where("tanggal >= ? AND tanggal < ?", dari.AddDate(0, 0, -1), sampai).Find(&meetings)
+	for _, m := range meetings {
+		if m.Tanggal == nil || isMeetingScheduleBatal(derefString(m.Status)) {
+			continue
+		}
+		tanggal := tanggalKetersediaan(*m.Tanggal, loc)
+		mulai, adaJam := jamMeeting(tanggal, derefString(m.Waktu))
+		selesai := tanggal.AddDate(0, 0, 1)
+		if adaJam {
+			if s, ok := jamMeeting(tanggal, derefString(m.Selesai)); ok && s.After(mulai) {
+				selesai = s
+			} else {
+				selesai = mulai.Add(time.Hour)
+			}
+		}
+		for _, u := range users {
+			if strings.EqualFold(strings.TrimSpace(derefString(m.Pic)), u.Username) {
+				tambah(u.ID, blokKetersediaan{
+					Start: mulai, End: selesai, AllDay: !adaJam, Status: statusKetersediaanBusy,
+					Alasan: []string{"Meeting: " + derefString(m.Perihal)},
+					Sumber: []string{fmt.Sprintf("MeetingSchedule#%d", m.ID)},
+				})
+			}
+		}
+	}
+
+	hasil := []ketersediaanUser{}
+	akhir := sampai.AddDate(0, 0, -1)
+	for _, u := range users {
+		gabungan := gabungBlokKetersediaan(blok[u.ID])
+		item := ketersediaanUser{
+			UserID:    u.ID,
+			Username:  u.Username,
+			HariKerja: kalender.hitungHariKerja(dari, akhir),
+			Blok:      gabungan,
+		}
+		for d := dari; d.Before(sampai); d = d.AddDate(0, 0, 1) {
+			if kalender.isHariKerja(d) && tertutupAway(gabungan, d) {
+				item.HariTidakTersedia++
+			}
+		}
+		hasil = append(hasil, item)
+	}
+	return hasil
+}
+
+// gabungBlokKetersediaan menyatukan blok berstatus sama yang bertumpuk atau bersambung
+func gabungBlokKetersediaan(blok []blokKetersediaan) []blokKetersediaan {
+	sort.Slice(blok, func(i, j int) bool {
+		if !blok[i].Start.Equal(blok[j].Start) {
+			return blok[i].Start.Before(blok[j].Start)
+		}
+		return blok[i].Status < blok[j].Status
+	})
+
+	hasil := []blokKetersediaan{}
+	for _, b := range blok {
+		gabung := false
+		for i := len(hasil) - 1; i >= 0; i-- {
+			h := &hasil[i]
+			if h.Status != b.Status || b.Start.After(h.End) {
+				continue
+			}
+			if b.End.After(h.End) {
+				h.End = b.End
+			}
+			h.AllDay = h.AllDay && b.AllDay
+			h.Alasan = append(h.Alasan, b.Alasan...)
+			h.Sumber = append(h.Sumber, b.Sumber...)
+			gabung = true
+			break
+		}
+		if !gabung {
+			hasil = append(hasil, b)
+		}
+	}
+	return hasil
+}
+
+func tertutupAway(blok []blokKetersediaan, hari time.Time) bool {
+	for _, b := range blok {
+		if b.Status == statusKetersediaanAway && !b.Start.After(hari) && b.End.After(hari) {
+			return true
+		}
+	}
+	return false
+}
+
+// blokHariPenuh membulatkan rentang ke hari penuh, dipakai untuk cuti
+func blokHariPenuh(mulai, selesai time.Time, loc *time.Location, status, alasan, sumber string) blokKetersediaan {
+	return blokKetersediaan{
+		Start:  truncateHariKetersediaan(mulai, loc),
+		End:    truncateHariKetersediaan(selesai.Add(-time.Nanosecond), loc).AddDate(0, 0, 1),
+		AllDay: true,
+		Status: status,
+		Alasan: []string{alasan},
+		Sumber: []string{sumber},
+	}
+}
+
+func blokJadwal(mulai, selesai time.Time, allDay bool, loc *time.Location, alasan, sumber string) blokKetersediaan {
+	if allDay {
+		return blokHariPenuh(mulai, selesai, loc, statusKetersediaanBusy, alasan, sumber)
+	}
+	return blokKetersediaan{
+		Start:  mulai.In(loc),
+		End:    selesai.In(loc),
+		Status: statusKetersediaanBusy,
+		Alasan: []string{alasan},
+		Sumber: []string{sumber},
+	}
+}
+
+func ringkasBlok(blok blokKetersediaan) string {
+	alasan := strings.Join(blok.Alasan, "; ")
+	if blok.AllDay {
+		return alasan
+	}
+	return fmt.Sprintf("%s-%s %s", blok.Start.Format("15:04"), blok.End.Format("15:04"), alasan)
+}
+
+// tanggalKetersediaan mengambil tanggal kalender dari kolom date lalu menempatkannya di tengah malam WIB
+func tanggalKetersediaan(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+func truncateHariKetersediaan(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+func isMeetingScheduleBatal(status string) bool {
+	switch strings.ToLower(strings.TrimSpace(status)) {
+	case "cancel", "canceled", "cancelled", "batal", "dibatalkan":
+		return true
+	}
+	return false
+}
@@ -189,12 +189,27 @@ func MeetingCreate(c *gin.Context) {
 		return
 	}
 
-	tanggal_actual, err := time.Parse("2006-01-02", *requestBody.TanggalActual)
-	if err != nil {
-		log.Printf("Error parsing date: %v", err)
-		c.Status(400)
-		c.JSON(400, gin.H{"error": "Invalid date format: " + err.Error()})
-		return
+	// Tanggal actual tidak wajib, diisi otomatis saat task Done
+	var tanggal_actual *time.Time
+	if requestBody.TanggalActual != nil && *requestBody.TanggalActual != "" {
+		parsed, err := time.Parse("2006-01-02", *requestBody.TanggalActual)
+		if err != nil {
+			log.Printf("Error parsing date: %v", err)
+			c.Status(400)
+			c.JSON(400, gin.H{"error": "Invalid date format: " + err.Error()})
+			return
+		}
+		tanggal_actual = &parsed
+	}
+
+	status := statusMeetingOpen
+	if requestBody.Status != nil && *requestBody.Status != "" {
+		normalized, ok := normalisasiStatusMeeting(*requestBody.Status)
+		if !ok {
+			c.JSON(400, gin.H{"error": "Status tidak dikenal: " + *requestBody.Status})
+			return
+		}
+		status = normalized
 	}
 
 	requestBody.CreateBy = c.MustGet("username").(string)
@@ -202,13 +217,14 @@ func MeetingCreate(c *gin.Context) {
 	meeting := models.Meeting{
 		Task:             requestBody.Task,
 		TindakLanjut:     requestBody.TindakLanjut,
-		Status:           requestBody.Status,
+		Status:           &status,
 		UpdatePengerjaan: requestBody.UpdatePengerjaan,
 		Pic:              requestBody.Pic,
 		TanggalTarget:    &tanggal_target,
-		TanggalActual:    &tanggal_actual,
+		TanggalActual:    tanggal_actual,
 		CreateBy:         requestBody.CreateBy,
 	}
+	sesuaikanTanggalActual(&meeting, time.Now())
 
 	result := initializers.DB.Create(&meeting)
 
@@ -219,7 +235,7 @@ func MeetingCreate(c *gin.Context) {
 	}
 
 	c.JSON(201, gin.H{
-		"meeting": meeting,
+		"meeting": &meeting,
 	})
 
 }
@@ -233,7 +249,7 @@ func MeetingShow(c *gin.Context) {
 	initializers.DB.First(&meeting, id)
 
 	c.JSON(200, gin.H{
-		"meeting": meeting,
+		"meeting": &meeting,
 	})
 
 }
@@ -268,7 +284,15 @@ func MeetingUpdate(c *gin.Context) {
 		meeting.TanggalTarget = &tanggal_target
 	}
 
-	if requestBody.TanggalActual != nil {
+	if requestBody.Status != nil {
+		if err := ubahStatusMeeting(&meeting, *requestBody.Status, time.Now()); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// Tanggal actual hanya bisa dikoreksi untuk task yang sudah Done
+	if requestBody.TanggalActual != nil && statusMeeting(meeting) == statusMeetingDone {
 		tanggal_actual, err := time.Parse("2006-01-02", *requestBody.TanggalActual)
 		if err != nil {
 			c.JSON(400, gin.H{"error": "Format tanggal tidak valid: " + err.Error()})
@@ -289,12 +313,6 @@ func MeetingUpdate(c *gin.Context) {
 		meeting.TindakLanjut = meeting.TindakLanjut
 	}
 
-	if requestBody.Status != nil {
-		meeting.Status = requestBody.Status
-	} else {
-		meeting.Status = meeting.Status
-	}
-
 	if requestBody.UpdatePengerjaan != nil {
 		meeting.UpdatePengerjaan = requestBody.UpdatePengerjaan
 	} else {
@@ -310,7 +328,7 @@ func MeetingUpdate(c *gin.Context) {
 	initializers.DB.Save(&meeting)
 
 	c.JSON(200, gin.H{
-		"meeting": meeting,
+		"meeting": &meeting,
 	})
 }
 
@@ -414,7 +432,7 @@ func CreateExcelMeeting(c *gin.Context) {
 	meetingSheetName := "MEETING"
 	for i, meeting := range meetings {
 		tanggalTargetString := meeting.TanggalTarget.Format("2006-01-02")
-		tanggalActualString := meeting.TanggalActual.Format("2006-01-02")
+		tanggalActualString := models.FormatTanggal(meeting.TanggalActual, "2006-01-02")
 		rowNum := i + 2 // Start from the second row (first row is header)
 
 		// Check for nil pointers and use the actual values
@@ -635,7 +653,7 @@ func UpdateSheetMeeting(c *gin.Context) {
 		}
 		f.SetCellValue(sheetName, fmt.Sprintf("E%d", rowNum), meeting.Pic)
 		f.SetCellValue(sheetName, fmt.Sprintf("F%d", rowNum), meeting.TanggalTarget.Format("2006-01-02"))
-		f.SetCellValue(sheetName, fmt.Sprintf("G%d", rowNum), meeting.TanggalActual.Format("2006-01-02"))
+		f.SetCellValue(sheetName, fmt.Sprintf("G%d", rowNum), models.FormatTanggal(meeting.TanggalActual, "2006-01-02"))
 	}
 
 	// Save the file with updated data
@@ -696,8 +714,8 @@ func ImportExcelMeeting(c *gin.Context) {
 			// Lewati header baris jika ada
 			continue
 		}
-		if len(row) < 7 {
-			// Pastikan ada cukup kolom
+		if len(row) < 6 {
+			// Pastikan ada cukup kolom, tanggal actual boleh kosong
 			continue
 		}
 		task := row[0]
@@ -706,7 +724,10 @@ func ImportExcelMeeting(c *gin.Context) {
 		updatePengerjaan := row[3]
 		pic := row[4]
 		tanggalTargetString := row[5]
-		tanggalActualString := row[6]
+		tanggalActualString := ""
+		if len(row) > 6 {
+			tanggalActualString = row[6]
+		}
 
 		// Parse tanggal
 		tanggalTarget, err := time.Parse("2006-01-02", tanggalTargetString)
@@ -714,22 +735,33 @@ func ImportExcelMeeting(c *gin.Context) {
 			c.String(http.StatusBadRequest, "Invalid date format in row %d: %v", i+1, err)
 			return
 		}
-		tanggalActual, err := time.Parse("2006-01-02", tanggalActualString)
-		if err != nil {
-			c.String(http.StatusBadRequest, "Invalid date format in row %d: %v", i+1, err)
+		var tanggalActual *time.Time
+		if tanggalActualString != "" {
+			parsed, err := time.Parse("2006-01-02", tanggalActualString)
+			if err != nil {
+				c.String(http.StatusBadRequest, "Invalid date format in row %d: %v", i+1, err)
+				return
+			}
+			tanggalActual = &parsed
+		}
+
+		normalized, ok := normalisasiStatusMeeting(status)
+		if !ok {
+			c.String(http.StatusBadRequest, "Status tidak dikenal in row %d: %s", i+1, status)
 			return
 		}
 
 		meeting := models.Meeting{
 			Task:             &task,
 			TindakLanjut:     &tindakLanjut,
-			Status:           &status,
+			Status:           &normalized,
 			UpdatePengerjaan: &updatePengerjaan,
 			Pic:              &pic,
 			TanggalTarget:    &tanggalTarget,
-			TanggalActual:    &tanggalActual,
+			TanggalActual:    tanggalActual,
 			CreateBy:         c.MustGet("username").(string),
 		}
+		sesuaikanTanggalActual(&meeting, time.Now())
 
 		// Simpan ke database
 		if err := initializers.DB.Create(&meeting).Error; err != nil {
@@ -0,0 +1,259 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"project-its/initializers"
+	"project-its/models"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Status action item meeting; label mengikuti pilihan yang sudah dipakai di halaman Meeting
+const (
+	statusMeetingOpen       = "Open"
+	statusMeetingOnProgress = "On Progress"
+	statusMeetingDone       = "Done"
+	statusMeetingCancel     = "Cancel"
+)
+
+// transisiStatusMeeting berisi status tujuan yang boleh dari setiap status.
+// Done dan Cancel masih bisa dibuka kembali bila ternyata pekerjaan berlanjut.
+var transisiStatusMeeting = map[string][]string{
+	statusMeetingOpen:       {statusMeetingOnProgress, statusMeetingDone, statusMeetingCancel},
+	statusMeetingOnProgress: {statusMeetingOpen, statusMeetingDone, statusMeetingCancel},
+	statusMeetingDone:       {statusMeetingOnProgress},
+	statusMeetingCancel:     {statusMeetingOpen},
+}
+
+type MeetingStatusRequest struct {
+	Status           *string `json:"status"`
+	UpdatePengerjaan *string `json:"update_pengerjaan"`
+}
+
+type meetingOverdue struct {
+	Meeting       *models.Meeting `json:"meeting"`
+	HariTerlambat int             `json:"hari_terlambat"`
+}
+
+type meetingOverduePic struct {
+	Pic    string           `json:"pic"`
+	Jumlah int              `json:"jumlah"`
+	Items  []meetingOverdue `json:"items"`
+}
+
+// MeetingUbahStatus memindahkan task ke status berikutnya sesuai transisi yang diizinkan
+func MeetingUbahStatus(c *gin.Context) {
+	var req MeetingStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Status == nil || *req.Status == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Status wajib diisi"})
+		return
+	}
+
+	var meeting models.Meeting
+	if err := initializers.DB.First(&meeting, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "meeting not found"})
+		return
+	}
+
+	if err := ubahStatusMeeting(&meeting, *req.Status, time.Now()); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.UpdatePengerjaan != nil {
+		meeting.UpdatePengerjaan = req.UpdatePengerjaan
+	}
+
+	if err := initializers.DB.Save(&meeting).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"meeting": &meeting})
+}
+
+// MeetingOverdueReport mengelompokkan task yang lewat target per PIC, filter opsional ?pic=
+func MeetingOverdueReport(c *gin.Context) {
+	overdue, err := findMeetingOverdue(time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	filterPic := strings.TrimSpace(c.Query("pic"))
+	perPic := make(map[string]*meetingOverduePic)
+	for _, item := range overdue {
+		pic := strings.TrimSpace(derefString(item.Meeting.Pic))
+		if pic == "" {
+			pic = "-"
+		}
+		if filterPic != "" && !strings.EqualFold(pic, filterPic) {
+			continue
+		}
+		grup, ok := perPic[pic]
+		if !ok {
+			grup = &meetingOverduePic{Pic: pic, Items: []meetingOverdue{}}
+			perPic[pic] = grup
+		}
+		grup.Items = append(grup.Items, item)
+		grup.Jumlah++
+	}
+
+	report := []meetingOverduePic{}
+	total := 0
+	for _, grup := range perPic {
+		report = append(report, *grup)
+		total += grup.Jumlah
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Jumlah != report[j].Jumlah {
+			return report[i].Jumlah > report[j].Jumlah
+		}
+		return report[i].Pic < report[j].Pic
+	})
+
+	c.JSON(http.StatusOK, gin.H{"total": total, "pic": report})
+}
+
+// CheckMeetingOverdue dijalankan scheduler dan mengirim pengingat ke PIC untuk task yang lewat target
+func CheckMeetingOverdue() {
+	overdue, err := findMeetingOverdue(time.Now())
+	if err != nil {
+		log.Printf("Error checking meeting overdue: %v", err)
+		return
+	}
+
+	hariIni := time.Now().In(jakartaLocation()).Format("2006-01-02")
+	for _, item := range overdue {
+		// Satu pengingat per task per hari keterlambatan, ditujukan ke PIC; PIC yang bukan pengguna diteruskan ke admin
+		pic := derefString(item.Meeting.Pic)
+		title := fmt.Sprintf("[PIC %s] Task %s lewat target %s (%d hari)", pic, derefString(item.Meeting.Task), item.Meeting.TanggalTarget.Format("2006-01-02"), item.HariTerlambat)
+		kunci := fmt.Sprintf("MeetingOverdue:%d:%s", item.Meeting.ID, hariIni)
+
+		if userID := userIDPic(pic); userID != nil {
+			createNotificationUnik(title, time.Now(), "MeetingOverdue", userID, kunci)
+			continue
+		}
+		role := roleAdmin
+		kirimNotifikasi(&models.Notification{
+			Title:     title,
+			Start:     time.Now(),
+			Category:  "MeetingOverdue",
+			Role:      &role,
+			KunciUnik: &kunci,
+		})
+	}
+}
+
+// userIDPic mencari pengguna dari PIC yang diisi bebas, berupa username tanpa membedakan huruf besar
+func userIDPic(pic string) *uint {
+	pic = strings.TrimSpace(pic)
+	var user models.User
+	if pic == "" || initializers.DB.Select("id").Where("LOWER(username) = LOWER(?)", pic).Take(&user).Error != nil {
+		return nil
+	}
+	return &user.ID
+}
+
+func findMeetingOverdue(now time.Time) ([]meetingOverdue, error) {
+	var meetings []models.Meeting
+	if err := initializers.DB.
+		Where("tanggal_target IS NOT NULL").
+		Where("status IS NULL OR status NOT IN ?", []string{statusMeetingDone, statusMeetingCancel}).
+		Order("tanggal_target asc").
+		Find(&meetings).Error; err != nil {
+		return nil, err
+	}
+
+	kalender, err := muatKalenderKerja(initializers.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	today := truncateToDate(now)
+	overdue := []meetingOverdue{}
+	for i := range meetings {
+		m := &meetings[i]
+		// Target yang jatuh pada akhir pekan atau hari libur berlaku sampai hari kerja berikutnya
+		if !isLewatBatas(kalender.hariKerjaBerikutnya(*m.TanggalTarget), now) {
+			continue
+		}
+		overdue = append(overdue, meetingOverdue{
+			Meeting:       m,
+			HariTerlambat: int(today.Sub(truncateToDate(*m.TanggalTarget)).Hours() / 24),
+		})
+	}
+	return overdue, nil
+}
+
+// ubahStatusMeeting memvalidasi transisi lalu menyesuaikan tanggal actual
+func ubahStatusMeeting(meeting *models.Meeting, status string, now time.Time) error {
+	tujuan, ok := normalisasiStatusMeeting(status)
+	if !ok {
+		return fmt.Errorf("Status tidak dikenal: %s", status)
+	}
+	asal := statusMeeting(*meeting)
+	if tujuan == asal {
+		return nil
+	}
+
+	diizinkan := false
+	for _, s := range transisiStatusMeeting[asal] {
+		if s == tujuan {
+			diizinkan = true
+			break
+		}
+	}
+	if !diizinkan {
+		return fmt.Errorf("Status tidak dapat diubah dari %s ke %s", asal, tujuan)
+	}
+
+	meeting.Status = &tujuan
+	sesuaikanTanggalActual(meeting, now)
+	return nil
+}
+
+// sesuaikanTanggalActual mengisi tanggal actual saat Done dan mengosongkannya untuk status lain
+func sesuaikanTanggalActual(meeting *models.Meeting, now time.Time) {
+	if statusMeeting(*meeting) != statusMeetingDone {
+		meeting.TanggalActual = nil
+		return
+	}
+	if meeting.TanggalActual == nil {
+		today := truncateToDate(now.In(jakartaLocation()))
+		meeting.TanggalActual = &today
+	}
+}
+
+// statusMeeting mengembalikan status baku; data lama tanpa status dianggap Open
+func statusMeeting(meeting models.Meeting) string {
+	if meeting.Status == nil {
+		return statusMeetingOpen
+	}
+	if status, ok := normalisasiStatusMeeting(*meeting.Status); ok {
+		return status
+	}
+	return statusMeetingOpen
+}
+
+// normalisasiStatusMeeting menerima label lama maupun bahasa inggris dan mengembalikan label baku
+func normalisasiStatusMeeting(status string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(status)) {
+	case "", "open", "not started", "belum":
+		return statusMeetingOpen, true
+	case "on progress", "in progress", "progress", "proses":
+		return statusMeetingOnProgress, true
+	case "done", "selesai":
+		return statusMeetingDone, true
+	case "cancel", "cancelled", "canceled", "batal":
+		return statusMeetingCancel, true
+	}
+	return "", false
+}
@@ -258,6 +258,7 @@ func MemoCreate(c *gin.Context) {
 		return
 	}
 	log.Printf("Memo created successfully: %v", memosag)
+	emitWebhook(eventMemoDibuat, memosag)
 
 	c.JSON(201, gin.H{
 		"memo": memosag,
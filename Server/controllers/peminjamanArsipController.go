@@ -0,0 +1,485 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"project-its/initializers"
+	"project-its/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	statusPinjamDiajukan     = "Diajukan"
+	statusPinjamDisetujui    = "Disetujui"
+	statusPinjamDitolak      = "Ditolak"
+	statusPinjamDipinjam     = "Dipinjam"
+	statusPinjamDikembalikan = "Dikembalikan"
+
+	sumberPeminjamanArsip = "PeminjamanArsip"
+
+	// arsip boleh dikembalikan sampai akhir jam kerja (WIB) pada hari batas kembali
+	jamAkhirHariKerja = 17
+)
+
+type PeminjamanArsipRequest struct {
+	ArsipID      *uint   `json:"arsip_id"`
+	Divisi       *string `json:"divisi"`
+	Keperluan    *string `json:"keperluan"`
+	BatasKembali *string `json:"batas_kembali"`
+}
+
+type peminjamanProsesRequest struct {
+	BatasKembali *string `json:"batas_kembali"`
+	Kondisi      *string `json:"kondisi"`
+	Catatan      *string `json:"catatan"`
+}
+
+type peminjamanOverdue struct {
+	Peminjaman    *models.PeminjamanArsip `json:"peminjaman"`
+	Arsip         models.Arsip            `json:"arsip"`
+	HariTerlambat int                     `json:"hari_terlambat"`
+}
+
+func PeminjamanArsipIndex(c *gin.Context) {
+	query := initializers.DB.Order("id desc")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var peminjaman []models.PeminjamanArsip
+	if err := query.Find(&peminjaman).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"peminjaman": peminjaman})
+}
+
+func PeminjamanArsipShow(c *gin.Context) {
+	var peminjaman models.PeminjamanArsip
+	if err := initializers.DB.First(&peminjaman, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Peminjaman tidak ditemukan"})
+		return
+	}
+
+	var arsip models.Arsip
+	initializers.DB.First(&arsip, peminjaman.ArsipID)
+	c.JSON(http.StatusOK, gin.H{"peminjaman": &peminjaman, "arsip": &arsip})
+}
+
+// PeminjamanArsipAjukan mencatat pengajuan pinjam oleh user yang login
+func PeminjamanArsipAjukan(c *gin.Context) {
+	var requestBody PeminjamanArsipRequest
+	if err := c.BindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+	if requestBody.ArsipID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "arsip_id harus diisi"})
+		return
+	}
+
+	var arsip models.Arsip
+	if err := initializers.DB.First(&arsip, *requestBody.ArsipID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Arsip not found"})
+		return
+	}
+	if arsip.StatusRetensi == statusRetensiMusnah {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Arsip sudah dimusnahkan"})
+		return
+	}
+
+	batasKembali, err := parseTanggalPinjam(requestBody.BatasKembali)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format batas_kembali: " + err.Error()})
+		return
+	}
+	if batasKembali, err = batasKembaliHariKerja(batasKembali); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.MustGet("userID").(uint)
+	peminjaman := models.PeminjamanArsip{
+		ArsipID:        arsip.ID,
+		Peminjam:       c.MustGet("username").(string),
+		PeminjamUserID: &userID,
+		Divisi:         requestBody.Divisi,
+		Keperluan:      requestBody.Keperluan,
+		BatasKembali:   batasKembali,
+		Status:         statusPinjamDiajukan,
+	}
+	if err := initializers.DB.Create(&peminjaman).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengajukan peminjaman"})
+		return
+	}
+
+	createNotificationRole(fmt.Sprintf("Pengajuan pinjam arsip %s oleh %s", derefString(arsip.NoArsip), peminjaman.Peminjam), time.Now(), "PeminjamanArsip", roleAdmin)
+	c.JSON(http.StatusCreated, gin.H{"peminjaman": &peminjaman})
+}
+
+func PeminjamanArsipSetujui(c *gin.Context) {
+	var requestBody peminjamanProsesRequest
+	c.ShouldBindJSON(&requestBody)
+
+	peminjaman, ok := findPeminjamanArsip(c, statusPinjamDiajukan)
+	if !ok {
+		return
+	}
+	if peminjamanMilikSendiri(c, peminjaman) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Peminjaman tidak dapat disetujui oleh peminjam sendiri"})
+		return
+	}
+
+	if requestBody.BatasKembali != nil && *requestBody.BatasKembali != "" {
+		batasKembali, err := parseTanggalPinjam(requestBody.BatasKembali)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format batas_kembali: " + err.Error()})
+			return
+		}
+		if batasKembali, err = batasKembaliHariKerja(batasKembali); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		peminjaman.BatasKembali = batasKembali
+	}
+	if peminjaman.BatasKembali == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "batas_kembali harus diisi sebelum disetujui"})
+		return
+	}
+
+	username := c.MustGet("username").(string)
+	var bentrok *models.PeminjamanArsip
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		// Baris arsip dikunci agar dua persetujuan bersamaan untuk arsip yang sama tidak sama-sama lolos
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.Arsip{}, peminjaman.ArsipID).Error; err != nil {
+			return err
+		}
+		// Satu arsip fisik hanya bisa dipegang satu peminjam
+		if aktif, found := peminjamanAktif(tx, peminjaman.ArsipID); found && aktif.ID != peminjaman.ID {
+			bentrok = &aktif
+			return nil
+		}
+		res := tx.Model(&peminjaman).Where("status = ?", statusPinjamDiajukan).Updates(map[string]interface{}{
+			"status":         statusPinjamDisetujui,
+			"disetujui_oleh": username,
+			"batas_kembali":  peminjaman.BatasKembali,
+			"catatan":        pilihCatatan(requestBody.Catatan, peminjaman.Catatan),
+		})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errPeminjamanBerubah
+		}
+		return tx.First(&peminjaman, peminjaman.ID).Error
+	})
+	if errors.Is(err, errPeminjamanBerubah) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyetujui peminjaman"})
+		return
+	}
+	if bentrok != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Arsip sedang %s oleh %s", keteranganStatusPinjam(bentrok.Status), bentrok.Peminjam)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"peminjaman": &peminjaman})
+}
+
+func PeminjamanArsipTolak(c *gin.Context) {
+	var requestBody peminjamanProsesRequest
+	c.ShouldBindJSON(&requestBody)
+
+	peminjaman, ok := findPeminjamanArsip(c, statusPinjamDiajukan)
+	if !ok {
+		return
+	}
+
+	username := c.MustGet("username").(string)
+	peminjaman.Status = statusPinjamDitolak
+	peminjaman.DisetujuiOleh = &username
+	peminjaman.Catatan = requestBody.Catatan
+	if err := initializers.DB.Save(&peminjaman).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menolak peminjaman"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"peminjaman": &peminjaman})
+}
+
+// PeminjamanArsipSerahkan mencatat serah terima fisik arsip ke peminjam dan menjadwalkan pengingat
+func PeminjamanArsipSerahkan(c *gin.Context) {
+	var requestBody peminjamanProsesRequest
+	c.ShouldBindJSON(&requestBody)
+
+	peminjaman, ok := findPeminjamanArsip(c, statusPinjamDisetujui)
+	if !ok {
+		return
+	}
+	if peminjamanMilikSendiri(c, peminjaman) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Serah terima tidak dapat dicatat oleh peminjam sendiri"})
+		return
+	}
+
+	now := time.Now()
+	username := c.MustGet("username").(string)
+	peminjaman.Status = statusPinjamDipinjam
+	peminjaman.TanggalPinjam = &now
+	peminjaman.DiserahkanOleh = &username
+	peminjaman.KondisiPinjam = requestBody.Kondisi
+	if err := initializers.DB.Save(&peminjaman).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan serah terima"})
+		return
+	}
+
+	var arsip models.Arsip
+	initializers.DB.First(&arsip, peminjaman.ArsipID)
+	if penerima := penerimaPeminjaman(&peminjaman); penerima != nil {
+		sumber := sumberPeminjamanArsip
+		setNotification(models.Notification{
+			Title:    fmt.Sprintf("Batas pengembalian arsip %s oleh %s", derefString(arsip.NoArsip), peminjaman.Peminjam),
+			Start:    akhirHariKerja(*peminjaman.BatasKembali),
+			Category: "PeminjamanArsip",
+			UserID:   penerima,
+			Sumber:   &sumber,
+			SumberID: &peminjaman.ID,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"peminjaman": &peminjaman})
+}
+
+// PeminjamanArsipKembalikan mencatat pengembalian beserta kondisi arsip saat diterima
+func PeminjamanArsipKembalikan(c *gin.Context) {
+	var requestBody peminjamanProsesRequest
+	c.ShouldBindJSON(&requestBody)
+
+	peminjaman, ok := findPeminjamanArsip(c, statusPinjamDipinjam)
+	if !ok {
+		return
+	}
+	if peminjamanMilikSendiri(c, peminjaman) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Pengembalian tidak dapat diterima oleh peminjam sendiri"})
+		return
+	}
+	if requestBody.Kondisi == nil || *requestBody.Kondisi == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Kondisi arsip saat dikembalikan harus diisi"})
+		return
+	}
+
+	now := time.Now()
+	username := c.MustGet("username").(string)
+	peminjaman.Status = statusPinjamDikembalikan
+	peminjaman.TanggalKembali = &now
+	peminjaman.DiterimaOleh = &username
+	peminjaman.KondisiKembali = requestBody.Kondisi
+	if requestBody.Catatan != nil {
+		peminjaman.Catatan = requestBody.Catatan
+	}
+	if err := initializers.DB.Save(&peminjaman).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan pengembalian"})
+		return
+	}
+	if err := batalkanPengingatEvent(initializers.DB, sumberPeminjamanArsip, peminjaman.ID); err != nil {
+		log.Printf("Error cancelling reminders for peminjaman %d: %v", peminjaman.ID, err)
+	}
+	c.JSON(http.StatusOK, gin.H{"peminjaman": &peminjaman})
+}
+
+func PeminjamanArsipDelete(c *gin.Context) {
+	var peminjaman models.PeminjamanArsip
+	if err := initializers.DB.First(&peminjaman, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Peminjaman tidak ditemukan"})
+		return
+	}
+	if peminjaman.Status != statusPinjamDiajukan && peminjaman.Status != statusPinjamDitolak {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Peminjaman yang sudah diproses tidak bisa dihapus"})
+		return
+	}
+
+	if err := initializers.DB.Delete(&peminjaman).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghapus peminjaman"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Peminjaman deleted successfully"})
+}
+
+// peminjamanMilikSendiri mencegah peminjam memutuskan pengajuannya sendiri
+func peminjamanMilikSendiri(c *gin.Context, p models.PeminjamanArsip) bool {
+	if p.PeminjamUserID != nil && *p.PeminjamUserID == c.MustGet("userID").(uint) {
+		return true
+	}
+	return p.Peminjam == c.MustGet("username").(string)
+}
+
+// penerimaPeminjaman mengembalikan user peminjam; peminjaman lama hanya menyimpan username
+func penerimaPeminjaman(p *models.PeminjamanArsip) *uint {
+	if p.PeminjamUserID != nil {
+		return p.PeminjamUserID
+	}
+	return userIDUsername(p.Peminjam)
+}
+
+// ArsipPeminjam menjawab "siapa yang sedang memegang arsip X"
+func ArsipPeminjam(c *gin.Context) {
+	var arsip models.Arsip
+	if err := initializers.DB.First(&arsip, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Arsip not found"})
+		return
+	}
+
+	aktif, found := peminjamanAktif(initializers.DB, arsip.ID)
+	if !found || aktif.Status != statusPinjamDipinjam {
+		c.JSON(http.StatusOK, gin.H{"arsip": &arsip, "dipinjam": false, "peminjaman": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"arsip": &arsip, "dipinjam": true, "peminjaman": &aktif})
+}
+
+// ArsipRiwayatPeminjaman menampilkan semua peminjaman untuk satu arsip
+func ArsipRiwayatPeminjaman(c *gin.Context) {
+	var arsip models.Arsip
+	if err := initializers.DB.First(&arsip, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Arsip not found"})
+		return
+	}
+
+	var peminjaman []models.PeminjamanArsip
+	initializers.DB.Where("arsip_id = ?", arsip.ID).Order("id desc").Find(&peminjaman)
+	c.JSON(http.StatusOK, gin.H{"arsip": &arsip, "peminjaman": peminjaman})
+}
+
+func PeminjamanArsipOverdueReport(c *gin.Context) {
+	overdue, err := findPeminjamanOverdue(time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"overdue": overdue, "total": len(overdue)})
+}
+
+// CheckPeminjamanArsipOverdue dijalankan berkala dan mengingatkan arsip yang belum kembali lewat tabel Notification
+func CheckPeminjamanArsipOverdue() {
+	overdue, err := findPeminjamanOverdue(time.Now())
+	if err != nil {
+		log.Printf("Error checking peminjaman arsip overdue: %v", err)
+		return
+	}
+
+	for _, item := range overdue {
+		title := fmt.Sprintf("Arsip %s dipinjam %s belum kembali, batas %s", derefString(item.Arsip.NoArsip), item.Peminjaman.Peminjam, item.Peminjaman.BatasKembali.Format("2006-01-02"))
+
+		penerima := penerimaPeminjaman(item.Peminjaman)
+		if penerima == nil {
+			continue
+		}
+		createNotificationUnik(title, time.Now(), "PeminjamanArsipOverdue", penerima, fmt.Sprintf("peminjaman-arsip-%d", item.Peminjaman.ID))
+	}
+}
+
+func findPeminjamanOverdue(now time.Time) ([]peminjamanOverdue, error) {
+	var dipinjam []models.PeminjamanArsip
+	if err := initializers.DB.Where("status = ? AND batas_kembali IS NOT NULL", statusPinjamDipinjam).Order("batas_kembali asc").Find(&dipinjam).Error; err != nil {
+		return nil, err
+	}
+
+	kalender, err := muatKalenderKerja(initializers.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	today := truncateToDate(now)
+	overdue := []peminjamanOverdue{}
+	for i := range dipinjam {
+		p := &dipinjam[i]
+		// Batas lama yang jatuh pada hari libur baru terlambat setelah hari kerja berikutnya
+		if !isLewatBatas(kalender.hariKerjaBerikutnya(*p.BatasKembali), now) {
+			continue
+		}
+		var arsip models.Arsip
+		initializers.DB.First(&arsip, p.ArsipID)
+		overdue = append(overdue, peminjamanOverdue{
+			Peminjaman:    p,
+			Arsip:         arsip,
+			HariTerlambat: int(today.Sub(truncateToDate(*p.BatasKembali)).Hours() / 24),
+		})
+	}
+	return overdue, nil
+}
+
+// findPeminjamanArsip mengambil peminjaman dari param id dan memastikan statusnya sesuai tahap
+func findPeminjamanArsip(c *gin.Context, status string) (models.PeminjamanArsip, bool) {
+	var peminjaman models.PeminjamanArsip
+	if err := initializers.DB.First(&peminjaman, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Peminjaman tidak ditemukan"})
+		return peminjaman, false
+	}
+	if peminjaman.Status != status {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Peminjaman berstatus %s, seharusnya %s", peminjaman.Status, status)})
+		return peminjaman, false
+	}
+	return peminjaman, true
+}
+
+// peminjamanAktif mencari peminjaman yang sudah disetujui atau sedang berjalan untuk arsip
+func peminjamanAktif(db *gorm.DB, arsipID uint) (models.PeminjamanArsip, bool) {
+	var peminjaman models.PeminjamanArsip
+	err := db.Where("arsip_id = ? AND status IN ?", arsipID, []string{statusPinjamDisetujui, statusPinjamDipinjam}).
+		Order("id desc").First(&peminjaman).Error
+	return peminjaman, err == nil
+}
+
+var errPeminjamanBerubah = errors.New("Peminjaman sudah diproses oleh pengguna lain")
+
+// pilihCatatan memakai catatan baru bila dikirim, selain itu catatan yang sudah ada
+func pilihCatatan(baru, lama *string) *string {
+	if baru != nil {
+		return baru
+	}
+	return lama
+}
+
+// akhirHariKerja mengembalikan jam akhir kerja (WIB) pada tanggal t, dipakai sebagai waktu batas kembali
+func akhirHariKerja(t time.Time) time.Time {
+	loc := jakartaLocation()
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), jamAkhirHariKerja, 0, 0, 0, loc)
+}
+
+func keteranganStatusPinjam(status string) string {
+	if status == statusPinjamDisetujui {
+		return "disiapkan untuk dipinjam"
+	}
+	return "dipinjam"
+}
+
+// batasKembaliHariKerja menggeser batas kembali yang jatuh pada akhir pekan atau hari libur ke hari kerja berikutnya
+func batasKembaliHariKerja(batas *time.Time) (*time.Time, error) {
+	if batas == nil {
+		return nil, nil
+	}
+	kalender, err := muatKalenderKerja(initializers.DB)
+	if err != nil {
+		return nil, err
+	}
+	t := kalender.hariKerjaBerikutnya(*batas)
+	return &t, nil
+}
+
+func parseTanggalPinjam(value *string) (*time.Time, error) {
+	if value == nil || *value == "" {
+		return nil, nil
+	}
+	parsed, err := time.ParseInLocation("2006-01-02", *value, jakartaLocation())
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
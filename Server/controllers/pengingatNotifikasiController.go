@@ -0,0 +1,290 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"project-its/initializers"
+	"project-its/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	statusPengingatMenunggu   = "Menunggu"
+	statusPengingatDiproses   = "Diproses"
+	statusPengingatTerkirim   = "Terkirim"
+	statusPengingatGagal      = "Gagal"
+	statusPengingatDibatalkan = "Dibatalkan"
+
+	maksPercobaanPengingat = 5
+	batchPengingat         = 50
+	// lama kunci (lease) satu worker atas pengingat yang sedang dikirim; lewat dari ini dianggap worker mati
+	leasePengingat = 5 * time.Minute
+)
+
+// offset pengingat sebelum waktu mulai event
+var offsetPengingat = []time.Duration{24 * time.Hour, time.Hour}
+
+// sumber notifikasi in-app yang dibuat worker pengingat, agar tidak dianggap event yang perlu pengingat lagi
+const sumberPengingatNotifikasi = "PengingatNotifikasi"
+
+// kanalPengingat adalah daftar kanal pengiriman pengingat; error pada salah satu kanal membuat pengingat dicoba ulang.
+// Kanal yang sudah berhasil dicatat di KanalTerkirim sehingga tidak dikirim dua kali.
+var kanalPengingat = []struct {
+	Nama  string
+	Kirim func(p models.PengingatNotifikasi) error
+}{
+	{"inapp", kirimPengingatInApp},
+	{"email", kirimPengingatEmail},
+	{"webhook", kirimPengingatWebhook},
+}
+
+// identitas instance server, dipakai untuk menandai pemilik kunci pengingat
+var workerPengingatID = func() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "server"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}()
+
+// jadwalkanPengingat menyimpan pengingat untuk notification; offset yang waktunya sudah lewat dilewati
+func jadwalkanPengingat(notification models.Notification) {
+	now := time.Now()
+	for _, offset := range offsetPengingat {
+		jadwal := notification.Start.Add(-offset)
+		if !jadwal.After(now) {
+			continue
+		}
+		pengingat := models.PengingatNotifikasi{
+			NotificationID: notification.ID,
+			Title:          notification.Title,
+			Category:       notification.Category,
+			UserID:         notification.UserID,
+			Role:           notification.Role,
+			WaktuEvent:     notification.Start,
+			OffsetMenit:    int(offset / time.Minute),
+			JadwalKirim:    jadwal,
+			Status:         statusPengingatMenunggu,
+			MaksPercobaan:  maksPercobaanPengingat,
+		}
+		if err := initializers.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&pengingat).Error; err != nil {
+			log.Printf("Error scheduling reminder for %s: %v", notification.Title, err)
+			continue
+		}
+		log.Printf("Reminder %s scheduled for %s", labelOffsetPengingat(pengingat.OffsetMenit), jadwal)
+	}
+}
+
+// batalkanPengingatEvent membatalkan pengingat yang belum terkirim untuk event asal (lihat SetNotificationEvent).
+// Dipanggil saat event dihapus atau waktunya diubah; pengingat baru dijadwalkan ulang oleh pemanggil bila perlu.
+func batalkanPengingatEvent(tx *gorm.DB, sumber string, sumberID interface{}) error {
+	notificationIDs := tx.Model(&models.Notification{}).Select("id").Where("sumber = ? AND sumber_id = ?", sumber, sumberID)
+	return tx.Model(&models.PengingatNotifikasi{}).
+		Where("notification_id IN (?) AND status IN ?", notificationIDs, []string{statusPengingatMenunggu, statusPengingatDiproses}).
+		Updates(map[string]interface{}{"status": statusPengingatDibatalkan, "dikunci_oleh": nil, "dikunci_sampai": nil}).Error
+}
+
+// ProsesPengingatJatuhTempo mengirim pengingat yang sudah jatuh tempo. Aman dijalankan di beberapa instance:
+// baris diklaim dengan FOR UPDATE SKIP LOCKED lalu dikunci dengan lease, pengingat dengan lease kedaluwarsa diklaim ulang.
+func ProsesPengingatJatuhTempo() {
+	for {
+		pengingat, err := klaimPengingatJatuhTempo()
+		if err != nil {
+			log.Printf("Error claiming reminders: %v", err)
+			return
+		}
+		for _, p := range pengingat {
+			kirimPengingat(p)
+		}
+		if len(pengingat) < batchPengingat {
+			return
+		}
+	}
+}
+
+func klaimPengingatJatuhTempo() ([]models.PengingatNotifikasi, error) {
+	var pengingat []models.PengingatNotifikasi
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("(status = ? AND jadwal_kirim <= ?) OR (status = ? AND dikunci_sampai < ?)",
+				statusPengingatMenunggu, now, statusPengingatDiproses, now).
+			Order("jadwal_kirim").Limit(batchPengingat).
+			Find(&pengingat).Error; err != nil {
+			return err
+		}
+		if len(pengingat) == 0 {
+			return nil
+		}
+		ids := make([]uint, 0, len(pengingat))
+		for _, p := range pengingat {
+			ids = append(ids, p.ID)
+		}
+		lease := now.Add(leasePengingat)
+		return tx.Model(&models.PengingatNotifikasi{}).Where("id IN ?", ids).
+			Updates(map[string]interface{}{
+				"status":         statusPengingatDiproses,
+				"dikunci_oleh":   workerPengingatID,
+				"dikunci_sampai": lease,
+			}).Error
+	})
+	return pengingat, err
+}
+
+// kirimPengingat mengirim lewat semua kanal lalu mencatat hasilnya; hanya baris yang masih dikunci worker ini yang diperbarui
+func kirimPengingat(p models.PengingatNotifikasi) {
+	var errKirim error
+	terkirim := p.KanalTerkirim
+	for _, kanal := range kanalPengingat {
+		if kanalSudahTerkirim(terkirim, kanal.Nama) {
+			continue
+		}
+		if err := kanal.Kirim(p); err != nil {
+			errKirim = err
+			break
+		}
+		if terkirim != "" {
+			terkirim += ","
+		}
+		terkirim += kanal.Nama
+		if err := initializers.DB.Model(&models.PengingatNotifikasi{}).
+			Where("id = ? AND dikunci_oleh = ?", p.ID, workerPengingatID).
+			Update("kanal_terkirim", terkirim).Error; err != nil {
+			errKirim = err
+			break
+		}
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"percobaan":      p.Percobaan + 1,
+		"dikunci_oleh":   nil,
+		"dikunci_sampai": nil,
+	}
+	if errKirim == nil {
+		updates["status"] = statusPengingatTerkirim
+		updates["terkirim_pada"] = now
+		updates["error_terakhir"] = nil
+	} else {
+		log.Printf("Error sending reminder %d (%s): %v", p.ID, p.Title, errKirim)
+		updates["error_terakhir"] = errKirim.Error()
+		if p.Percobaan+1 >= p.MaksPercobaan {
+			updates["status"] = statusPengingatGagal
+		} else {
+			updates["status"] = statusPengingatMenunggu
+			updates["jadwal_kirim"] = now.Add(backoffAntrean(p.Percobaan + 1))
+		}
+	}
+	res := initializers.DB.Model(&models.PengingatNotifikasi{}).
+		Where("id = ? AND status = ? AND dikunci_oleh = ?", p.ID, statusPengingatDiproses, workerPengingatID).
+		Updates(updates)
+	if res.Error != nil {
+		log.Printf("Error updating reminder %d: %v", p.ID, res.Error)
+	}
+}
+
+func kanalSudahTerkirim(terkirim, nama string) bool {
+	for _, k := range strings.Split(terkirim, ",") {
+		if k == nama {
+			return true
+		}
+	}
+	return false
+}
+
+// backoffAntrean: 1, 2, 4, 8 ... menit, maksimal 1 jam
+func backoffAntrean(percobaan int) time.Duration {
+	d := time.Minute << uint(percobaan-1)
+	if d > time.Hour || d <= 0 {
+		d = time.Hour
+	}
+	return d
+}
+
+func labelOffsetPengingat(offsetMenit int) string {
+	if offsetMenit%60 == 0 {
+		return fmt.Sprintf("%d jam", offsetMenit/60)
+	}
+	return fmt.Sprintf("%d menit", offsetMenit)
+}
+
+// kirimPengingatInApp mencatat pengingat sebagai notifikasi yang langsung tampil di aplikasi
+func kirimPengingatInApp(p models.PengingatNotifikasi) error {
+	sumber := sumberPengingatNotifikasi
+	notification := models.Notification{
+		Title:    fmt.Sprintf("Pengingat %s lagi: %s", labelOffsetPengingat(p.OffsetMenit), p.Title),
+		Start:    p.WaktuEvent,
+		Category: p.Category,
+		UserID:   p.UserID,
+		Role:     p.Role,
+		Sumber:   &sumber,
+		SumberID: &p.ID,
+	}
+	if err := initializers.DB.Create(&notification).Error; err != nil {
+		return err
+	}
+	log.Printf("%s notification sent for event %s", labelOffsetPengingat(p.OffsetMenit), p.Title)
+	return nil
+}
+
+// GetPengingatNotifikasi menampilkan antrean pengingat, bisa disaring dengan ?status= dan ?notification_id=
+func GetPengingatNotifikasi(c *gin.Context) {
+	query := initializers.DB.Model(&models.PengingatNotifikasi{})
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if notificationID := c.Query("notification_id"); notificationID != "" {
+		query = query.Where("notification_id = ?", notificationID)
+	}
+
+	var pengingat []models.PengingatNotifikasi
+	if err := query.Order("jadwal_kirim desc").Limit(500).Find(&pengingat).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, pengingat)
+}
+
+// UlangiPengingatNotifikasi menjadwalkan ulang pengingat yang gagal agar segera dikirim lagi
+func UlangiPengingatNotifikasi(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID tidak valid"})
+		return
+	}
+
+	var pengingat models.PengingatNotifikasi
+	if err := initializers.DB.First(&pengingat, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Pengingat tidak ditemukan"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if pengingat.Status != statusPengingatGagal {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Hanya pengingat berstatus Gagal yang dapat dikirim ulang"})
+		return
+	}
+
+	if err := initializers.DB.Model(&pengingat).Updates(map[string]interface{}{
+		"status":         statusPengingatMenunggu,
+		"percobaan":      0,
+		"jadwal_kirim":   time.Now(),
+		"error_terakhir": nil,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	initializers.DB.First(&pengingat, id)
+	c.JSON(http.StatusOK, pengingat)
+}
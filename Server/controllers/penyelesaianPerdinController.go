@@ -0,0 +1,640 @@
+package controllers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"project-its/initializers"
+	"project-its/middleware"
+	"project-its/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Status penyelesaian perdin; Draft dan Ditolak masih bisa diubah, mengubah yang Ditolak mengembalikannya ke Draft
+const (
+	statusPenyelesaianDraft     = "Draft"
+	statusPenyelesaianDiajukan  = "Diajukan"
+	statusPenyelesaianDisetujui = "Disetujui"
+	statusPenyelesaianDitolak   = "Ditolak"
+)
+
+type realisasiPerdinRequest struct {
+	Kategori    *string        `json:"kategori"`
+	Keterangan  *string        `json:"keterangan"`
+	Tanggal     *string        `json:"tanggal"`
+	Jumlah      *int           `json:"jumlah"`
+	HargaSatuan *models.Amount `json:"harga_satuan"`
+	FileID      *uint          `json:"file_id"`   // id dari respons uploadFilePerdin
+	NamaFile    *string        `json:"nama_file"` // atau nama file pada filesPerdin/:id
+}
+
+type PenyelesaianPerdinRequest struct {
+	UangMuka  *models.Amount           `json:"uang_muka"` // default total estimasi, hanya admin yang boleh mengubah
+	Catatan   *string                  `json:"catatan"`
+	Realisasi []realisasiPerdinRequest `json:"realisasi"` // nil berarti baris tidak diubah
+}
+
+type KeputusanPenyelesaianPerdinRequest struct {
+	Catatan *string `json:"catatan"`
+}
+
+type perbandinganBiayaPerdin struct {
+	Kategori  string        `json:"kategori"`
+	Estimasi  models.Amount `json:"estimasi"`
+	Realisasi models.Amount `json:"realisasi"`
+	Selisih   models.Amount `json:"selisih"`
+}
+
+// PenyelesaianPerdinShow menampilkan penyelesaian beserta baris realisasi dan perbandingan per kategori
+func PenyelesaianPerdinShow(c *gin.Context) {
+	var perdin models.Perdin
+	if err := initializers.DB.First(&perdin, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Perdin tidak ditemukan"})
+		return
+	}
+	var penyelesaian models.PenyelesaianPerdin
+	if err := initializers.DB.Where("perdin_id = ?", perdin.ID).First(&penyelesaian).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Penyelesaian perdin belum dibuat"})
+		return
+	}
+	respondPenyelesaianPerdin(c, perdin, penyelesaian)
+}
+
+// PenyelesaianPerdinSimpan membuat atau mengubah draft penyelesaian perdin
+func PenyelesaianPerdinSimpan(c *gin.Context) {
+	var req PenyelesaianPerdinRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var perdin models.Perdin
+	var penyelesaian models.PenyelesaianPerdin
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&perdin, c.Param("id")).Error; err != nil {
+			return errPenyelesaianPerdin{"Perdin tidak ditemukan"}
+		}
+		if _, err := pengajuPenyelesaianPerdin(tx, c, perdin.ID); err != nil {
+			return err
+		}
+
+		err := tx.Where("perdin_id = ?", perdin.ID).First(&penyelesaian).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			penyelesaian = models.PenyelesaianPerdin{
+				PerdinID: perdin.ID,
+				UangMuka: perdin.TotalEstimasi,
+				Status:   statusPenyelesaianDraft,
+				CreateBy: c.MustGet("username").(string),
+			}
+			if err := tx.Create(&penyelesaian).Error; err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		case penyelesaian.Status != statusPenyelesaianDraft && penyelesaian.Status != statusPenyelesaianDitolak:
+			return errPenyelesaianPerdin{fmt.Sprintf("Penyelesaian berstatus %s tidak dapat diubah", penyelesaian.Status)}
+		}
+
+		penyelesaian.Status = statusPenyelesaianDraft
+		if req.UangMuka != nil && *req.UangMuka != penyelesaian.UangMuka {
+			if !middleware.HasRole(c, roleAdmin) {
+				return errPenyelesaianPerdin{"Uang muka hanya dapat diubah oleh admin"}
+			}
+			if *req.UangMuka < 0 {
+				return errPenyelesaianPerdin{"Uang muka tidak boleh negatif"}
+			}
+			penyelesaian.UangMuka = *req.UangMuka
+		}
+		if req.Catatan != nil {
+			penyelesaian.Catatan = req.Catatan
+		}
+
+		if req.Realisasi != nil {
+			if err := tx.Where("penyelesaian_id = ?", penyelesaian.ID).Delete(&models.RealisasiPerdin{}).Error; err != nil {
+				return err
+			}
+			for i, r := range req.Realisasi {
+				baris, err := barisRealisasiPerdin(tx, perdin, penyelesaian.ID, i+1, r)
+				if err != nil {
+					return err
+				}
+				if err := tx.Create(&baris).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := hitungPenyelesaianPerdin(tx, perdin, &penyelesaian); err != nil {
+			return err
+		}
+		return tx.Save(&penyelesaian).Error
+	})
+	if err != nil {
+		respondPenyelesaianPerdinError(c, err)
+		return
+	}
+	respondPenyelesaianPerdin(c, perdin, penyelesaian)
+}
+
+// PenyelesaianPerdinAjukan mengirim draft untuk disetujui
+func PenyelesaianPerdinAjukan(c *gin.Context) {
+	var perdin models.Perdin
+	var penyelesaian models.PenyelesaianPerdin
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := findPenyelesaianPerdin(tx, c, &perdin, &penyelesaian); err != nil {
+			return err
+		}
+		if penyelesaian.Status != statusPenyelesaianDraft {
+			return errPenyelesaianPerdin{fmt.Sprintf("Penyelesaian berstatus %s tidak dapat diajukan", penyelesaian.Status)}
+		}
+
+		var jumlah int64
+		tx.Model(&models.RealisasiPerdin{}).Where("penyelesaian_id = ?", penyelesaian.ID).Count(&jumlah)
+		if jumlah == 0 {
+			return errPenyelesaianPerdin{"Belum ada baris realisasi biaya"}
+		}
+
+		// Estimasi bisa berubah sejak draft dibuat, hitung ulang sebelum dikunci
+		if err := hitungPenyelesaianPerdin(tx, perdin, &penyelesaian); err != nil {
+			return err
+		}
+		// Atasan peserta disalin saat diajukan seperti pengajuan cuti, agar pemutus tidak bergantung pada data user sesudahnya
+		pengajuID, err := pengajuPenyelesaianPerdin(tx, c, perdin.ID)
+		if err != nil {
+			return err
+		}
+		var pengaju models.User
+		if err := tx.First(&pengaju, pengajuID).Error; err != nil {
+			return errPenyelesaianPerdin{"Data pengaju tidak ditemukan"}
+		}
+
+		now := time.Now()
+		oleh := c.MustGet("username").(string)
+		penyelesaian.Status = statusPenyelesaianDiajukan
+		penyelesaian.DiajukanOleh = &oleh
+		penyelesaian.PengajuID = &pengaju.ID
+		penyelesaian.AtasanID = pengaju.AtasanID
+		penyelesaian.TanggalPengajuan = &now
+		penyelesaian.DiputuskanOleh = nil
+		penyelesaian.TanggalKeputusan = nil
+		penyelesaian.CatatanKeputusan = nil
+		return tx.Save(&penyelesaian).Error
+	})
+	if err != nil {
+		respondPenyelesaianPerdinError(c, err)
+		return
+	}
+
+	// Diarahkan ke atasan pengaju bila ada, selain itu ke admin
+	judul := fmt.Sprintf("Penyelesaian perdin %s menunggu persetujuan", derefString(perdin.NoPerdin))
+	if penyelesaian.AtasanID != nil {
+		createNotificationUntuk(judul, time.Now(), "PenyelesaianPerdin", penyelesaian.AtasanID)
+	} else {
+		createNotificationRole(judul, time.Now(), "PenyelesaianPerdin", roleAdmin)
+	}
+	respondPenyelesaianPerdin(c, perdin, penyelesaian)
+}
+
+func PenyelesaianPerdinSetujui(c *gin.Context) {
+	putuskanPenyelesaianPerdin(c, statusPenyelesaianDisetujui)
+}
+
+func PenyelesaianPerdinTolak(c *gin.Context) {
+	putuskanPenyelesaianPerdin(c, statusPenyelesaianDitolak)
+}
+
+// ExportPenyelesaianPerdin menghasilkan lembar rincian biaya SPPD (pertanggungjawaban) dalam Excel
+func ExportPenyelesaianPerdin(c *gin.Context) {
+	var perdin models.Perdin
+	if err := initializers.DB.First(&perdin, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Perdin tidak ditemukan"})
+		return
+	}
+	var penyelesaian models.PenyelesaianPerdin
+	if err := initializers.DB.Where("perdin_id = ?", perdin.ID).First(&penyelesaian).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Penyelesaian perdin belum dibuat"})
+		return
+	}
+
+	peserta, biaya := muatRincianPerdin(perdin.ID)
+	var realisasi []models.RealisasiPerdin
+	initializers.DB.Where("penyelesaian_id = ?", penyelesaian.ID).Order("tanggal asc, id asc").Find(&realisasi)
+
+	f := excelize.NewFile()
+	sheet := "SPPD"
+	f.SetSheetName("Sheet1", sheet)
+	if err := tulisLembarPenyelesaianPerdin(f, sheet, perdin, penyelesaian, peserta, biaya, realisasi); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var buffer bytes.Buffer
+	if err := f.Write(&buffer); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	nama := strings.NewReplacer("/", "-", "\\", "-", " ", "_").Replace(derefString(perdin.NoPerdin))
+	if nama == "" {
+		nama = strconv.Itoa(int(perdin.ID))
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=Penyelesaian_SPPD_%s.xlsx", nama))
+	c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Header("Content-Length", strconv.Itoa(len(buffer.Bytes())))
+	c.Writer.Write(buffer.Bytes())
+}
+
+// errPenyelesaianPerdin dipakai untuk kesalahan validasi yang dikembalikan sebagai 400
+type errPenyelesaianPerdin struct{ pesan string }
+
+func (e errPenyelesaianPerdin) Error() string { return e.pesan }
+
+var errBukanPesertaPerdin = errors.New("Penyelesaian hanya dapat diisi dan diajukan oleh peserta perdin atau admin")
+
+func respondPenyelesaianPerdinError(c *gin.Context, err error) {
+	if errors.Is(err, errBukanPesertaPerdin) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	var errValidasi errPenyelesaianPerdin
+	if errors.As(err, &errValidasi) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errValidasi.pesan})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+func respondPenyelesaianPerdin(c *gin.Context, perdin models.Perdin, penyelesaian models.PenyelesaianPerdin) {
+	_, biaya := muatRincianPerdin(perdin.ID)
+	realisasi := []models.RealisasiPerdin{}
+	initializers.DB.Where("penyelesaian_id = ?", penyelesaian.ID).Order("tanggal asc, id asc").Find(&realisasi)
+
+	c.JSON(http.StatusOK, gin.H{
+		"perdin":       &perdin,
+		"penyelesaian": &penyelesaian,
+		"realisasi":    realisasi,
+		"perbandingan": bandingkanBiayaPerdin(biaya, realisasi),
+	})
+}
+
+// cekPerdinTerkunci menolak perubahan estimasi perdin yang penyelesaiannya sudah diajukan atau disetujui
+func cekPerdinTerkunci(tx *gorm.DB, perdinID uint) error {
+	var penyelesaian models.PenyelesaianPerdin
+	err := tx.Where("perdin_id = ?", perdinID).First(&penyelesaian).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if penyelesaian.Status == statusPenyelesaianDiajukan || penyelesaian.Status == statusPenyelesaianDisetujui {
+		return errPenyelesaianPerdin{fmt.Sprintf("Penyelesaian perdin berstatus %s, estimasi tidak dapat diubah", penyelesaian.Status)}
+	}
+	return nil
+}
+
+func findPenyelesaianPerdin(tx *gorm.DB, c *gin.Context, perdin *models.Perdin, penyelesaian *models.PenyelesaianPerdin) error {
+	if err := tx.First(perdin, c.Param("id")).Error; err != nil {
+		return errPenyelesaianPerdin{"Perdin tidak ditemukan"}
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("perdin_id = ?", perdin.ID).First(penyelesaian).Error; err != nil {
+		return errPenyelesaianPerdin{"Penyelesaian perdin belum dibuat"}
+	}
+	return nil
+}
+
+// pengajuPenyelesaianPerdin memastikan pemanggil peserta perdin atau admin, lalu mengembalikan peserta yang
+// menjadi pengaju: pemanggil sendiri, atau peserta pertama bila admin mengajukan atas nama peserta
+func pengajuPenyelesaianPerdin(tx *gorm.DB, c *gin.Context, perdinID uint) (uint, error) {
+	var peserta []models.PerdinPeserta
+	if err := tx.Where("perdin_id = ?", perdinID).Order("id asc").Find(&peserta).Error; err != nil {
+		return 0, err
+	}
+	pengajuID, ok := pilihPengajuPerdin(peserta, c.MustGet("userID").(uint), middleware.HasRole(c, roleAdmin))
+	if !ok {
+		return 0, errBukanPesertaPerdin
+	}
+	return pengajuID, nil
+}
+
+func pilihPengajuPerdin(peserta []models.PerdinPeserta, userID uint, admin bool) (uint, bool) {
+	for _, p := range peserta {
+		if p.UserID == userID {
+			return userID, true
+		}
+	}
+	if admin && len(peserta) > 0 {
+		return peserta[0].UserID, true
+	}
+	return 0, false
+}
+
+// putuskanPenyelesaianPerdin memakai aturan pemutus yang sama dengan cuti (cekPemutusPengajuan)
+func putuskanPenyelesaianPerdin(c *gin.Context, status string) {
+	var req KeputusanPenyelesaianPerdinRequest
+	_ = c.ShouldBindJSON(&req) // catatan opsional
+
+	var perdin models.Perdin
+	var penyelesaian models.PenyelesaianPerdin
+	var penerima *uint
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := findPenyelesaianPerdin(tx, c, &perdin, &penyelesaian); err != nil {
+			return err
+		}
+		if penyelesaian.Status != statusPenyelesaianDiajukan {
+			return errPenyelesaianPerdin{fmt.Sprintf("Penyelesaian berstatus %s, bukan %s", penyelesaian.Status, statusPenyelesaianDiajukan)}
+		}
+
+		if penyelesaian.PengajuID == nil {
+			return errPenyelesaianPerdin{"Data pengaju tidak ditemukan"}
+		}
+		if pesan := cekPemutusPengajuan(c, *penyelesaian.PengajuID, penyelesaian.AtasanID); pesan != "" {
+			return errPenyelesaianPerdin{pesan}
+		}
+		username := c.MustGet("username").(string)
+		penerima = penyelesaian.PengajuID
+
+		now := time.Now()
+		penyelesaian.Status = status
+		penyelesaian.DiputuskanOleh = &username
+		penyelesaian.TanggalKeputusan = &now
+		penyelesaian.CatatanKeputusan = req.Catatan
+		return tx.Save(&penyelesaian).Error
+	})
+	if err != nil {
+		respondPenyelesaianPerdinError(c, err)
+		return
+	}
+
+	createNotificationUntuk(fmt.Sprintf("Penyelesaian perdin %s %s", derefString(perdin.NoPerdin), strings.ToLower(status)), time.Now(), "PenyelesaianPerdin", penerima)
+	respondPenyelesaianPerdin(c, perdin, penyelesaian)
+}
+
+// barisRealisasiPerdin memvalidasi satu baris biaya aktual beserta buktinya
+func barisRealisasiPerdin(tx *gorm.DB, perdin models.Perdin, penyelesaianID uint, no int, r realisasiPerdinRequest) (models.RealisasiPerdin, error) {
+	kategori, ok := normalisasiKategoriBiaya(derefString(r.Kategori))
+	if !ok {
+		return models.RealisasiPerdin{}, errPenyelesaianPerdin{fmt.Sprintf("Realisasi ke-%d: kategori %q tidak dikenal", no, derefString(r.Kategori))}
+	}
+	jumlah := 1
+	if r.Jumlah != nil {
+		jumlah = *r.Jumlah
+	}
+	if jumlah < 1 {
+		return models.RealisasiPerdin{}, errPenyelesaianPerdin{fmt.Sprintf("Realisasi ke-%d: jumlah minimal 1", no)}
+	}
+	if r.HargaSatuan == nil || *r.HargaSatuan < 0 {
+		return models.RealisasiPerdin{}, errPenyelesaianPerdin{fmt.Sprintf("Realisasi ke-%d: harga satuan wajib diisi dan tidak boleh negatif", no)}
+	}
+
+	baris := models.RealisasiPerdin{
+		PenyelesaianID: penyelesaianID,
+		PerdinID:       perdin.ID,
+		Kategori:       kategori,
+		Keterangan:     r.Keterangan,
+		Jumlah:         jumlah,
+		HargaSatuan:    *r.HargaSatuan,
+		Total:          *r.HargaSatuan * models.Amount(jumlah),
+	}
+	if r.Tanggal != nil && *r.Tanggal != "" {
+		t, err := time.Parse("2006-01-02", *r.Tanggal)
+		if err != nil {
+			return baris, errPenyelesaianPerdin{fmt.Sprintf("Realisasi ke-%d: format tanggal harus YYYY-MM-DD", no)}
+		}
+		baris.Tanggal = &t
+	}
+
+	// Uang harian dibayar lumpsum sehingga tidak wajib bukti, kategori lain harus ada kuitansinya
+	file, err := buktiPerdin(tx, perdin.ID, r.FileID, r.NamaFile)
+	if err != nil {
+		return baris, err
+	}
+	if file == nil && kategori != kategoriBiayaUangHarian {
+		return baris, errPenyelesaianPerdin{fmt.Sprintf("Realisasi ke-%d: bukti pembayaran wajib dilampirkan", no)}
+	}
+	if file != nil {
+		baris.FileID = &file.ID
+		baris.NamaFile = file.FileName
+	}
+	return baris, nil
+}
+
+// buktiPerdin mencari file yang diunggah untuk perdin ini lewat uploadFilePerdin
+func buktiPerdin(tx *gorm.DB, perdinID uint, fileID *uint, namaFile *string) (*models.File, error) {
+	dir := filepath.Join(baseDirFilePerdin, strconv.Itoa(int(perdinID)))
+
+	var file models.File
+	switch {
+	case fileID != nil && *fileID != 0:
+		if err := tx.First(&file, *fileID).Error; err != nil || filepath.Dir(file.FilePath) != dir {
+			return nil, errPenyelesaianPerdin{fmt.Sprintf("File bukti %d tidak ditemukan pada perdin ini", *fileID)}
+		}
+	case namaFile != nil && *namaFile != "":
+		if err := tx.Where("file_path = ?", filepath.Join(dir, *namaFile)).First(&file).Error; err != nil {
+			return nil, errPenyelesaianPerdin{fmt.Sprintf("File bukti %s tidak ditemukan pada perdin ini", *namaFile)}
+		}
+	default:
+		return nil, nil
+	}
+	return &file, nil
+}
+
+// hitungPenyelesaianPerdin mengisi total realisasi dan selisih terhadap estimasi serta uang muka
+func hitungPenyelesaianPerdin(tx *gorm.DB, perdin models.Perdin, penyelesaian *models.PenyelesaianPerdin) error {
+	var realisasi []models.RealisasiPerdin
+	if err := tx.Where("penyelesaian_id = ?", penyelesaian.ID).Find(&realisasi).Error; err != nil {
+		return err
+	}
+	var total models.Amount
+	for _, r := range realisasi {
+		total += r.Total
+	}
+	penyelesaian.TotalEstimasi = perdin.TotalEstimasi
+	penyelesaian.TotalRealisasi = total
+	penyelesaian.SelisihEstimasi = total - perdin.TotalEstimasi
+	penyelesaian.SelisihUangMuka = total - penyelesaian.UangMuka
+	return nil
+}
+
+func bandingkanBiayaPerdin(biaya []models.PerdinBiaya, realisasi []models.RealisasiPerdin) []perbandinganBiayaPerdin {
+	estimasi := make(map[string]models.Amount)
+	for _, b := range biaya {
+		estimasi[b.Kategori] += b.Total
+	}
+	aktual := make(map[string]models.Amount)
+	for _, r := range realisasi {
+		aktual[r.Kategori] += r.Total
+	}
+
+	hasil := []perbandinganBiayaPerdin{}
+	for _, kategori := range urutanKategoriBiaya {
+		hasil = append(hasil, perbandinganBiayaPerdin{
+			Kategori:  kategori,
+			Estimasi:  estimasi[kategori],
+			Realisasi: aktual[kategori],
+			Selisih:   aktual[kategori] - estimasi[kategori],
+		})
+	}
+	return hasil
+}
+
+func tulisLembarPenyelesaianPerdin(f *excelize.File, sheet string, perdin models.Perdin, penyelesaian models.PenyelesaianPerdin, peserta []models.PerdinPeserta, biaya []models.PerdinBiaya, realisasi []models.RealisasiPerdin) error {
+	border := []excelize.Border{
+		{Type: "left", Color: "000000", Style: 1},
+		{Type: "top", Color: "000000", Style: 1},
+		{Type: "bottom", Color: "000000", Style: 1},
+		{Type: "right", Color: "000000", Style: 1},
+	}
+	judulStyle, err := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true, Size: 14},
+		Alignment: &excelize.Alignment{Horizontal: "center"},
+	})
+	if err != nil {
+		return err
+	}
+	headerStyle, err := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true},
+		Fill:      excelize.Fill{Type: "pattern", Color: []string{"#DDEBF7"}, Pattern: 1},
+		Alignment: &excelize.Alignment{Horizontal: "center", Vertical: "center", WrapText: true},
+		Border:    border,
+	})
+	if err != nil {
+		return err
+	}
+	teksStyle, err := f.NewStyle(&excelize.Style{Border: border, Alignment: &excelize.Alignment{WrapText: true, Vertical: "top"}})
+	if err != nil {
+		return err
+	}
+	uangStyle, err := newAnggaranStyle(f)
+	if err != nil {
+		return err
+	}
+	totalStyle, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}, Border: border})
+	if err != nil {
+		return err
+	}
+
+	f.SetColWidth(sheet, "A", "A", 5)
+	f.SetColWidth(sheet, "B", "B", 18)
+	f.SetColWidth(sheet, "C", "C", 40)
+	f.SetColWidth(sheet, "D", "D", 12)
+	f.SetColWidth(sheet, "E", "E", 8)
+	f.SetColWidth(sheet, "F", "G", 18)
+	f.SetColWidth(sheet, "H", "H", 30)
+
+	f.SetCellValue(sheet, "A1", "RINCIAN BIAYA PERJALANAN DINAS (PENYELESAIAN SPPD)")
+	f.MergeCell(sheet, "A1", "H1")
+	f.SetCellStyle(sheet, "A1", "H1", judulStyle)
+
+	nama := []string{}
+	for _, p := range peserta {
+		if p.Golongan != "" {
+			nama = append(nama, fmt.Sprintf("%s (%s)", p.Nama, p.Golongan))
+		} else {
+			nama = append(nama, p.Nama)
+		}
+	}
+	info := [][]interface{}{
+		{"No. Perdin", derefString(perdin.NoPerdin)},
+		{"Pelaksana", strings.Join(nama, ", ")},
+		{"Tujuan", derefString(perdin.Tujuan)},
+		{"Keperluan", derefString(perdin.Keperluan)},
+		{"Tanggal", fmt.Sprintf("%s s/d %s (%d hari)", models.FormatTanggal(perdin.TanggalBerangkat, "02-01-2006"), models.FormatTanggal(perdin.TanggalKembali, "02-01-2006"), jumlahHariPerdin(perdin))},
+		{"Status", penyelesaian.Status},
+	}
+	row := 3
+	for _, baris := range info {
+		f.SetCellValue(sheet, fmt.Sprintf("B%d", row), baris[0])
+		f.SetCellValue(sheet, fmt.Sprintf("C%d", row), ": "+baris[1].(string))
+		f.MergeCell(sheet, fmt.Sprintf("C%d", row), fmt.Sprintf("H%d", row))
+		row++
+	}
+
+	// Rincian biaya aktual
+	row++
+	f.SetSheetRow(sheet, fmt.Sprintf("A%d", row), &[]interface{}{"No", "Kategori", "Keterangan", "Tanggal", "Jumlah", "Harga Satuan", "Total", "Bukti"})
+	f.SetCellStyle(sheet, fmt.Sprintf("A%d", row), fmt.Sprintf("H%d", row), headerStyle)
+	row++
+	for i, r := range realisasi {
+		f.SetSheetRow(sheet, fmt.Sprintf("A%d", row), &[]interface{}{
+			i + 1, r.Kategori, derefString(r.Keterangan), models.FormatTanggal(r.Tanggal, "02-01-2006"), r.Jumlah,
+			r.HargaSatuan.Float64(), r.Total.Float64(), r.NamaFile,
+		})
+		f.SetCellStyle(sheet, fmt.Sprintf("A%d", row), fmt.Sprintf("E%d", row), teksStyle)
+		f.SetCellStyle(sheet, fmt.Sprintf("F%d", row), fmt.Sprintf("G%d", row), uangStyle)
+		f.SetCellStyle(sheet, fmt.Sprintf("H%d", row), fmt.Sprintf("H%d", row), teksStyle)
+		row++
+	}
+	f.SetCellValue(sheet, fmt.Sprintf("F%d", row), "Total Realisasi")
+	f.SetCellValue(sheet, fmt.Sprintf("G%d", row), penyelesaian.TotalRealisasi.Float64())
+	f.SetCellStyle(sheet, fmt.Sprintf("F%d", row), fmt.Sprintf("F%d", row), totalStyle)
+	f.SetCellStyle(sheet, fmt.Sprintf("G%d", row), fmt.Sprintf("G%d", row), uangStyle)
+
+	// Perbandingan estimasi dan realisasi per kategori
+	row += 2
+	f.SetSheetRow(sheet, fmt.Sprintf("B%d", row), &[]interface{}{"Kategori", "", "", "", "Estimasi", "Realisasi"})
+	f.SetCellValue(sheet, fmt.Sprintf("H%d", row), "Selisih")
+	f.MergeCell(sheet, fmt.Sprintf("B%d", row), fmt.Sprintf("E%d", row))
+	f.SetCellStyle(sheet, fmt.Sprintf("B%d", row), fmt.Sprintf("H%d", row), headerStyle)
+	row++
+	for _, p := range bandingkanBiayaPerdin(biaya, realisasi) {
+		f.SetCellValue(sheet, fmt.Sprintf("B%d", row), p.Kategori)
+		f.MergeCell(sheet, fmt.Sprintf("B%d", row), fmt.Sprintf("E%d", row))
+		f.SetCellValue(sheet, fmt.Sprintf("F%d", row), p.Estimasi.Float64())
+		f.SetCellValue(sheet, fmt.Sprintf("G%d", row), p.Realisasi.Float64())
+		f.SetCellValue(sheet, fmt.Sprintf("H%d", row), p.Selisih.Float64())
+		f.SetCellStyle(sheet, fmt.Sprintf("B%d", row), fmt.Sprintf("E%d", row), teksStyle)
+		f.SetCellStyle(sheet, fmt.Sprintf("F%d", row), fmt.Sprintf("H%d", row), uangStyle)
+		row++
+	}
+
+	// Ringkasan terhadap uang muka
+	row++
+	selisihLabel := "Kurang Bayar (dibayarkan ke pelaksana)"
+	if penyelesaian.SelisihUangMuka < 0 {
+		selisihLabel = "Lebih Bayar (dikembalikan pelaksana)"
+	}
+	ringkasan := []struct {
+		label string
+		nilai models.Amount
+	}{
+		{"Total Estimasi", penyelesaian.TotalEstimasi},
+		{"Uang Muka Diterima", penyelesaian.UangMuka},
+		{"Total Realisasi", penyelesaian.TotalRealisasi},
+		{selisihLabel, absAmount(penyelesaian.SelisihUangMuka)},
+	}
+	for _, r := range ringkasan {
+		f.SetCellValue(sheet, fmt.Sprintf("B%d", row), r.label)
+		f.MergeCell(sheet, fmt.Sprintf("B%d", row), fmt.Sprintf("F%d", row))
+		f.SetCellValue(sheet, fmt.Sprintf("G%d", row), r.nilai.Float64())
+		f.SetCellStyle(sheet, fmt.Sprintf("B%d", row), fmt.Sprintf("F%d", row), totalStyle)
+		f.SetCellStyle(sheet, fmt.Sprintf("G%d", row), fmt.Sprintf("G%d", row), uangStyle)
+		row++
+	}
+
+	// Kolom tanda tangan
+	row += 2
+	f.SetCellValue(sheet, fmt.Sprintf("B%d", row), "Pelaksana")
+	f.SetCellValue(sheet, fmt.Sprintf("G%d", row), "Menyetujui")
+	row += 4
+	f.SetCellValue(sheet, fmt.Sprintf("B%d", row), derefString(penyelesaian.DiajukanOleh))
+	f.SetCellValue(sheet, fmt.Sprintf("G%d", row), derefString(penyelesaian.DiputuskanOleh))
+	return nil
+}
+
+func absAmount(a models.Amount) models.Amount {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
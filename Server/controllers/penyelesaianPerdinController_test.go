@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"net/http/httptest"
+	"project-its/models"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPilihPengajuPerdin(t *testing.T) {
+	peserta := []models.PerdinPeserta{{UserID: 7}, {UserID: 8}, {UserID: 9}}
+	tests := []struct {
+		nama    string
+		peserta []models.PerdinPeserta
+		userID  uint
+		admin   bool
+		want    uint
+		ok      bool
+	}{
+		{"peserta mengajukan sendiri", peserta, 8, false, 8, true},
+		{"admin yang juga peserta tetap menjadi pengaju", peserta, 9, true, 9, true},
+		{"admin atas nama peserta pertama", peserta, 1, true, 7, true},
+		{"bukan peserta", peserta, 3, false, 0, false},
+		{"admin tanpa peserta", nil, 1, true, 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := pilihPengajuPerdin(tt.peserta, tt.userID, tt.admin)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%s: pilihPengajuPerdin = %d, %v, ingin %d, %v", tt.nama, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// Pemutus penyelesaian adalah atasan pengaju yang disalin saat diajukan, bukan atasan admin yang mengajukan
+func TestPemutusPenyelesaianPerdin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	atasanPeserta := uint(20)
+	peserta := []models.PerdinPeserta{{UserID: 7}, {UserID: 8}}
+	atasan := map[uint]*uint{7: &atasanPeserta, 8: &atasanPeserta, 1: nil}
+
+	tests := []struct {
+		nama    string
+		pengaju uint // user yang mengajukan
+		admin   bool
+		pemutus uint
+		role    string
+		boleh   bool
+	}{
+		{"atasan peserta menyetujui", 8, false, 20, "user", true},
+		{"diajukan admin, diputus atasan peserta", 1, true, 20, "user", true},
+		{"diajukan admin, admin tidak bisa memutus", 1, true, 1, roleAdmin, false},
+		{"peserta tidak bisa memutus sendiri", 8, false, 8, "user", false},
+		{"peserta lain bukan atasan", 8, false, 7, "user", false},
+	}
+
+	for _, tt := range tests {
+		pengajuID, ok := pilihPengajuPerdin(peserta, tt.pengaju, tt.admin)
+		if !ok {
+			t.Fatalf("%s: pengaju tidak terpilih", tt.nama)
+		}
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Set("userID", tt.pemutus)
+		c.Set("role", tt.role)
+		if pesan := cekPemutusPengajuan(c, pengajuID, atasan[pengajuID]); (pesan == "") != tt.boleh {
+			t.Errorf("%s: cekPemutusPengajuan = %q, ingin boleh %v", tt.nama, pesan, tt.boleh)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -15,15 +16,25 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
 )
 
+// folder file perdin, juga dipakai untuk mencari bukti penyelesaian perdin
+const baseDirFilePerdin = "C:/UploadedFile/perdin"
+
 type perdinRequest struct {
-	ID        uint    `gorm:"primaryKey"`
-	NoPerdin  *string `json:"no_perdin"`
-	Tanggal   *string `json:"tanggal"`
-	Hotel     *string `json:"hotel"`
-	Transport *string `json:"transport"`
-	CreateBy  string  `json:"create_by"`
+	ID               uint                   `gorm:"primaryKey"`
+	NoPerdin         *string                `json:"no_perdin"`
+	Tanggal          *string                `json:"tanggal"`
+	Hotel            *string                `json:"hotel"`
+	Transport        *string                `json:"transport"`
+	CreateBy         string                 `json:"create_by"`
+	Tujuan           *string                `json:"tujuan"`
+	Keperluan        *string                `json:"keperluan"`
+	TanggalBerangkat *string                `json:"tanggal_berangkat"`
+	TanggalKembali   *string                `json:"tanggal_kembali"`
+	Peserta          []perdinPesertaRequest `json:"peserta"` // nil berarti peserta tidak diubah
+	Biaya            []perdinBiayaRequest   `json:"biaya"`   // tanpa uang harian, dihitung server
 }
 
 func UploadHandlerPerdin(c *gin.Context) {
@@ -41,8 +52,7 @@ func UploadHandlerPerdin(c *gin.Context) {
 		return
 	}
 
-	baseDir := "C:/UploadedFile/perdin"
-	dir := filepath.Join(baseDir, id)
+	dir := filepath.Join(baseDirFilePerdin, id)
 	if _, err := os.Stat(dir); os.IsNotExist(err) {
 		os.MkdirAll(dir, 0755)
 	}
@@ -67,7 +77,8 @@ func UploadHandlerPerdin(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "File berhasil diunggah"})
+	// file_id dipakai untuk menautkan bukti ke baris realisasi penyelesaian perdin
+	c.JSON(http.StatusOK, gin.H{"message": "File berhasil diunggah", "file_id": newFile.ID})
 }
 
 func GetFilesByIDPerdin(c *gin.Context) {
@@ -100,8 +111,7 @@ func DeleteFileHandlerPerdin(c *gin.Context) {
 	id := c.Param("id")
 	log.Printf("Received ID: %s and Filename: %s", id, filename) // Tambahkan log ini
 
-	baseDir := "C:/UploadedFile/perdin"
-	fullPath := filepath.Join(baseDir, id, filename)
+	fullPath := filepath.Join(baseDirFilePerdin, id, filename)
 
 	log.Printf("Attempting to delete file at path: %s", fullPath)
 
@@ -127,8 +137,7 @@ func DeleteFileHandlerPerdin(c *gin.Context) {
 func DownloadFileHandlerPerdin(c *gin.Context) {
 	id := c.Param("id")
 	filename := c.Param("filename")
-	baseDir := "C:/UploadedFile/perdin"
-	fullPath := filepath.Join(baseDir, id, filename)
+	fullPath := filepath.Join(baseDirFilePerdin, id, filename)
 
 	log.Printf("Full path for download: %s", fullPath)
 
@@ -186,17 +195,30 @@ func PerdinCreate(c *gin.Context) {
 		Transport: requestBody.Transport,
 		CreateBy:  requestBody.CreateBy,
 	}
+	if err := terapkanHeaderPerdin(&perdin, requestBody); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
 
-	result := initializers.DB.Create(&perdin)
-
-	if result.Error != nil {
-		c.Status(400)
+	// Peserta, rincian biaya dan total estimasi disimpan bersama header perdin
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&perdin).Error; err != nil {
+			return err
+		}
+		return simpanRincianPerdin(tx, &perdin, requestBody)
+	})
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
 
+	peserta, biaya := muatRincianPerdin(perdin.ID)
+
 	// Mengembalikan hasil
 	c.JSON(200, gin.H{
-		"perdin": perdin,
+		"perdin":  &perdin,
+		"peserta": peserta,
+		"biaya":   biaya,
 	})
 }
 
@@ -219,10 +241,13 @@ func PerdinShow(c *gin.Context) {
 	var perdin models.Perdin
 
 	initializers.DB.First(&perdin, id)
+	peserta, biaya := muatRincianPerdin(perdin.ID)
 
 	//Respond with them
 	c.JSON(200, gin.H{
-		"perdin": perdin,
+		"perdin":  &perdin,
+		"peserta": peserta,
+		"biaya":   biaya,
 	})
 }
 
@@ -282,10 +307,31 @@ func PerdinUpdate(c *gin.Context) {
 		perdin.CreateBy = perdin.CreateBy // gunakan nilai yang ada dari database
 	}
 
-	initializers.DB.Model(&perdin).Updates(perdin)
+	if err := terapkanHeaderPerdin(&perdin, requestBody); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := cekPerdinTerkunci(tx, perdin.ID); err != nil {
+			return err
+		}
+		if err := tx.Model(&perdin).Updates(perdin).Error; err != nil {
+			return err
+		}
+		return simpanRincianPerdin(tx, &perdin, requestBody)
+	})
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	peserta, biaya := muatRincianPerdin(perdin.ID)
 
 	c.JSON(200, gin.H{
-		"perdin": perdin,
+		"perdin":  &perdin,
+		"peserta": peserta,
+		"biaya":   biaya,
 	})
 
 }
@@ -303,8 +349,31 @@ func PerdinDelete(c *gin.Context) {
 		return
 	}
 
-	/// delete it
-	if err := initializers.DB.Delete(&perdin).Error; err != nil {
+	/// delete it beserta peserta, rincian biaya dan penyelesaiannya
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := cekPerdinTerkunci(tx, perdin.ID); err != nil {
+			return err
+		}
+		if err := tx.Where("perdin_id = ?", perdin.ID).Delete(&models.RealisasiPerdin{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("perdin_id = ?", perdin.ID).Delete(&models.PenyelesaianPerdin{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("perdin_id = ?", perdin.ID).Delete(&models.PerdinBiaya{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("perdin_id = ?", perdin.ID).Delete(&models.PerdinPeserta{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&perdin).Error
+	})
+	var errTerkunci errPenyelesaianPerdin
+	if errors.As(err, &errTerkunci) {
+		c.JSON(400, gin.H{"error": errTerkunci.Error()})
+		return
+	}
+	if err != nil {
 		c.JSON(404, gin.H{"error": "Perdin Failed to Delete"})
 		return
 	}
@@ -0,0 +1,396 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"project-its/initializers"
+	"project-its/models"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Kategori estimasi biaya perdin; Uang Harian selalu dihitung server dari TarifUangHarian
+const (
+	kategoriBiayaTransport      = "Transport"
+	kategoriBiayaHotel          = "Hotel"
+	kategoriBiayaUangHarian     = "Uang Harian"
+	kategoriBiayaTransportLokal = "Transport Lokal"
+)
+
+// tujuan pada TarifUangHarian yang dipakai bila tujuan perdin tidak punya tarif sendiri
+const tujuanTarifDefault = "*"
+
+var urutanKategoriBiaya = []string{kategoriBiayaTransport, kategoriBiayaHotel, kategoriBiayaUangHarian, kategoriBiayaTransportLokal}
+
+type perdinPesertaRequest struct {
+	UserID   *uint   `json:"user_id"`
+	Golongan *string `json:"golongan"` // kosong berarti memakai golongan user
+}
+
+type perdinBiayaRequest struct {
+	Kategori    *string        `json:"kategori"`
+	Keterangan  *string        `json:"keterangan"`
+	Jumlah      *int           `json:"jumlah"`
+	HargaSatuan *models.Amount `json:"harga_satuan"`
+}
+
+type TarifUangHarianRequest struct {
+	Tujuan     *string        `json:"tujuan"`
+	Golongan   *string        `json:"golongan"`
+	Tarif      *models.Amount `json:"tarif"`
+	Keterangan *string        `json:"keterangan"`
+}
+
+type rekapBiayaPerdin struct {
+	Kategori string        `json:"kategori"`
+	Total    models.Amount `json:"total"`
+}
+
+// PerdinRincian menampilkan perdin beserta peserta, rincian biaya dan total per kategori
+func PerdinRincian(c *gin.Context) {
+	var perdin models.Perdin
+	if err := initializers.DB.First(&perdin, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Perdin tidak ditemukan"})
+		return
+	}
+
+	peserta, biaya := muatRincianPerdin(perdin.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"perdin":      &perdin,
+		"jumlah_hari": jumlahHariPerdin(perdin),
+		"peserta":     peserta,
+		"biaya":       biaya,
+		"rekap":       rekapKategoriBiaya(biaya),
+	})
+}
+
+// PerdinHitungUlang menghitung ulang uang harian dan total, misalnya setelah tarif berubah
+func PerdinHitungUlang(c *gin.Context) {
+	var perdin models.Perdin
+	if err := initializers.DB.First(&perdin, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Perdin tidak ditemukan"})
+		return
+	}
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := cekPerdinTerkunci(tx, perdin.ID); err != nil {
+			return err
+		}
+		if err := hitungUangHarianPerdin(tx, &perdin); err != nil {
+			return err
+		}
+		return hitungTotalEstimasiPerdin(tx, &perdin)
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	peserta, biaya := muatRincianPerdin(perdin.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"perdin":  &perdin,
+		"peserta": peserta,
+		"biaya":   biaya,
+		"rekap":   rekapKategoriBiaya(biaya),
+	})
+}
+
+func TarifUangHarianIndex(c *gin.Context) {
+	var tarif []models.TarifUangHarian
+	query := initializers.DB.Order("tujuan asc, golongan asc")
+	if tujuan := c.Query("tujuan"); tujuan != "" {
+		query = query.Where("LOWER(tujuan) = LOWER(?)", tujuan)
+	}
+	if err := query.Find(&tarif).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tarif": tarif})
+}
+
+// TarifUangHarianSet membuat atau mengganti tarif untuk pasangan tujuan dan golongan
+func TarifUangHarianSet(c *gin.Context) {
+	var req TarifUangHarianRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Tujuan == nil || strings.TrimSpace(*req.Tujuan) == "" || req.Golongan == nil || strings.TrimSpace(*req.Golongan) == "" || req.Tarif == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "tujuan, golongan dan tarif wajib diisi"})
+		return
+	}
+	if *req.Tarif < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tarif tidak boleh negatif"})
+		return
+	}
+
+	tujuan := strings.TrimSpace(*req.Tujuan)
+	golongan := strings.TrimSpace(*req.Golongan)
+
+	var tarif models.TarifUangHarian
+	err := initializers.DB.Where("LOWER(tujuan) = LOWER(?) AND golongan = ?", tujuan, golongan).First(&tarif).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	tarif.Tujuan = tujuan
+	tarif.Golongan = golongan
+	tarif.Tarif = *req.Tarif
+	tarif.Keterangan = req.Keterangan
+	tarif.UpdateBy = c.MustGet("username").(string)
+
+	if err := initializers.DB.Save(&tarif).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tarif": tarif})
+}
+
+func TarifUangHarianDelete(c *gin.Context) {
+	if err := initializers.DB.Where("id = ?", c.Param("id")).Delete(&models.TarifUangHarian{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// terapkanHeaderPerdin mengisi tujuan, keperluan dan tanggal perjalanan dari request
+func terapkanHeaderPerdin(perdin *models.Perdin, req perdinRequest) error {
+	if req.Tujuan != nil {
+		perdin.Tujuan = req.Tujuan
+	}
+	if req.Keperluan != nil {
+		perdin.Keperluan = req.Keperluan
+	}
+	if req.TanggalBerangkat != nil && *req.TanggalBerangkat != "" {
+		t, err := time.Parse("2006-01-02", *req.TanggalBerangkat)
+		if err != nil {
+			return fmt.Errorf("Format tanggal berangkat harus YYYY-MM-DD")
+		}
+		perdin.TanggalBerangkat = &t
+	}
+	if req.TanggalKembali != nil && *req.TanggalKembali != "" {
+		t, err := time.Parse("2006-01-02", *req.TanggalKembali)
+		if err != nil {
+			return fmt.Errorf("Format tanggal kembali harus YYYY-MM-DD")
+		}
+		perdin.TanggalKembali = &t
+	}
+	if perdin.TanggalBerangkat != nil && perdin.TanggalKembali != nil && perdin.TanggalKembali.Before(*perdin.TanggalBerangkat) {
+		return fmt.Errorf("Tanggal kembali tidak boleh sebelum tanggal berangkat")
+	}
+	// Tanggal lama tetap terisi agar daftar dan export perdin yang sudah ada tidak kosong
+	if perdin.Tanggal == nil && perdin.TanggalBerangkat != nil {
+		tanggal := *perdin.TanggalBerangkat
+		perdin.Tanggal = &tanggal
+	}
+	return nil
+}
+
+// simpanRincianPerdin mengganti peserta dan biaya bila dikirim (nil berarti tidak diubah),
+// lalu menghitung ulang uang harian dan total estimasi
+func simpanRincianPerdin(tx *gorm.DB, perdin *models.Perdin, req perdinRequest) error {
+	if req.Peserta != nil {
+		if err := tx.Where("perdin_id = ?", perdin.ID).Delete(&models.PerdinPeserta{}).Error; err != nil {
+			return err
+		}
+		sudah := make(map[uint]bool)
+		for _, p := range req.Peserta {
+			if p.UserID == nil {
+				return fmt.Errorf("user_id peserta wajib diisi")
+			}
+			if sudah[*p.UserID] {
+				return fmt.Errorf("Peserta dengan user_id %d tercantum lebih dari sekali", *p.UserID)
+			}
+			sudah[*p.UserID] = true
+
+			var user models.User
+			if err := tx.First(&user, *p.UserID).Error; err != nil {
+				return fmt.Errorf("User %d tidak ditemukan", *p.UserID)
+			}
+			golongan := user.Golongan
+			if p.Golongan != nil && strings.TrimSpace(*p.Golongan) != "" {
+				golongan = strings.TrimSpace(*p.Golongan)
+			}
+			peserta := models.PerdinPeserta{PerdinID: perdin.ID, UserID: user.ID, Nama: user.Username, Golongan: golongan}
+			if err := tx.Create(&peserta).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	if req.Biaya != nil {
+		if err := tx.Where("perdin_id = ? AND kategori <> ?", perdin.ID, kategoriBiayaUangHarian).Delete(&models.PerdinBiaya{}).Error; err != nil {
+			return err
+		}
+		for i, b := range req.Biaya {
+			kategori, ok := normalisasiKategoriBiaya(derefString(b.Kategori))
+			if !ok {
+				return fmt.Errorf("Biaya ke-%d: kategori %q tidak dikenal", i+1, derefString(b.Kategori))
+			}
+			if kategori == kategoriBiayaUangHarian {
+				return fmt.Errorf("Biaya ke-%d: uang harian dihitung otomatis dari tarif", i+1)
+			}
+			jumlah := 1
+			if b.Jumlah != nil {
+				jumlah = *b.Jumlah
+			}
+			if jumlah < 1 {
+				return fmt.Errorf("Biaya ke-%d: jumlah minimal 1", i+1)
+			}
+			if b.HargaSatuan == nil || *b.HargaSatuan < 0 {
+				return fmt.Errorf("Biaya ke-%d: harga satuan wajib diisi dan tidak boleh negatif", i+1)
+			}
+			biaya := models.PerdinBiaya{
+				PerdinID:    perdin.ID,
+				Kategori:    kategori,
+				Keterangan:  b.Keterangan,
+				Jumlah:      jumlah,
+				HargaSatuan: *b.HargaSatuan,
+				Total:       *b.HargaSatuan * models.Amount(jumlah),
+			}
+			if err := tx.Create(&biaya).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := hitungUangHarianPerdin(tx, perdin); err != nil {
+		return err
+	}
+	return hitungTotalEstimasiPerdin(tx, perdin)
+}
+
+// hitungUangHarianPerdin membuat ulang baris uang harian: tarif tujuan x golongan x jumlah hari per peserta
+func hitungUangHarianPerdin(tx *gorm.DB, perdin *models.Perdin) error {
+	if err := tx.Where("perdin_id = ? AND kategori = ?", perdin.ID, kategoriBiayaUangHarian).Delete(&models.PerdinBiaya{}).Error; err != nil {
+		return err
+	}
+	hari := jumlahHariPerdin(*perdin)
+	tujuan := strings.TrimSpace(derefString(perdin.Tujuan))
+	if hari == 0 || tujuan == "" {
+		return tx.Model(&models.PerdinPeserta{}).Where("perdin_id = ?", perdin.ID).Update("peringatan", nil).Error
+	}
+
+	var peserta []models.PerdinPeserta
+	if err := tx.Where("perdin_id = ?", perdin.ID).Order("id asc").Find(&peserta).Error; err != nil {
+		return err
+	}
+	// Peserta tanpa golongan/tarif dilewati dan ditandai, biaya lain tetap dihitung
+	for _, p := range peserta {
+		var tarif models.Amount
+		var peringatan *string
+		if p.Golongan == "" {
+			pesan := fmt.Sprintf("Golongan peserta %s belum diisi", p.Nama)
+			peringatan = &pesan
+		} else {
+			var err error
+			tarif, err = cariTarifUangHarian(tx, tujuan, p.Golongan)
+			if errors.Is(err, errTarifBelumDiatur) {
+				pesan := err.Error()
+				peringatan = &pesan
+			} else if err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&models.PerdinPeserta{}).Where("id = ?", p.ID).Update("peringatan", peringatan).Error; err != nil {
+			return err
+		}
+		if peringatan != nil {
+			continue
+		}
+		pesertaID := p.ID
+		keterangan := fmt.Sprintf("Uang harian %s (golongan %s, %d hari)", p.Nama, p.Golongan, hari)
+		biaya := models.PerdinBiaya{
+			PerdinID:    perdin.ID,
+			PesertaID:   &pesertaID,
+			Kategori:    kategoriBiayaUangHarian,
+			Keterangan:  &keterangan,
+			Jumlah:      hari,
+			HargaSatuan: tarif,
+			Total:       tarif * models.Amount(hari),
+		}
+		if err := tx.Create(&biaya).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func hitungTotalEstimasiPerdin(tx *gorm.DB, perdin *models.Perdin) error {
+	var biaya []models.PerdinBiaya
+	if err := tx.Where("perdin_id = ?", perdin.ID).Find(&biaya).Error; err != nil {
+		return err
+	}
+	var total models.Amount
+	for _, b := range biaya {
+		total += b.Total
+	}
+	perdin.TotalEstimasi = total
+	return tx.Model(perdin).Update("total_estimasi", total).Error
+}
+
+var errTarifBelumDiatur = errors.New("Tarif uang harian belum diatur")
+
+// cariTarifUangHarian mencari tarif tujuan (tanpa membedakan huruf besar) lalu jatuh ke tarif "*"
+func cariTarifUangHarian(tx *gorm.DB, tujuan, golongan string) (models.Amount, error) {
+	var tarif models.TarifUangHarian
+	err := tx.Where("LOWER(tujuan) = LOWER(?) AND golongan = ?", tujuan, golongan).First(&tarif).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = tx.Where("tujuan = ? AND golongan = ?", tujuanTarifDefault, golongan).First(&tarif).Error
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, fmt.Errorf("%w untuk tujuan %s golongan %s", errTarifBelumDiatur, tujuan, golongan)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return tarif.Tarif, nil
+}
+
+func muatRincianPerdin(perdinID uint) ([]models.PerdinPeserta, []models.PerdinBiaya) {
+	peserta := []models.PerdinPeserta{}
+	biaya := []models.PerdinBiaya{}
+	initializers.DB.Where("perdin_id = ?", perdinID).Order("id asc").Find(&peserta)
+	initializers.DB.Where("perdin_id = ?", perdinID).Order("id asc").Find(&biaya)
+	return peserta, biaya
+}
+
+func rekapKategoriBiaya(biaya []models.PerdinBiaya) []rekapBiayaPerdin {
+	total := make(map[string]models.Amount)
+	for _, b := range biaya {
+		total[b.Kategori] += b.Total
+	}
+	rekap := []rekapBiayaPerdin{}
+	for _, kategori := range urutanKategoriBiaya {
+		rekap = append(rekap, rekapBiayaPerdin{Kategori: kategori, Total: total[kategori]})
+	}
+	return rekap
+}
+
+// jumlahHariPerdin menghitung hari perjalanan termasuk hari berangkat dan kembali
+func jumlahHariPerdin(perdin models.Perdin) int {
+	if perdin.TanggalBerangkat == nil || perdin.TanggalKembali == nil {
+		return 0
+	}
+	return int(truncateToDate(*perdin.TanggalKembali).Sub(truncateToDate(*perdin.TanggalBerangkat)).Hours()/24) + 1
+}
+
+func normalisasiKategoriBiaya(kategori string) (string, bool) {
+	switch strings.ToLower(strings.NewReplacer("_", " ", "-", " ").Replace(strings.TrimSpace(kategori))) {
+	case "transport", "transportasi", "tiket":
+		return kategoriBiayaTransport, true
+	case "hotel", "penginapan", "akomodasi":
+		return kategoriBiayaHotel, true
+	case "uang harian", "per diem", "perdiem":
+		return kategoriBiayaUangHarian, true
+	case "transport lokal", "local transport":
+		return kategoriBiayaTransportLokal, true
+	}
+	return "", false
+}
@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"project-its/models"
+	"testing"
+	"time"
+)
+
+func TestJumlahHariPerdin(t *testing.T) {
+	waktu := func(y int, m time.Month, d, jam int) *time.Time {
+		t := time.Date(y, m, d, jam, 0, 0, 0, time.UTC)
+		return &t
+	}
+
+	tests := []struct {
+		nama      string
+		berangkat *time.Time
+		kembali   *time.Time
+		want      int
+	}{
+		{"pulang hari", waktu(2024, time.March, 4, 0), waktu(2024, time.March, 4, 0), 1},
+		{"tiga hari", waktu(2024, time.March, 4, 0), waktu(2024, time.March, 6, 0), 3},
+		{"jam diabaikan", waktu(2024, time.March, 4, 21), waktu(2024, time.March, 5, 6), 2},
+		{"melewati akhir bulan kabisat", waktu(2024, time.February, 28, 0), waktu(2024, time.March, 1, 0), 3},
+		{"tanggal kembali kosong", waktu(2024, time.March, 4, 0), nil, 0},
+		{"tanggal berangkat kosong", nil, waktu(2024, time.March, 4, 0), 0},
+	}
+
+	for _, tt := range tests {
+		perdin := models.Perdin{TanggalBerangkat: tt.berangkat, TanggalKembali: tt.kembali}
+		if got := jumlahHariPerdin(perdin); got != tt.want {
+			t.Errorf("%s: jumlahHariPerdin = %d, ingin %d", tt.nama, got, tt.want)
+		}
+	}
+}
+
+func TestRekapKategoriBiaya(t *testing.T) {
+	tests := []struct {
+		nama  string
+		biaya []models.PerdinBiaya
+		want  map[string]models.Amount
+	}{
+		{
+			nama:  "tanpa biaya",
+			biaya: nil,
+			want:  map[string]models.Amount{},
+		},
+		{
+			nama: "dijumlah per kategori",
+			biaya: []models.PerdinBiaya{
+				{Kategori: kategoriBiayaTransport, Total: 150000050},
+				{Kategori: kategoriBiayaHotel, Total: 75000000},
+				{Kategori: kategoriBiayaTransport, Total: 99999950},
+				{Kategori: kategoriBiayaUangHarian, Total: 135000000},
+			},
+			want: map[string]models.Amount{
+				kategoriBiayaTransport:  250000000,
+				kategoriBiayaHotel:      75000000,
+				kategoriBiayaUangHarian: 135000000,
+			},
+		},
+		{
+			nama: "kategori di luar daftar tidak ikut",
+			biaya: []models.PerdinBiaya{
+				{Kategori: kategoriBiayaTransportLokal, Total: 10000000},
+				{Kategori: "Lainnya", Total: 5000000},
+			},
+			want: map[string]models.Amount{kategoriBiayaTransportLokal: 10000000},
+		},
+	}
+
+	for _, tt := range tests {
+		rekap := rekapKategoriBiaya(tt.biaya)
+		if len(rekap) != len(urutanKategoriBiaya) {
+			t.Fatalf("%s: jumlah kategori = %d, ingin %d", tt.nama, len(rekap), len(urutanKategoriBiaya))
+		}
+		for i, r := range rekap {
+			if r.Kategori != urutanKategoriBiaya[i] {
+				t.Errorf("%s: kategori ke-%d = %q, ingin %q", tt.nama, i, r.Kategori, urutanKategoriBiaya[i])
+			}
+			if r.Total != tt.want[r.Kategori] {
+				t.Errorf("%s: total %s = %s, ingin %s", tt.nama, r.Kategori, r.Total, tt.want[r.Kategori])
+			}
+		}
+	}
+}
+
+func TestNormalisasiKategoriBiaya(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		ok    bool
+	}{
+		{"Transport", kategoriBiayaTransport, true},
+		{" tiket ", kategoriBiayaTransport, true},
+		{"Penginapan", kategoriBiayaHotel, true},
+		{"uang_harian", kategoriBiayaUangHarian, true},
+		{"Per-Diem", kategoriBiayaUangHarian, true},
+		{"transport lokal", kategoriBiayaTransportLokal, true},
+		{"konsumsi", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := normalisasiKategoriBiaya(tt.input)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("normalisasiKategoriBiaya(%q) = %q, %v, ingin %q, %v", tt.input, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,414 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"project-its/initializers"
+	"project-its/models"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	kanalInApp   = "inapp"
+	kanalEmail   = "email"
+	kanalWebhook = "webhook"
+
+	modeLangsung  = "langsung"  // dikirim saat itu juga
+	modeRingkasan = "ringkasan" // ditahan lalu masuk ringkasan harian
+	modeSenyap    = "senyap"    // tidak dikirim sama sekali
+
+	kategoriSemua           = "*"
+	kategoriRingkasanHarian = "RingkasanHarian"
+
+	// ringkasan harian dikirim mulai jam ini (WIB) pada hari kerja
+	jamRingkasanHarian = 7
+	maksItemTertahan   = 20
+)
+
+var daftarKanalNotifikasi = []string{kanalInApp, kanalEmail, kanalWebhook}
+var daftarModeNotifikasi = []string{modeLangsung, modeRingkasan, modeSenyap}
+
+// preferensiUser memetakan "kanal|kategori" ke mode milik satu pengguna
+type preferensiUser map[string]string
+
+func kunciPreferensi(kanal, category string) string {
+	return kanal + "|" + category
+}
+
+// mode mencari preferensi kategori, lalu preferensi "*", default langsung.
+// Ringkasan harian sendiri tidak bisa ditahan ke ringkasan, paling jauh dibuat senyap.
+func (p preferensiUser) mode(kanal, category string) string {
+	mode, ok := p[kunciPreferensi(kanal, category)]
+	if !ok {
+		mode, ok = p[kunciPreferensi(kanal, kategoriSemua)]
+	}
+	if !ok {
+		return modeLangsung
+	}
+	if category == kategoriRingkasanHarian && mode == modeRingkasan {
+		return modeLangsung
+	}
+	return mode
+}
+
+// adaRingkasan bernilai true bila kategori ditahan untuk ringkasan di salah satu kanal
+func (p preferensiUser) adaRingkasan(category string) bool {
+	for _, kanal := range daftarKanalNotifikasi {
+		if p.mode(kanal, category) == modeRingkasan {
+			return true
+		}
+	}
+	return false
+}
+
+func muatPreferensi(tx *gorm.DB, userIDs []uint) (map[uint]preferensiUser, error) {
+	hasil := make(map[uint]preferensiUser)
+	if len(userIDs) == 0 {
+		return hasil, nil
+	}
+	var rows []models.PreferensiNotifikasi
+	if err := tx.Where("user_id IN ?", userIDs).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		if hasil[r.UserID] == nil {
+			hasil[r.UserID] = preferensiUser{}
+		}
+		hasil[r.UserID][kunciPreferensi(r.Kanal, r.Category)] = r.Mode
+	}
+	return hasil, nil
+}
+
+func muatPreferensiUser(tx *gorm.DB, userID uint) preferensiUser {
+	prefs, err := muatPreferensi(tx, []uint{userID})
+	if err != nil {
+		log.Printf("Error loading notification preferences: %v", err)
+		return preferensiUser{}
+	}
+	return prefs[userID]
+}
+
+// filterInboxPreferensi menyembunyikan kategori yang kanal in-app-nya tidak langsung
+func filterInboxPreferensi(query *gorm.DB, p preferensiUser) *gorm.DB {
+	var tampil, sembunyi []string
+	for kunci := range p {
+		kanal, category, _ := strings.Cut(kunci, "|")
+		if kanal != kanalInApp || category == kategoriSemua {
+			continue
+		}
+		if p.mode(kanalInApp, category) == modeLangsung {
+			tampil = append(tampil, category)
+		} else {
+			sembunyi = append(sembunyi, category)
+		}
+	}
+
+	if p.mode(kanalInApp, kategoriSemua) == modeLangsung {
+		if len(sembunyi) == 0 {
+			return query
+		}
+		return query.Where("notifications.category NOT IN ?", sembunyi)
+	}
+
+	// Default in-app ditahan/senyap: hanya kategori yang eksplisit langsung, plus ringkasan harian
+	if p.mode(kanalInApp, kategoriRingkasanHarian) == modeLangsung {
+		tampil = append(tampil, kategoriRingkasanHarian)
+	}
+	if len(tampil) == 0 {
+		return query.Where("1 = 0")
+	}
+	return query.Where("notifications.category IN ?", tampil)
+}
+
+// penggunaPenerimaNotifikasi mengikuti aturan inbox: ke pengguna, ke role, atau ke semua pengguna
+func penggunaPenerimaNotifikasi(tx *gorm.DB, n models.Notification) ([]models.User, error) {
+	query := tx.Model(&models.User{})
+	switch {
+	case n.UserID != nil:
+		query = query.Where("id = ?", *n.UserID)
+	case n.Role != nil:
+		query = query.Where("role = ?", *n.Role)
+	}
+	var users []models.User
+	err := query.Find(&users).Error
+	return users, err
+}
+
+func kategoriNotifikasi() []string {
+	kategori := []string{kategoriRingkasanHarian}
+	for k := range templateEmailPerKategori {
+		kategori = append(kategori, k)
+	}
+	sort.Strings(kategori)
+	return kategori
+}
+
+// GetPreferensiNotifikasi menampilkan preferensi pengguna yang login beserta pilihan kanal, mode dan kategori
+func GetPreferensiNotifikasi(c *gin.Context) {
+	var rows []models.PreferensiNotifikasi
+	if err := initializers.DB.Where("user_id = ?", c.MustGet("userID").(uint)).
+		Order("category, kanal").Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"preferensi": rows,
+		"kanal":      daftarKanalNotifikasi,
+		"mode":       daftarModeNotifikasi,
+		"kategori":   kategoriNotifikasi(),
+		"default":    modeLangsung,
+	})
+}
+
+type preferensiNotifikasiRequest struct {
+	Preferensi []struct {
+		Category string `json:"category"`
+		Kanal    string `json:"kanal"`
+		Mode     string `json:"mode"` // kosong atau "default" menghapus preferensi
+	} `json:"preferensi"`
+}
+
+// SetPreferensiNotifikasi menyimpan preferensi pengguna yang login. Category "*" berlaku untuk semua kategori.
+func SetPreferensiNotifikasi(c *gin.Context) {
+	var req preferensiNotifikasiRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID := c.MustGet("userID").(uint)
+
+	for _, p := range req.Preferensi {
+		if strings.TrimSpace(p.Category) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Kategori wajib diisi, gunakan * untuk semua kategori"})
+			return
+		}
+		if !containsString(daftarKanalNotifikasi, p.Kanal) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Kanal %s tidak dikenal", p.Kanal)})
+			return
+		}
+		if p.Mode != "" && p.Mode != "default" && !containsString(daftarModeNotifikasi, p.Mode) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Mode %s tidak dikenal", p.Mode)})
+			return
+		}
+		if p.Category == kategoriRingkasanHarian && p.Mode == modeRingkasan {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Ringkasan harian tidak dapat dimasukkan ke ringkasan"})
+			return
+		}
+	}
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		for _, p := range req.Preferensi {
+			category := strings.TrimSpace(p.Category)
+			if p.Mode == "" || p.Mode == "default" {
+				if err := tx.Where("user_id = ? AND category = ? AND kanal = ?", userID, category, p.Kanal).
+					Delete(&models.PreferensiNotifikasi{}).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			pref := models.PreferensiNotifikasi{UserID: userID, Category: category, Kanal: p.Kanal, Mode: p.Mode}
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "user_id"}, {Name: "category"}, {Name: "kanal"}},
+				DoUpdates: clause.AssignmentColumns([]string{"mode", "updated_at"}),
+			}).Create(&pref).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	GetPreferensiNotifikasi(c)
+}
+
+// KirimRingkasanHarian mengirim satu ringkasan per hari kerja ke pengguna yang menahan notifikasi ke ringkasan.
+// Baris RingkasanHarian (unik per pengguna dan tanggal) diklaim lebih dulu sehingga aman di beberapa instance.
+func KirimRingkasanHarian() {
+	loc := jakartaLocation()
+	now := time.Now().In(loc)
+	if now.Hour() < jamRingkasanHarian {
+		return
+	}
+	hariIni := truncateHariKetersediaan(now, loc)
+	kalender, err := muatKalenderKerja(initializers.DB)
+	if err != nil {
+		log.Printf("Error checking working day for daily digest: %v", err)
+		return
+	}
+	if !kalender.isHariKerja(hariIni) {
+		return
+	}
+
+	var userIDs []uint
+	if err := initializers.DB.Model(&models.PreferensiNotifikasi{}).
+		Where("mode = ?", modeRingkasan).Distinct("user_id").Pluck("user_id", &userIDs).Error; err != nil {
+		log.Printf("Error loading digest recipients: %v", err)
+		return
+	}
+	if len(userIDs) == 0 {
+		return
+	}
+	var users []models.User
+	if err := initializers.DB.Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+		log.Printf("Error loading digest recipients: %v", err)
+		return
+	}
+
+	// Task lewat target dimuat sekali lalu disaring per pengguna
+	overdue, err := findMeetingOverdue(now)
+	if err != nil {
+		log.Printf("Error loading overdue tasks for daily digest: %v", err)
+	}
+
+	tanggal := hariIni.Format("2006-01-02")
+	for _, u := range users {
+		// Klaim, notifikasi dan tanda kirim disimpan dalam satu transaksi: bila gagal di tengah jalan tidak ada
+		// yang tersimpan dan ringkasan dicoba lagi; instance lain menunggu klaim ini lalu melewatinya.
+		var notification *models.Notification
+		err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+			tanda := models.RingkasanHarian{UserID: u.ID, Tanggal: tanggal}
+			res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tanda)
+			if res.Error != nil || res.RowsAffected == 0 {
+				return res.Error // RowsAffected 0: sudah dikirim instance lain
+			}
+
+			isi, jumlah := susunRingkasanHarian(u, now, overdue)
+			if jumlah == 0 {
+				return nil
+			}
+			notification = &models.Notification{
+				Title:    fmt.Sprintf("Ringkasan harian %s: %d item", tanggal, jumlah),
+				Start:    now,
+				Category: kategoriRingkasanHarian,
+				UserID:   &u.ID,
+				Isi:      &isi,
+			}
+			if err := tx.Create(notification).Error; err != nil {
+				return err
+			}
+			return tx.Model(&tanda).Updates(map[string]interface{}{"jumlah": jumlah, "notification_id": notification.ID}).Error
+		})
+		if err != nil {
+			log.Printf("Error sending digest for %s: %v", u.Username, err)
+			continue
+		}
+		if notification != nil {
+			antreKanalNotifikasi(*notification)
+		}
+	}
+}
+
+// susunRingkasanHarian merangkum jadwal hari ini, task lewat target, persetujuan yang menunggu,
+// dan notifikasi yang ditahan 24 jam terakhir
+func susunRingkasanHarian(u models.User, now time.Time, overdue []meetingOverdue) (string, int) {
+	loc := jakartaLocation()
+	hariIni := truncateHariKetersediaan(now, loc)
+	var bagian []string
+	jumlah := 0
+	tulis := func(judul string, baris []string) {
+		if len(baris) == 0 {
+			return
+		}
+		jumlah += len(baris)
+		bagian = append(bagian, judul+"\n- "+strings.Join(baris, "\n- "))
+	}
+
+	// Booking ruang, meeting, cuti dan perdin hari ini; hanya sumber yang tertaut ke pengguna (lihat hitungKetersediaan)
+	var jadwal []string
+	if hasil := hitungKetersediaan([]models.User{u}, hariIni, hariIni.AddDate(0, 0, 1), u.ID, false); len(hasil) > 0 {
+		for _, b := range hasil[0].Blok {
+			jadwal = append(jadwal, ringkasBlok(b))
+		}
+	}
+	tulis("Jadwal hari ini", jadwal)
+
+	// Task meeting dengan PIC pengguna yang lewat target
+	var task []string
+	for _, item := range overdue {
+		if strings.EqualFold(strings.TrimSpace(derefString(item.Meeting.Pic)), u.Username) {
+			task = append(task, fmt.Sprintf("%s (target %s, lewat %d hari)", derefString(item.Meeting.Task), item.Meeting.TanggalTarget.Format("2006-01-02"), item.HariTerlambat))
+		}
+	}
+	tulis("Task lewat target", task)
+
+	// Persetujuan yang menunggu pengguna sebagai atasan; pengajuan tanpa atasan diputus admin
+	admin := u.Role == roleAdmin
+	var persetujuan []string
+	var cuti []models.PengajuanCuti
+	initializers.DB.Where("status = ? AND atasan_id = ?", statusCutiDiajukan, u.ID).Order("tanggal_mulai").Find(&cuti)
+	for _, p := range cuti {
+		persetujuan = append(persetujuan, fmt.Sprintf("Cuti %s %s s/d %s (%d hari)", p.Pemohon, models.FormatTanggal(p.TanggalMulai, "2006-01-02"), models.FormatTanggal(p.TanggalSelesai, "2006-01-02"), p.JumlahHari))
+	}
+	var penyelesaian []models.PenyelesaianPerdin
+	qPenyelesaian := initializers.DB.Where("status = ?", statusPenyelesaianDiajukan)
+	if admin {
+		qPenyelesaian = qPenyelesaian.Where("atasan_id = ? OR atasan_id IS NULL", u.ID)
+	} else {
+		qPenyelesaian = qPenyelesaian.Where("atasan_id = ?", u.ID)
+	}
+	qPenyelesaian.Order("tanggal_pengajuan").Find(&penyelesaian)
+	for _, p := range penyelesaian {
+		var perdin models.Perdin
+		initializers.DB.First(&perdin, p.PerdinID)
+		persetujuan = append(persetujuan, fmt.Sprintf("Penyelesaian perdin %s oleh %s", derefString(perdin.NoPerdin), derefString(p.DiajukanOleh)))
+	}
+	if admin {
+		var peminjaman []models.PeminjamanArsip
+		initializers.DB.Where("status = ?", statusPinjamDiajukan).Order("created_at").Find(&peminjaman)
+		for _, p := range peminjaman {
+			var arsip models.Arsip
+			initializers.DB.First(&arsip, p.ArsipID)
+			persetujuan = append(persetujuan, fmt.Sprintf("Peminjaman arsip %s oleh %s", derefString(arsip.NoArsip), p.Peminjam))
+		}
+		var pemusnahan []models.PemusnahanArsip
+		initializers.DB.Where("status = ?", statusPemusnahanDiajukan).Order("created_at").Find(&pemusnahan)
+		for _, p := range pemusnahan {
+			persetujuan = append(persetujuan, fmt.Sprintf("Usulan pemusnahan arsip #%d oleh %s", p.ID, p.DiajukanOleh))
+		}
+	}
+	tulis("Menunggu persetujuan Anda", persetujuan)
+
+	// Notifikasi 24 jam terakhir yang kategorinya ditahan ke ringkasan
+	prefs := muatPreferensiUser(initializers.DB, u.ID)
+	var tertahan []string
+	var notifikasi []models.Notification
+	initializers.DB.
+		Where("(user_id IS NULL AND role IS NULL) OR user_id = ? OR role = ?", u.ID, u.Role).
+		Where("created_at >= ? AND category <> ?", now.Add(-24*time.Hour), kategoriRingkasanHarian).
+		Order("created_at").Find(&notifikasi)
+	for _, n := range notifikasi {
+		if !prefs.adaRingkasan(n.Category) {
+			continue
+		}
+		if len(tertahan) == maksItemTertahan {
+			tertahan = append(tertahan, "dan lainnya, lihat daftar notifikasi")
+			break
+		}
+		tertahan = append(tertahan, fmt.Sprintf("[%s] %s", templateEmailUntuk(n.Category).Label, n.Title))
+	}
+	tulis("Notifikasi tertahan", tertahan)
+
+	return strings.Join(bagian, "\n\n"), jumlah
+}
+
+// PratinjauRingkasanHarian menampilkan isi ringkasan harian pengguna yang login tanpa mengirimnya
+func PratinjauRingkasanHarian(c *gin.Context) {
+	var user models.User
+	if err := initializers.DB.First(&user, c.MustGet("userID").(uint)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User tidak ditemukan"})
+		return
+	}
+	now := time.Now().In(jakartaLocation())
+	overdue, _ := findMeetingOverdue(now)
+	isi, jumlah := susunRingkasanHarian(user, now, overdue)
+	c.JSON(http.StatusOK, gin.H{"isi": isi, "jumlah": jumlah})
+}
@@ -13,30 +13,33 @@ import (
 	"strconv"
 	"strings"
 	"time"
-	"unicode"
 
 	"github.com/gin-gonic/gin"
 	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
 )
 
 type ProjectRequest struct {
-	ID              uint    `gorm:"primaryKey"`
-	KodeProject     *string `json:"kode_project"`
-	JenisPengadaan  *string `json:"jenis_pengadaan"`
-	NamaPengadaan   *string `json:"nama_pengadaan"`
-	DivInisiasi     *string `json:"div_inisiasi"`
-	Bulan           *string `json:"bulan"`
-	SumberPendanaan *string `json:"sumber_pendanaan"`
-	Anggaran        *string `json:"anggaran"`
-	NoIzin          *string `json:"no_izin"`
-	TanggalIzin     *string `json:"tanggal_izin"`
-	TanggalTor      *string `json:"tanggal_tor"`
-	Pic             *string `json:"pic"`
-	CreateBy        string  `json:"create_by"`
-	Group           *string `json:"group"`
-	InfraType       *string `json:"infra_type"`
-	BudgetType      *string `json:"budget_type"`
-	Type            *string `json:"type"`
+	ID              uint           `gorm:"primaryKey"`
+	KodeProject     *string        `json:"kode_project"`
+	JenisPengadaan  *string        `json:"jenis_pengadaan"`
+	NamaPengadaan   *string        `json:"nama_pengadaan"`
+	DivInisiasi     *string        `json:"div_inisiasi"`
+	Bulan           *string        `json:"bulan"`
+	SumberPendanaan *string        `json:"sumber_pendanaan"`
+	Anggaran        *models.Amount `json:"anggaran"`
+	MataUang        *string        `json:"mata_uang"`
+	NoIzin          *string        `json:"no_izin"`
+	TanggalIzin     *string        `json:"tanggal_izin"`
+	TanggalTor      *string        `json:"tanggal_tor"`
+	Pic             *string        `json:"pic"`
+	CreateBy        string         `json:"create_by"`
+	Group           *string        `json:"group"`
+	InfraType       *string        `json:"infra_type"`
+	BudgetType      *string        `json:"budget_type"`
+	Type            *string        `json:"type"`
+	BuatTimeline    *bool          `json:"buat_timeline"`      // buat resource timeline untuk project baru
+	ParentResource  *uint          `json:"parent_resource_id"` // induk resource timeline, opsional
 }
 
 func UploadHandlerProject(c *gin.Context) {
@@ -245,6 +248,7 @@ func ProjectCreate(c *gin.Context) {
 		Bulan:           bulan,
 		SumberPendanaan: requestBody.SumberPendanaan,
 		Anggaran:        requestBody.Anggaran,
+		MataUang:        derefString(requestBody.MataUang),
 		NoIzin:          requestBody.NoIzin,
 		TanggalIzin:     tanggal_izin,
 		TanggalTor:      tanggal_tor,
@@ -258,6 +262,21 @@ func ProjectCreate(c *gin.Context) {
 		return
 	}
 
+	// Siapkan tahapan pengadaan sesuai jenis pengadaan
+	if err := generateTahapProject(initializers.DB, &project, requestBody.CreateBy); err != nil {
+		log.Printf("Error generating tahap pengadaan for project %d: %v", project.ID, err)
+	}
+
+	if requestBody.BuatTimeline != nil && *requestBody.BuatTimeline {
+		var parentID uint
+		if requestBody.ParentResource != nil {
+			parentID = *requestBody.ParentResource
+		}
+		if _, err := buatResourceProject(initializers.DB, project, parentID); err != nil {
+			log.Printf("Error creating timeline resource for project %d: %v", project.ID, err)
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{"project": project})
 }
 
@@ -265,7 +284,11 @@ func ProjectIndex(c *gin.Context) {
 
 	// Get models from DB
 	var project []models.Project
-	initializers.DB.Find(&project)
+	query := initializers.DB
+	if c.Query("arsip") != "true" {
+		query = query.Where("diarsipkan = ?", false)
+	}
+	query.Find(&project)
 
 	//Respond with them
 	c.JSON(200, gin.H{
@@ -281,10 +304,15 @@ func ProjectShow(c *gin.Context) {
 	var project models.Project
 
 	initializers.DB.First(&project, id)
+	resources, events := timelineProject(project.ID)
 
 	//Respond with them
 	c.JSON(200, gin.H{
 		"project": project,
+		"timeline": gin.H{
+			"resources": resources,
+			"events":    events,
+		},
 	})
 }
 
@@ -360,6 +388,9 @@ func ProjectUpdate(c *gin.Context) {
 	if requestBody.Anggaran != nil {
 		project.Anggaran = requestBody.Anggaran
 	}
+	if requestBody.MataUang != nil && *requestBody.MataUang != "" {
+		project.MataUang = *requestBody.MataUang
+	}
 	if requestBody.NoIzin != nil {
 		project.NoIzin = requestBody.NoIzin
 	}
@@ -368,12 +399,27 @@ func ProjectUpdate(c *gin.Context) {
 	}
 	project.CreateBy = c.MustGet("username").(string)
 
-	// Save changes
-	if err := initializers.DB.Save(&project).Error; err != nil {
+	// Save changes, tahap TOR dan izin ikut tanggal yang baru
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&project).Error; err != nil {
+			return err
+		}
+		return syncTahapDariProject(tx, &project, project.CreateBy)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update project"})
 		return
 	}
 
+	// Nama resource utama timeline mengikuti nama pengadaan. Resource utama bisa berada di bawah grup lain
+	// (parent_id bukan 0), jadi yang diganti adalah resource project yang induknya bukan milik project ini.
+	if requestBody.NamaPengadaan != nil && *requestBody.NamaPengadaan != "" {
+		initializers.DB.Model(&models.ResourceProject{}).
+			Where("project_id = ? AND parent_id NOT IN (?)", project.ID,
+				initializers.DB.Model(&models.ResourceProject{}).Select("id").Where("project_id = ?", project.ID)).
+			Update("name", *requestBody.NamaPengadaan)
+	}
+
 	c.JSON(http.StatusOK, gin.H{"project": project})
 }
 
@@ -389,7 +435,23 @@ func ProjectDelete(c *gin.Context) {
 		return
 	}
 
-	if err := initializers.DB.Delete(&project).Error; err != nil {
+	// Hapus juga rincian anggaran, realisasi, tahap pengadaan dan timeline-nya dalam satu transaksi
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("project_id = ?", project.ID).Delete(&models.RealisasiProject{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("project_id = ?", project.ID).Delete(&models.AnggaranProject{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("project_id = ?", project.ID).Delete(&models.TahapProject{}).Error; err != nil {
+			return err
+		}
+		if err := hapusTimelineProject(tx, project.ID); err != nil {
+			return err
+		}
+		return tx.Delete(&project).Error
+	})
+	if err != nil {
 		c.JSON(400, gin.H{"error": "Failed to delete project: " + err.Error()})
 		return
 	}
@@ -455,7 +517,7 @@ func CreateExcelProject(c *gin.Context) {
 		f.SetCellValue(projectSheetName, fmt.Sprintf("D%d", rowNum), project.DivInisiasi)
 		f.SetCellValue(projectSheetName, fmt.Sprintf("E%d", rowNum), bulanString) // Ensure this is the correct format
 		f.SetCellValue(projectSheetName, fmt.Sprintf("F%d", rowNum), project.SumberPendanaan)
-		f.SetCellValue(projectSheetName, fmt.Sprintf("G%d", rowNum), project.Anggaran)
+		f.SetCellValue(projectSheetName, fmt.Sprintf("G%d", rowNum), amountCellValue(project.Anggaran))
 		f.SetCellValue(projectSheetName, fmt.Sprintf("H%d", rowNum), project.NoIzin)
 		f.SetCellValue(projectSheetName, fmt.Sprintf("I%d", rowNum), izinString)
 		f.SetCellValue(projectSheetName, fmt.Sprintf("J%d", rowNum), torString)
@@ -539,7 +601,7 @@ func UpdateSheetProject(c *gin.Context) {
 		f.SetCellValue(sheetName, fmt.Sprintf("D%d", rowNum), project.DivInisiasi)
 		f.SetCellValue(sheetName, fmt.Sprintf("E%d", rowNum), bulanString) // Write month as text
 		f.SetCellValue(sheetName, fmt.Sprintf("F%d", rowNum), project.SumberPendanaan)
-		f.SetCellValue(sheetName, fmt.Sprintf("G%d", rowNum), project.Anggaran)
+		f.SetCellValue(sheetName, fmt.Sprintf("G%d", rowNum), amountCellValue(project.Anggaran))
 		f.SetCellValue(sheetName, fmt.Sprintf("H%d", rowNum), project.NoIzin)
 		f.SetCellValue(sheetName, fmt.Sprintf("I%d", rowNum), project.TanggalIzin.Format("02-01-2006"))
 		f.SetCellValue(sheetName, fmt.Sprintf("J%d", rowNum), project.TanggalTor.Format("02-01-2006"))
@@ -621,12 +683,15 @@ func ImportExcelProject(c *gin.Context) {
 			continue
 		}
 
-		// Membersihkan string anggaran dari karakter non-numerik
-		rawAnggaran := getStringOrNil(getColumn(row, 6))
-		var anggaranCleaned *string
-		if rawAnggaran != nil {
-			cleanedAnggaran := cleanNumericString(*rawAnggaran)
-			anggaranCleaned = &cleanedAnggaran
+		// Anggaran bisa ditulis "Rp 1.500.000" atau "1500000,50" di Excel
+		var anggaran *models.Amount
+		if rawAnggaran := getColumn(row, 6); rawAnggaran != "" {
+			parsed, err := models.ParseAmount(rawAnggaran)
+			if err != nil {
+				log.Printf("Row %d: anggaran %q tidak valid, dikosongkan", i+1, rawAnggaran)
+			} else {
+				anggaran = &parsed
+			}
 		}
 
 		project := models.Project{
@@ -636,7 +701,7 @@ func ImportExcelProject(c *gin.Context) {
 			DivInisiasi:     getStringOrNil(getColumn(row, 3)),
 			Bulan:           parseDateOrNil(getStringOrNil(getColumn(row, 4))),
 			SumberPendanaan: getStringOrNil(getColumn(row, 5)),
-			Anggaran:        anggaranCleaned,
+			Anggaran:        anggaran,
 			NoIzin:          getStringOrNil(getColumn(row, 7)),
 			TanggalIzin:     parseDateOrNil(getStringOrNil(getColumn(row, 8))),
 			TanggalTor:      parseDateOrNil(getStringOrNil(getColumn(row, 9))),
@@ -699,16 +764,6 @@ func parseDate(dateStr string) (time.Time, error) {
 	return time.Time{}, fmt.Errorf("no valid date format found")
 }
 
-// Fungsi untuk membersihkan string dari karakter non-numerik
-func cleanNumericString(input string) string {
-	return strings.Map(func(r rune) rune {
-		if unicode.IsDigit(r) {
-			return r
-		}
-		return -1
-	}, input)
-}
-
 // Helper function to parse date or return nil if input is nil
 func parseDateOrNil(dateStr *string) *time.Time {
 	if dateStr == nil {
@@ -803,6 +858,13 @@ func exportProjectToExcel(projects []models.Project) (*excelize.File, error) {
 		return nil, err
 	}
 
+	// Anggaran ditulis sebagai angka agar bisa dijumlah di Excel
+	styleAnggaran, err := newAnggaranStyle(f)
+	if err != nil {
+		return nil, err
+	}
+	totalAnggaran := make(map[string]models.Amount)
+
 	// Loop through projects
 	for _, project := range projects {
 		// Dereference pointers if not nil
@@ -849,23 +911,9 @@ func exportProjectToExcel(projects []models.Project) (*excelize.File, error) {
 			f.SetCellValue("PROJECT", fmt.Sprintf("E%d", rowIndex), bulan)
 			f.SetCellValue("PROJECT", fmt.Sprintf("F%d", rowIndex), sumberPendanaan)
 
+			f.SetCellValue("PROJECT", fmt.Sprintf("G%d", rowIndex), amountCellValue(project.Anggaran))
 			if project.Anggaran != nil {
-				anggaran, err := strconv.ParseInt(*project.Anggaran, 10, 64)
-				if err != nil {
-					return nil, err
-				}
-				formattedAnggaran := fmt.Sprintf("Rp. %d", anggaran)
-				f.SetCellValue("PROJECT", fmt.Sprintf("G%d", rowIndex), formattedAnggaran)
-				styleAnggaran, err := f.NewStyle(&excelize.Style{
-					NumFmt: 3,
-				})
-				if err != nil {
-					return nil, err
-				}
-				err = f.SetCellStyle("PROJECT", fmt.Sprintf("G%d", rowIndex), fmt.Sprintf("G%d", rowIndex), styleAnggaran)
-				if err != nil {
-					return nil, err
-				}
+				totalAnggaran[project.MataUang] += *project.Anggaran
 			}
 
 			f.SetCellValue("PROJECT", fmt.Sprintf("H%d", rowIndex), noIzin)
@@ -876,6 +924,10 @@ func exportProjectToExcel(projects []models.Project) (*excelize.File, error) {
 			if err != nil {
 				return nil, err
 			}
+			err = f.SetCellStyle("PROJECT", fmt.Sprintf("G%d", rowIndex), fmt.Sprintf("G%d", rowIndex), styleAnggaran)
+			if err != nil {
+				return nil, err
+			}
 			rowIndex++
 			lastRowSAG = rowIndex
 		}
@@ -890,23 +942,9 @@ func exportProjectToExcel(projects []models.Project) (*excelize.File, error) {
 			f.SetCellValue("PROJECT", fmt.Sprintf("E%d", rowISO), bulan)
 			f.SetCellValue("PROJECT", fmt.Sprintf("F%d", rowISO), sumberPendanaan)
 
+			f.SetCellValue("PROJECT", fmt.Sprintf("G%d", rowISO), amountCellValue(project.Anggaran))
 			if project.Anggaran != nil {
-				anggaran, err := strconv.ParseInt(*project.Anggaran, 10, 64)
-				if err != nil {
-					return nil, err
-				}
-				formattedAnggaran := fmt.Sprintf("Rp. %d", anggaran)
-				f.SetCellValue("PROJECT", fmt.Sprintf("G%d", rowISO), formattedAnggaran)
-				styleAnggaran, err := f.NewStyle(&excelize.Style{
-					NumFmt: 3,
-				})
-				if err != nil {
-					return nil, err
-				}
-				err = f.SetCellStyle("PROJECT", fmt.Sprintf("G%d", rowISO), fmt.Sprintf("G%d", rowISO), styleAnggaran)
-				if err != nil {
-					return nil, err
-				}
+				totalAnggaran[project.MataUang] += *project.Anggaran
 			}
 
 			f.SetCellValue("PROJECT", fmt.Sprintf("H%d", rowISO), noIzin)
@@ -917,6 +955,10 @@ func exportProjectToExcel(projects []models.Project) (*excelize.File, error) {
 			if err != nil {
 				return nil, err
 			}
+			err = f.SetCellStyle("PROJECT", fmt.Sprintf("G%d", rowISO), fmt.Sprintf("G%d", rowISO), styleAnggaran)
+			if err != nil {
+				return nil, err
+			}
 			rowIndex++
 		}
 	}
@@ -947,6 +989,10 @@ func exportProjectToExcel(projects []models.Project) (*excelize.File, error) {
 		return nil, err
 	}
 
+	if err := writeTotalAnggaran(f, "PROJECT", rowIndex+2, totalAnggaran, styleHeader, styleAnggaran); err != nil {
+		return nil, err
+	}
+
 	// Set column widths after all data is filled
 	f.SetColWidth("PROJECT", "A", "A", 30)
 	f.SetColWidth("PROJECT", "B", "B", 15)
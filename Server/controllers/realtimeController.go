@@ -0,0 +1,462 @@
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"project-its/initializers"
+	"project-its/models"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// kanal NOTIFY yang didengar semua instance server
+const kanalRealtime = "realtime_events"
+
+const (
+	tipeRealtimeNotifikasi = "notifikasi"
+	tipeRealtimeRecord     = "record"
+	tipeRealtimeSinkron    = "sinkron" // koneksi LISTEN sempat putus, client sebaiknya memuat ulang data
+
+	aksiRealtimeDibuat  = "dibuat"
+	aksiRealtimeDiubah  = "diubah"
+	aksiRealtimeDihapus = "dihapus"
+
+	// batas payload NOTIFY PostgreSQL 8000 byte, sisakan ruang
+	maksPayloadRealtime = 7000
+	// insert batch besar (mis. import excel) cukup dikirim sebagai satu event tanpa id
+	maksIDRealtime = 50
+)
+
+// tabel antrean/internal yang perubahannya tidak perlu disiarkan
+var tabelTanpaRealtime = map[string]bool{
+	"users":                 true,
+	"user_tokens":           true,
+	"status_notifikasis":    true,
+	"pengingat_notifikasis": true,
+	"email_keluars":         true,
+	"webhooks":              true,
+	"pengiriman_webhooks":   true,
+	"feed_kalenders":        true,
+	"import_kalenders":      true,
+	"ringkasan_harians":     true,
+}
+
+// tabel yang perubahannya hanya disiarkan ke admin dan pengguna yang terkait dengan record-nya.
+// Query menerima @ids (id record yang berubah) dan mengembalikan id user penerima: pemilik/pengaju,
+// atasan yang memutuskan, dan penerima disposisi.
+var tabelRealtimePribadi = map[string]string{
+	"pengajuan_cutis": `SELECT user_id FROM pengajuan_cutis WHERE id IN @ids
+		UNION SELECT atasan_id FROM pengajuan_cutis WHERE id IN @ids`,
+	"kuota_cutis": `SELECT k.user_id FROM kuota_cutis k WHERE k.id IN @ids
+		UNION SELECT u.atasan_id FROM kuota_cutis k JOIN users u ON u.id = k.user_id WHERE k.id IN @ids`,
+	"disposisis": `SELECT d.tujuan_user_id FROM disposisis d WHERE d.id IN @ids
+		UNION SELECT u.id FROM disposisis d JOIN users u ON LOWER(TRIM(u.div)) = LOWER(TRIM(d.tujuan_div)) AND u.deleted_at IS NULL WHERE d.id IN @ids
+		UNION SELECT u.id FROM disposisis d JOIN users u ON u.username = d.dari AND u.deleted_at IS NULL WHERE d.id IN @ids`,
+	"peminjaman_arsips": `SELECT p.peminjam_user_id FROM peminjaman_arsips p WHERE p.id IN @ids
+		UNION SELECT u.id FROM peminjaman_arsips p JOIN users u ON u.username = p.peminjam AND u.deleted_at IS NULL WHERE p.id IN @ids`,
+	"penyelesaian_perdins": `SELECT p.pengaju_id FROM penyelesaian_perdins p WHERE p.id IN @ids
+		UNION SELECT p.atasan_id FROM penyelesaian_perdins p WHERE p.id IN @ids
+		UNION SELECT ps.user_id FROM penyelesaian_perdins p JOIN perdin_pesertas ps ON ps.perdin_id = p.perdin_id WHERE p.id IN @ids`,
+	"realisasi_perdins": `SELECT p.pengaju_id FROM realisasi_perdins r JOIN penyelesaian_perdins p ON p.id = r.penyelesaian_id WHERE r.id IN @ids
+		UNION SELECT p.atasan_id FROM realisasi_perdins r JOIN penyelesaian_perdins p ON p.id = r.penyelesaian_id WHERE r.id IN @ids
+		UNION SELECT ps.user_id FROM realisasi_perdins r JOIN perdin_pesertas ps ON ps.perdin_id = r.perdin_id WHERE r.id IN @ids`,
+	"pemusnahan_arsips": `SELECT u.id FROM pemusnahan_arsips p JOIN users u ON u.username = p.diajukan_oleh AND u.deleted_at IS NULL WHERE p.id IN @ids`,
+	"pemusnahan_arsip_items": `SELECT u.id FROM pemusnahan_arsip_items i JOIN pemusnahan_arsips p ON p.id = i.pemusnahan_id
+		JOIN users u ON u.username = p.diajukan_oleh AND u.deleted_at IS NULL WHERE i.id IN @ids`,
+	"preferensi_notifikasis": `SELECT user_id FROM preferensi_notifikasis WHERE id IN @ids`,
+}
+
+// eventRealtime dikirim lewat NOTIFY lalu diteruskan ke client SSE. UserIDs/Role membatasi penerima
+// dan tidak ikut dikirim ke client.
+type eventRealtime struct {
+	Tipe         string               `json:"tipe"`
+	Modul        string               `json:"modul,omitempty"`
+	Aksi         string               `json:"aksi,omitempty"`
+	IDs          []interface{}        `json:"ids,omitempty"`
+	Jumlah       int64                `json:"jumlah,omitempty"`
+	Notification *models.Notification `json:"notification,omitempty"`
+	Waktu        time.Time            `json:"waktu"`
+	UserIDs      []uint               `json:"user_ids,omitempty"`
+	Role         *string              `json:"role,omitempty"`
+}
+
+type klienRealtime struct {
+	userID uint
+	role   string
+	modul  map[string]bool // kosong berarti semua modul
+	pref   preferensiUser  // dimuat saat client tersambung
+	kirim  chan eventRealtime
+}
+
+// boleh menerapkan aturan yang sama dengan inbox: ke pengguna tertentu atau role tertentu, atau ke semua
+// pengguna bila keduanya kosong
+func (k *klienRealtime) boleh(ev eventRealtime) bool {
+	if len(k.modul) > 0 && ev.Tipe == tipeRealtimeRecord && !k.modul[ev.Modul] {
+		return false
+	}
+	if ev.Notification != nil && k.pref.mode(kanalInApp, ev.Notification.Category) != modeLangsung {
+		return false
+	}
+	if len(ev.UserIDs) == 0 && ev.Role == nil {
+		return true
+	}
+	for _, id := range ev.UserIDs {
+		if id == k.userID {
+			return true
+		}
+	}
+	return ev.Role != nil && *ev.Role == k.role
+}
+
+type hubRealtime struct {
+	mu    sync.RWMutex
+	klien map[*klienRealtime]struct{}
+}
+
+var realtimeHub = &hubRealtime{klien: map[*klienRealtime]struct{}{}}
+
+func (h *hubRealtime) daftar(k *klienRealtime) {
+	h.mu.Lock()
+	h.klien[k] = struct{}{}
+	h.mu.Unlock()
+}
+
+func (h *hubRealtime) hapus(k *klienRealtime) {
+	h.mu.Lock()
+	delete(h.klien, k)
+	h.mu.Unlock()
+}
+
+// siarkan meneruskan event ke client lokal; client yang antreannya penuh dilewati agar tidak menahan yang lain
+func (h *hubRealtime) siarkan(ev eventRealtime) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for k := range h.klien {
+		if !k.boleh(ev) {
+			continue
+		}
+		select {
+		case k.kirim <- ev:
+		default:
+			log.Printf("Realtime client %d lambat, event %s %s dilewati", k.userID, ev.Modul, ev.Aksi)
+		}
+	}
+}
+
+// StartRealtime mendaftarkan callback GORM yang menerbitkan event dan menjalankan listener NOTIFY, dipanggil sekali dari main
+func StartRealtime() {
+	daftarkanCallbackRealtime(initializers.DB)
+	go dengarkanRealtime()
+}
+
+func daftarkanCallbackRealtime(db *gorm.DB) {
+	cb := db.Callback()
+	if err := cb.Create().After("gorm:create").Register("realtime:create", callbackRealtime(aksiRealtimeDibuat)); err != nil {
+		log.Printf("Error registering realtime callback: %v", err)
+	}
+	if err := cb.Update().After("gorm:update").Register("realtime:update", callbackRealtime(aksiRealtimeDiubah)); err != nil {
+		log.Printf("Error registering realtime callback: %v", err)
+	}
+	if err := cb.Delete().After("gorm:delete").Register("realtime:delete", callbackRealtime(aksiRealtimeDihapus)); err != nil {
+		log.Printf("Error registering realtime callback: %v", err)
+	}
+}
+
+// callbackRealtime menerbitkan NOTIFY di koneksi/transaksi yang sama dengan perubahan data,
+// sehingga event hanya sampai ke listener bila transaksinya commit
+func callbackRealtime(aksi string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Error != nil || db.Statement.Schema == nil || db.RowsAffected == 0 {
+			return
+		}
+		tabel := db.Statement.Schema.Table
+		if tabelTanpaRealtime[tabel] {
+			return
+		}
+
+		tx := db.Session(&gorm.Session{NewDB: true, SkipHooks: true})
+		var events []eventRealtime
+		if tabel == "notifications" && aksi == aksiRealtimeDibuat {
+			events = eventNotifikasiRealtime(db)
+		} else {
+			ev := eventRealtime{Tipe: tipeRealtimeRecord, Modul: tabel, Aksi: aksi, Waktu: time.Now()}
+			ev.IDs = idRecordRealtime(db)
+			if len(ev.IDs) > maksIDRealtime {
+				ev.IDs = nil
+			}
+			if len(ev.IDs) == 0 {
+				ev.Jumlah = db.RowsAffected
+			}
+			if query, ok := tabelRealtimePribadi[tabel]; ok {
+				// Admin selalu menerima; tanpa id (insert batch besar) penerima lain tidak bisa ditentukan
+				role := roleAdmin
+				ev.Role = &role
+				ev.UserIDs = audiensRecordRealtime(db)
+				if len(ev.IDs) > 0 {
+					var ids []uint
+					if err := tx.Raw("SELECT DISTINCT id FROM ("+query+") a(id) WHERE id IS NOT NULL", sql.Named("ids", ev.IDs)).Scan(&ids).Error; err != nil {
+						log.Printf("Error resolving realtime audience %s: %v", tabel, err)
+					}
+					ev.UserIDs = append(ev.UserIDs, ids...)
+				}
+			}
+			events = append(events, ev)
+		}
+
+		for _, ev := range events {
+			payload, err := json.Marshal(ev)
+			if err != nil || len(payload) > maksPayloadRealtime {
+				log.Printf("Realtime event %s %s dilewati: payload tidak valid atau terlalu besar", ev.Modul, ev.Aksi)
+				continue
+			}
+			if err := tx.Exec("SELECT pg_notify(?, ?)", kanalRealtime, string(payload)).Error; err != nil {
+				log.Printf("Error publishing realtime event: %v", err)
+			}
+		}
+	}
+}
+
+func eventNotifikasiRealtime(db *gorm.DB) []eventRealtime {
+	var events []eventRealtime
+	tambah := func(n models.Notification) {
+		if len(n.Title) > 1000 {
+			n.Title = n.Title[:1000]
+		}
+		n.Isi = nil // isi panjang diambil client dari /notifications
+		ev := eventRealtime{Tipe: tipeRealtimeNotifikasi, Modul: "notifications", Aksi: aksiRealtimeDibuat, Notification: &n, Waktu: time.Now(), Role: n.Role}
+		if n.UserID != nil {
+			ev.UserIDs = []uint{*n.UserID}
+		}
+		events = append(events, ev)
+	}
+	switch v := db.Statement.Dest.(type) {
+	case *models.Notification:
+		tambah(*v)
+	case []models.Notification:
+		for _, n := range v {
+			tambah(n)
+		}
+	case *[]models.Notification:
+		for _, n := range *v {
+			tambah(n)
+		}
+	}
+	return events
+}
+
+// idRecordRealtime mengambil primary key dari model yang disimpan, atau dari kondisi WHERE bila
+// perubahan dilakukan lewat Where("id = ?", id)
+func idRecordRealtime(db *gorm.DB) []interface{} {
+	pf := db.Statement.Schema.PrioritizedPrimaryField
+	if pf == nil {
+		return nil
+	}
+
+	var ids []interface{}
+	ambil := func(rv reflect.Value) {
+		rv = reflect.Indirect(rv)
+		if rv.Kind() != reflect.Struct {
+			return
+		}
+		if v, zero := pf.ValueOf(db.Statement.Context, rv); !zero {
+			ids = append(ids, v)
+		}
+	}
+	rv := db.Statement.ReflectValue
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			ambil(rv.Index(i))
+		}
+	case reflect.Struct:
+		ambil(rv)
+	}
+	if len(ids) > 0 {
+		return ids
+	}
+
+	c, ok := db.Statement.Clauses["WHERE"]
+	if !ok {
+		return nil
+	}
+	where, ok := c.Expression.(clause.Where)
+	if !ok {
+		return nil
+	}
+	kolomPK := func(col interface{}) bool {
+		switch x := col.(type) {
+		case clause.Column:
+			return x.Name == clause.PrimaryKey || x.Name == pf.DBName
+		case string:
+			return x == pf.DBName
+		}
+		return false
+	}
+	for _, e := range where.Exprs {
+		switch x := e.(type) {
+		case clause.Eq:
+			if kolomPK(x.Column) {
+				ids = append(ids, x.Value)
+			}
+		case clause.IN:
+			if kolomPK(x.Column) {
+				ids = append(ids, x.Values...)
+			}
+		case clause.Expr:
+			sql := strings.ToLower(strings.ReplaceAll(x.SQL, " ", ""))
+			sql = sql[strings.LastIndex(sql, ".")+1:]
+			if len(x.Vars) != 1 || (sql != pf.DBName+"=?" && sql != pf.DBName+"in?") {
+				continue
+			}
+			if v := reflect.ValueOf(x.Vars[0]); v.Kind() == reflect.Slice {
+				for i := 0; i < v.Len(); i++ {
+					ids = append(ids, v.Index(i).Interface())
+				}
+			} else {
+				ids = append(ids, x.Vars[0])
+			}
+		}
+	}
+	return ids
+}
+
+// audiensRecordRealtime mengambil id user terkait langsung dari model yang disimpan; dipakai juga untuk
+// record yang sudah dihapus sehingga tidak bisa lagi dicari lewat query tabelRealtimePribadi
+func audiensRecordRealtime(db *gorm.DB) []uint {
+	var audiens []uint
+	ambil := func(rv reflect.Value) {
+		rv = reflect.Indirect(rv)
+		if rv.Kind() != reflect.Struct {
+			return
+		}
+		for _, nama := range []string{"UserID", "AtasanID", "PengajuID", "PeminjamUserID", "TujuanUserID"} {
+			f := rv.FieldByName(nama)
+			if !f.IsValid() {
+				continue
+			}
+			if f.Kind() == reflect.Ptr {
+				if f.IsNil() {
+					continue
+				}
+				f = f.Elem()
+			}
+			if f.Kind() == reflect.Uint && f.Uint() != 0 {
+				audiens = append(audiens, uint(f.Uint()))
+			}
+		}
+	}
+	rv := db.Statement.ReflectValue
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			ambil(rv.Index(i))
+		}
+	case reflect.Struct:
+		ambil(rv)
+	}
+	return audiens
+}
+
+// dengarkanRealtime menjaga satu koneksi LISTEN per instance dan menyambung ulang bila putus
+func dengarkanRealtime() {
+	jeda := time.Second
+	pernahTersambung := false
+	for {
+		err := dengarkanRealtimeSekali(func() {
+			jeda = time.Second
+			if pernahTersambung {
+				realtimeHub.siarkan(eventRealtime{Tipe: tipeRealtimeSinkron, Waktu: time.Now()})
+			}
+			pernahTersambung = true
+		})
+		log.Printf("Realtime listener terputus: %v, menyambung ulang dalam %s", err, jeda)
+		time.Sleep(jeda)
+		if jeda < time.Minute {
+			jeda *= 2
+		}
+	}
+}
+
+func dengarkanRealtimeSekali(tersambung func()) error {
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, os.Getenv("DB_URL"))
+	if err != nil {
+		return err
+	}
+	defer conn.Close(ctx)
+
+	if _, err := conn.Exec(ctx, "LISTEN "+kanalRealtime); err != nil {
+		return err
+	}
+	tersambung()
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		var ev eventRealtime
+		if err := json.Unmarshal([]byte(n.Payload), &ev); err != nil {
+			log.Printf("Realtime payload tidak valid: %v", err)
+			continue
+		}
+		realtimeHub.siarkan(ev)
+	}
+}
+
+// StreamRealtime adalah endpoint Server-Sent Events. Client dapat membatasi modul dengan ?modul=memos,booking_rapats.
+// Event: "notifikasi", "record", "sinkron", dan "ping" setiap 25 detik.
+func StreamRealtime(c *gin.Context) {
+	role, _ := c.Get("role")
+	roleStr, _ := role.(string)
+	k := &klienRealtime{
+		userID: c.MustGet("userID").(uint),
+		role:   roleStr,
+		modul:  map[string]bool{},
+		kirim:  make(chan eventRealtime, 64),
+	}
+	k.pref = muatPreferensiUser(initializers.DB, k.userID)
+	for _, m := range strings.Split(c.Query("modul"), ",") {
+		if m = strings.TrimSpace(m); m != "" {
+			k.modul[m] = true
+		}
+	}
+	realtimeHub.daftar(k)
+	defer realtimeHub.hapus(k)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	detak := time.NewTicker(25 * time.Second)
+	defer detak.Stop()
+
+	c.SSEvent("siap", gin.H{"user_id": k.userID})
+	c.Writer.Flush()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case ev := <-k.kirim:
+			ev.UserIDs = nil
+			ev.Role = nil
+			c.SSEvent(ev.Tipe, ev)
+			return true
+		case <-detak.C:
+			c.SSEvent("ping", time.Now().Unix())
+			return true
+		}
+	})
+}
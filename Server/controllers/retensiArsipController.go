@@ -0,0 +1,548 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"project-its/initializers"
+	"project-its/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	statusRetensiAktif    = "Aktif"
+	statusRetensiInaktif  = "Inaktif"
+	statusRetensiPermanen = "Permanen"
+	statusRetensiMusnah   = "Musnah"
+
+	nasibMusnah   = "Musnah"
+	nasibPermanen = "Permanen"
+
+	statusPemusnahanDiajukan  = "Diajukan"
+	statusPemusnahanDisetujui = "Disetujui"
+	statusPemusnahanDitolak   = "Ditolak"
+)
+
+type RetensiArsipRequest struct {
+	JenisDokumen   *string `json:"jenis_dokumen"`
+	RetensiAktif   *int    `json:"retensi_aktif"`
+	RetensiInaktif *int    `json:"retensi_inaktif"`
+	Nasib          *string `json:"nasib"`
+	Keterangan     *string `json:"keterangan"`
+}
+
+type prosesRetensiRequest struct {
+	ArsipIDs []uint `json:"arsip_ids"`
+	Status   string `json:"status"`
+}
+
+type PemusnahanArsipRequest struct {
+	ArsipIDs []uint  `json:"arsip_ids"`
+	Perihal  *string `json:"perihal"`
+	Catatan  *string `json:"catatan"`
+}
+
+type persetujuanPemusnahanRequest struct {
+	Kategori *string `json:"kategori"` // kategori penomoran berita acara, mis. ITS-SAG
+	Tanggal  *string `json:"tanggal"`
+	Pic      *string `json:"pic"`
+	Catatan  *string `json:"catatan"`
+}
+
+type arsipJatuhTempo struct {
+	Arsip        *models.Arsip `json:"arsip"`
+	AkhirAktif   string        `json:"akhir_aktif"`
+	AkhirInaktif string        `json:"akhir_inaktif"`
+	Nasib        string        `json:"nasib"`
+	Tindakan     string        `json:"tindakan"`
+}
+
+type daftarJatuhTempo struct {
+	PindahInaktif []arsipJatuhTempo `json:"pindah_inaktif"`
+	Musnah        []arsipJatuhTempo `json:"musnah"`
+	Permanen      []arsipJatuhTempo `json:"permanen"`
+}
+
+func RetensiArsipIndex(c *gin.Context) {
+	var retensi []models.RetensiArsip
+	if err := initializers.DB.Order("jenis_dokumen asc").Find(&retensi).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"retensi": retensi})
+}
+
+func RetensiArsipCreate(c *gin.Context) {
+	var requestBody RetensiArsipRequest
+	if err := c.BindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+	if requestBody.JenisDokumen == nil || *requestBody.JenisDokumen == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "jenis_dokumen harus diisi"})
+		return
+	}
+
+	retensi := models.RetensiArsip{JenisDokumen: *requestBody.JenisDokumen, Nasib: nasibMusnah}
+	if msg := applyRetensiRequest(&retensi, requestBody); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	if err := initializers.DB.Create(&retensi).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan retensi, jenis dokumen mungkin sudah ada"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"retensi": retensi})
+}
+
+func RetensiArsipUpdate(c *gin.Context) {
+	var requestBody RetensiArsipRequest
+	if err := c.BindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+
+	var retensi models.RetensiArsip
+	if err := initializers.DB.First(&retensi, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Retensi tidak ditemukan"})
+		return
+	}
+
+	if requestBody.JenisDokumen != nil && *requestBody.JenisDokumen != "" {
+		retensi.JenisDokumen = *requestBody.JenisDokumen
+	}
+	if msg := applyRetensiRequest(&retensi, requestBody); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	if err := initializers.DB.Save(&retensi).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan retensi"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"retensi": retensi})
+}
+
+func RetensiArsipDelete(c *gin.Context) {
+	if err := initializers.DB.Delete(&models.RetensiArsip{}, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghapus retensi"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Retensi deleted successfully"})
+}
+
+// RetensiArsipJatuhTempo menampilkan arsip yang sudah waktunya dipindah ke inaktif, dimusnahkan atau dipermanenkan
+func RetensiArsipJatuhTempo(c *gin.Context) {
+	daftar, err := findArsipJatuhTempo(time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"jatuh_tempo": daftar})
+}
+
+// RetensiArsipProses memindahkan arsip ke inaktif atau menetapkannya sebagai arsip permanen
+func RetensiArsipProses(c *gin.Context) {
+	var requestBody prosesRetensiRequest
+	if err := c.BindJSON(&requestBody); err != nil || len(requestBody.ArsipIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "arsip_ids harus diisi"})
+		return
+	}
+
+	var dariStatus []string
+	switch requestBody.Status {
+	case statusRetensiInaktif:
+		dariStatus = []string{statusRetensiAktif}
+	case statusRetensiPermanen:
+		dariStatus = []string{statusRetensiAktif, statusRetensiInaktif}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Status harus Inaktif atau Permanen, pemusnahan melalui usulan pemusnahan"})
+		return
+	}
+
+	result := initializers.DB.Model(&models.Arsip{}).
+		Where("id IN ? AND status_retensi IN ?", requestBody.ArsipIDs, dariStatus).
+		Update("status_retensi", requestBody.Status)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("%d arsip diubah menjadi %s", result.RowsAffected, requestBody.Status)})
+}
+
+func PemusnahanArsipIndex(c *gin.Context) {
+	var pemusnahan []models.PemusnahanArsip
+	if err := initializers.DB.Order("id desc").Find(&pemusnahan).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"pemusnahan": pemusnahan})
+}
+
+func PemusnahanArsipShow(c *gin.Context) {
+	var pemusnahan models.PemusnahanArsip
+	if err := initializers.DB.First(&pemusnahan, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pemusnahan tidak ditemukan"})
+		return
+	}
+
+	arsip, err := arsipPemusnahan(initializers.DB, pemusnahan.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var beritaAcara *models.BeritaAcara
+	if pemusnahan.BeritaAcaraID != nil {
+		var ba models.BeritaAcara
+		if err := initializers.DB.First(&ba, *pemusnahan.BeritaAcaraID).Error; err == nil {
+			beritaAcara = &ba
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"pemusnahan":  &pemusnahan,
+		"arsip":       arsip,
+		"beritaAcara": beritaAcara,
+	})
+}
+
+// PemusnahanArsipAjukan membuat usulan pemusnahan untuk arsip yang sudah melewati masa inaktif
+func PemusnahanArsipAjukan(c *gin.Context) {
+	var requestBody PemusnahanArsipRequest
+	if err := c.BindJSON(&requestBody); err != nil || len(requestBody.ArsipIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "arsip_ids harus diisi"})
+		return
+	}
+
+	daftar, err := findArsipJatuhTempo(time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	bolehMusnah := make(map[uint]bool)
+	for _, item := range daftar.Musnah {
+		bolehMusnah[item.Arsip.ID] = true
+	}
+
+	for _, id := range requestBody.ArsipIDs {
+		if !bolehMusnah[id] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Arsip %d belum jatuh tempo pemusnahan atau tidak berstatus musnah di JRA", id)})
+			return
+		}
+		if aktif, found := peminjamanAktif(initializers.DB, id); found {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Arsip %d sedang dipinjam oleh %s", id, aktif.Peminjam)})
+			return
+		}
+	}
+
+	var sudahDiusulkan int64
+	initializers.DB.Model(&models.PemusnahanArsipItem{}).
+		Joins("JOIN pemusnahan_arsips ON pemusnahan_arsips.id = pemusnahan_arsip_items.pemusnahan_id").
+		Where("pemusnahan_arsip_items.arsip_id IN ? AND pemusnahan_arsips.status = ?", requestBody.ArsipIDs, statusPemusnahanDiajukan).
+		Count(&sudahDiusulkan)
+	if sudahDiusulkan > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Sebagian arsip sudah ada di usulan pemusnahan lain"})
+		return
+	}
+
+	pemusnahan := models.PemusnahanArsip{
+		Perihal:      requestBody.Perihal,
+		Status:       statusPemusnahanDiajukan,
+		DiajukanOleh: c.MustGet("username").(string),
+		Catatan:      requestBody.Catatan,
+	}
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&pemusnahan).Error; err != nil {
+			return err
+		}
+		for _, id := range requestBody.ArsipIDs {
+			if err := tx.Create(&models.PemusnahanArsipItem{PemusnahanID: pemusnahan.ID, ArsipID: id}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat usulan pemusnahan"})
+		return
+	}
+
+	createNotificationRole(fmt.Sprintf("Usulan pemusnahan %d arsip oleh %s", len(requestBody.ArsipIDs), pemusnahan.DiajukanOleh), time.Now(), "PemusnahanArsip", roleAdmin)
+	c.JSON(http.StatusCreated, gin.H{"pemusnahan": &pemusnahan})
+}
+
+// PemusnahanArsipSetujui membuat berita acara pemusnahan dan menandai arsip sebagai musnah tanpa menghapusnya
+func PemusnahanArsipSetujui(c *gin.Context) {
+	var requestBody persetujuanPemusnahanRequest
+	c.ShouldBindJSON(&requestBody)
+
+	pemusnahan, ok := findPemusnahanDiajukan(c)
+	if !ok {
+		return
+	}
+	if pemusnahan.DiajukanOleh == c.MustGet("username").(string) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Usulan pemusnahan tidak dapat disetujui oleh pengusulnya sendiri"})
+		return
+	}
+
+	tanggal := time.Now()
+	if requestBody.Tanggal != nil && *requestBody.Tanggal != "" {
+		parsed, err := time.Parse("2006-01-02", *requestBody.Tanggal)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format: " + err.Error()})
+			return
+		}
+		tanggal = parsed
+	}
+
+	kategori := "ITS-SAG"
+	if requestBody.Kategori != nil && *requestBody.Kategori != "" {
+		kategori = *requestBody.Kategori
+	}
+	nomor, err := GetLatestBeritaAcaraNumber(kategori)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get latest berita acara number"})
+		return
+	}
+
+	username := c.MustGet("username").(string)
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		arsip, err := arsipPemusnahan(tx, pemusnahan.ID)
+		if err != nil {
+			return err
+		}
+
+		perihal := fmt.Sprintf("Pemusnahan %d arsip", len(arsip))
+		if pemusnahan.Perihal != nil && *pemusnahan.Perihal != "" {
+			perihal = *pemusnahan.Perihal
+		}
+		beritaAcara := models.BeritaAcara{
+			NoSurat:  &nomor,
+			Tanggal:  &tanggal,
+			Perihal:  &perihal,
+			Pic:      requestBody.Pic,
+			CreateBy: username,
+		}
+		if err := tx.Create(&beritaAcara).Error; err != nil {
+			return err
+		}
+
+		keterangan := "Dimusnahkan, berita acara " + nomor
+		for i := range arsip {
+			if err := keluarkanArsipDariBox(tx, &arsip[i], &keterangan, username); err != nil {
+				return err
+			}
+			err := tx.Model(&arsip[i]).Updates(map[string]interface{}{
+				"status_retensi": statusRetensiMusnah,
+				"pemusnahan_id":  pemusnahan.ID,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		pemusnahan.Status = statusPemusnahanDisetujui
+		pemusnahan.DisetujuiOleh = &username
+		pemusnahan.TanggalMusnah = &tanggal
+		pemusnahan.BeritaAcaraID = &beritaAcara.ID
+		if requestBody.Catatan != nil {
+			pemusnahan.Catatan = requestBody.Catatan
+		}
+		return tx.Save(&pemusnahan).Error
+	})
+	if err != nil {
+		log.Printf("Error approving pemusnahan %d: %v", pemusnahan.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyetujui pemusnahan"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"pemusnahan": &pemusnahan})
+}
+
+func PemusnahanArsipTolak(c *gin.Context) {
+	var requestBody persetujuanPemusnahanRequest
+	c.ShouldBindJSON(&requestBody)
+
+	pemusnahan, ok := findPemusnahanDiajukan(c)
+	if !ok {
+		return
+	}
+
+	username := c.MustGet("username").(string)
+	pemusnahan.Status = statusPemusnahanDitolak
+	pemusnahan.DisetujuiOleh = &username
+	pemusnahan.Catatan = requestBody.Catatan
+	if err := initializers.DB.Save(&pemusnahan).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menolak pemusnahan"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"pemusnahan": &pemusnahan})
+}
+
+func PemusnahanArsipDelete(c *gin.Context) {
+	pemusnahan, ok := findPemusnahanDiajukan(c)
+	if !ok {
+		return
+	}
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("pemusnahan_id = ?", pemusnahan.ID).Delete(&models.PemusnahanArsipItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&pemusnahan).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghapus pemusnahan"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Pemusnahan deleted successfully"})
+}
+
+// CheckRetensiArsip dijalankan berkala dan mencatat arsip yang jatuh tempo retensi ke tabel Notification
+func CheckRetensiArsip() {
+	daftar, err := findArsipJatuhTempo(time.Now())
+	if err != nil {
+		log.Printf("Error checking retensi arsip: %v", err)
+		return
+	}
+
+	ringkasan := []struct {
+		kode     string
+		jumlah   int
+		tindakan string
+	}{
+		{"inaktif", len(daftar.PindahInaktif), "perlu dipindahkan ke inaktif"},
+		{"musnah", len(daftar.Musnah), "siap diusulkan musnah"},
+		{"permanen", len(daftar.Permanen), "perlu ditetapkan permanen"},
+	}
+
+	today := time.Now().Format("2006-01-02")
+	for _, r := range ringkasan {
+		if r.jumlah == 0 {
+			continue
+		}
+		// Satu ringkasan per tindakan per hari, walaupun jumlah arsipnya berubah di tengah hari
+		role := roleAdmin
+		kunci := fmt.Sprintf("retensi-arsip-%s-%s", r.kode, today)
+		kirimNotifikasi(&models.Notification{
+			Title:     fmt.Sprintf("%d arsip %s (%s)", r.jumlah, r.tindakan, today),
+			Start:     time.Now(),
+			Category:  "RetensiArsip",
+			Role:      &role,
+			KunciUnik: &kunci,
+		})
+	}
+}
+
+// findArsipJatuhTempo menghitung masa retensi dari TanggalDokumen sesuai JRA jenis dokumennya
+func findArsipJatuhTempo(now time.Time) (daftarJatuhTempo, error) {
+	daftar := daftarJatuhTempo{
+		PindahInaktif: []arsipJatuhTempo{},
+		Musnah:        []arsipJatuhTempo{},
+		Permanen:      []arsipJatuhTempo{},
+	}
+
+	var rules []models.RetensiArsip
+	if err := initializers.DB.Find(&rules).Error; err != nil {
+		return daftar, err
+	}
+	retensiPerJenis := make(map[string]models.RetensiArsip)
+	jenis := []string{}
+	for _, r := range rules {
+		retensiPerJenis[r.JenisDokumen] = r
+		jenis = append(jenis, r.JenisDokumen)
+	}
+	if len(jenis) == 0 {
+		return daftar, nil
+	}
+
+	var arsip []models.Arsip
+	err := initializers.DB.
+		Where("tanggal_dokumen IS NOT NULL AND jenis_dokumen IN ? AND status_retensi IN ?", jenis, []string{statusRetensiAktif, statusRetensiInaktif}).
+		Order("tanggal_dokumen asc").
+		Find(&arsip).Error
+	if err != nil {
+		return daftar, err
+	}
+
+	for i := range arsip {
+		a := &arsip[i]
+		rule := retensiPerJenis[*a.JenisDokumen]
+		akhirAktif, akhirInaktif := hitungRetensi(*a.TanggalDokumen, rule)
+		item := arsipJatuhTempo{
+			Arsip:        a,
+			AkhirAktif:   akhirAktif.Format("2006-01-02"),
+			AkhirInaktif: akhirInaktif.Format("2006-01-02"),
+			Nasib:        rule.Nasib,
+		}
+
+		switch {
+		case isLewatBatas(akhirInaktif, now) && rule.Nasib == nasibPermanen:
+			item.Tindakan = "Permanen"
+			daftar.Permanen = append(daftar.Permanen, item)
+		case isLewatBatas(akhirInaktif, now):
+			item.Tindakan = "Musnah"
+			daftar.Musnah = append(daftar.Musnah, item)
+		case isLewatBatas(akhirAktif, now) && a.StatusRetensi == statusRetensiAktif:
+			item.Tindakan = "Pindah Inaktif"
+			daftar.PindahInaktif = append(daftar.PindahInaktif, item)
+		}
+	}
+	return daftar, nil
+}
+
+// hitungRetensi mengembalikan akhir masa aktif dan akhir masa inaktif
+func hitungRetensi(tanggalDokumen time.Time, rule models.RetensiArsip) (time.Time, time.Time) {
+	akhirAktif := truncateToDate(tanggalDokumen).AddDate(rule.RetensiAktif, 0, 0)
+	akhirInaktif := akhirAktif.AddDate(rule.RetensiInaktif, 0, 0)
+	return akhirAktif, akhirInaktif
+}
+
+func applyRetensiRequest(retensi *models.RetensiArsip, requestBody RetensiArsipRequest) string {
+	if requestBody.RetensiAktif != nil {
+		if *requestBody.RetensiAktif < 0 {
+			return "retensi_aktif tidak boleh negatif"
+		}
+		retensi.RetensiAktif = *requestBody.RetensiAktif
+	}
+	if requestBody.RetensiInaktif != nil {
+		if *requestBody.RetensiInaktif < 0 {
+			return "retensi_inaktif tidak boleh negatif"
+		}
+		retensi.RetensiInaktif = *requestBody.RetensiInaktif
+	}
+	if requestBody.Nasib != nil {
+		if *requestBody.Nasib != nasibMusnah && *requestBody.Nasib != nasibPermanen {
+			return "nasib harus Musnah atau Permanen"
+		}
+		retensi.Nasib = *requestBody.Nasib
+	}
+	if requestBody.Keterangan != nil {
+		retensi.Keterangan = requestBody.Keterangan
+	}
+	return ""
+}
+
+func findPemusnahanDiajukan(c *gin.Context) (models.PemusnahanArsip, bool) {
+	var pemusnahan models.PemusnahanArsip
+	if err := initializers.DB.First(&pemusnahan, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pemusnahan tidak ditemukan"})
+		return pemusnahan, false
+	}
+	if pemusnahan.Status != statusPemusnahanDiajukan {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Pemusnahan sudah " + pemusnahan.Status})
+		return pemusnahan, false
+	}
+	return pemusnahan, true
+}
+
+func arsipPemusnahan(db *gorm.DB, pemusnahanID uint) ([]models.Arsip, error) {
+	var arsip []models.Arsip
+	err := db.Where("id IN (?)", db.Model(&models.PemusnahanArsipItem{}).Select("arsip_id").Where("pemusnahan_id = ?", pemusnahanID)).
+		Order("no_arsip asc").Find(&arsip).Error
+	return arsip, err
+}
@@ -0,0 +1,693 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"project-its/initializers"
+	"project-its/models"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	sumberJadwalRapat  = "JadwalRapat"
+	sumberBookingRapat = "BookingRapat"
+
+	// batas iterasi periode agar aturan yang tidak pernah menghasilkan kejadian tidak berputar terus
+	maksPeriodeRRule = 20000
+)
+
+// aturanRRule adalah subset RFC 5545 yang dipakai kalender: FREQ, INTERVAL, COUNT, UNTIL, BYDAY, BYMONTHDAY
+type aturanRRule struct {
+	Freq       string
+	Interval   int
+	Count      int
+	Until      *time.Time
+	ByDay      []hariRRule
+	ByMonthDay []int
+}
+
+// hariRRule adalah satu nilai BYDAY, misal MO, 1MO (senin pertama) atau -1FR (jumat terakhir)
+type hariRRule struct {
+	Ordinal int
+	Weekday time.Weekday
+}
+
+// kejadianEvent adalah satu kemunculan event setelah aturan dan pengecualian diterapkan
+type kejadianEvent struct {
+	Start        time.Time
+	End          time.Time
+	RecurrenceID string
+	Title        *string
+}
+
+type pengecualianRequest struct {
+	TanggalAsli *string `json:"tanggal_asli"` // nilai recurrence_id kejadian yang diubah
+	Batal       *bool   `json:"batal"`
+	Start       *string `json:"start"`
+	End         *string `json:"end"`
+	Title       *string `json:"title"`
+}
+
+var hariRRuleMap = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// PengecualianJadwalRapatCreate melewati atau memindah satu kejadian jadwal rapat berulang
+func PengecualianJadwalRapatCreate(c *gin.Context) {
+	var event models.JadwalRapat
+	if err := initializers.DB.First(&event, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Jadwal rapat tidak ditemukan"})
+		return
+	}
+
+	pengecualian, ok := bindPengecualian(c, sumberJadwalRapat, event.ID, event.AllDay, event.Start, event.End, event.RRule)
+	if !ok {
+		return
+	}
+	if err := initializers.DB.Save(&pengecualian).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"pengecualian": pengecualian})
+}
+
+// PengecualianBookingRapatCreate sama seperti jadwal rapat, waktu pindahan ikut dicek bentrok ruang
+func PengecualianBookingRapatCreate(c *gin.Context) {
+	var event models.BookingRapat
+	if err := initializers.DB.First(&event, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Booking tidak ditemukan"})
+		return
+	}
+
+	pengecualian, ok := bindPengecualian(c, sumberBookingRapat, event.ID, event.AllDay, event.Start, event.End, event.RRule)
+	if !ok {
+		return
+	}
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if event.RuangRapatID != nil && !pengecualian.Batal {
+			startAt, endAt, err := rentangBookingRapat(event.AllDay, pengecualian.Start, pengecualian.End)
+			if err != nil {
+				return err
+			}
+			if err := cekBentrokRuangRapat(tx, *event.RuangRapatID, event.ID, []kejadianEvent{{Start: startAt, End: endAt}}, startAt, endAt); err != nil {
+				return err
+			}
+		}
+		return tx.Save(&pengecualian).Error
+	})
+	if err != nil {
+		respondBookingRapatError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"pengecualian": pengecualian})
+}
+
+func PengecualianJadwalRapatDelete(c *gin.Context) {
+	hapusPengecualian(c, sumberJadwalRapat)
+}
+
+func PengecualianBookingRapatDelete(c *gin.Context) {
+	hapusPengecualian(c, sumberBookingRapat)
+}
+
+func hapusPengecualian(c *gin.Context, sumber string) {
+	result := initializers.DB.Where("id = ? AND sumber = ? AND event_id = ?", c.Param("pid"), sumber, c.Param("id")).Delete(&models.PengecualianJadwal{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pengecualian tidak ditemukan"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// bindPengecualian memvalidasi request dan memastikan tanggal_asli memang salah satu kejadian event.
+// Pengecualian yang sudah ada untuk kejadian yang sama ditimpa.
+func bindPengecualian(c *gin.Context, sumber string, eventID uint, allDay bool, start, end, rrule string) (models.PengecualianJadwal, bool) {
+	var pengecualian models.PengecualianJadwal
+	var req pengecualianRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return pengecualian, false
+	}
+	if rrule == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Event tidak berulang"})
+		return pengecualian, false
+	}
+	if req.TanggalAsli == nil || *req.TanggalAsli == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "tanggal_asli wajib diisi"})
+		return pengecualian, false
+	}
+
+	loc := jakartaLocation()
+	asli, err := parseWaktuEvent(allDay, *req.TanggalAsli, loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format tanggal_asli tidak valid"})
+		return pengecualian, false
+	}
+	dtstart, _, err := rentangEventKalender(allDay, start, end)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return pengecualian, false
+	}
+	aturan, err := parseRRule(rrule, loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return pengecualian, false
+	}
+	semua := kejadianRRule(dtstart, aturan, asli.Add(time.Second))
+	if len(semua) == 0 || !semua[len(semua)-1].Equal(asli) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "tanggal_asli bukan salah satu kejadian event"})
+		return pengecualian, false
+	}
+
+	initializers.DB.Where("sumber = ? AND event_id = ? AND tanggal_asli = ?", sumber, eventID, asli).First(&pengecualian)
+	pengecualian.Sumber = sumber
+	pengecualian.EventID = eventID
+	pengecualian.TanggalAsli = asli
+	pengecualian.Batal = req.Batal != nil && *req.Batal
+	pengecualian.Start = ""
+	pengecualian.End = ""
+	pengecualian.Title = req.Title
+	pengecualian.CreateBy = c.MustGet("username").(string)
+
+	if !pengecualian.Batal {
+		if req.Start == nil || *req.Start == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Isi batal=true atau start/end baru untuk kejadian ini"})
+			return pengecualian, false
+		}
+		pengecualian.Start = *req.Start
+		if req.End != nil {
+			pengecualian.End = *req.End
+		}
+		if _, _, err := rentangEventKalender(allDay, pengecualian.Start, pengecualian.End); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return pengecualian, false
+		}
+	}
+	return pengecualian, true
+}
+
+// expandJadwalRapat mengganti event berulang dengan setiap kejadiannya di [dari, sampai).
+// Event sekali tetap dikembalikan apa adanya kecuali saring bernilai true.
+func expandJadwalRapat(events []models.JadwalRapat, dari, sampai time.Time, saring bool) []models.JadwalRapat {
+	var berulang []uint
+	for _, event := range events {
+		if event.RRule != "" {
+			berulang = append(berulang, event.ID)
+		}
+	}
+	pengecualian := muatPengecualian(sumberJadwalRapat, berulang)
+	hasil := []models.JadwalRapat{}
+	for _, event := range events {
+		if event.RRule == "" && !saring {
+			hasil = append(hasil, event)
+			continue
+		}
+		kejadian, err := kejadianEventKalender(event.AllDay, event.Start, event.End, event.RRule, pengecualian[event.ID], dari, sampai)
+		if err != nil {
+			// data lama dengan format waktu lain tetap ditampilkan
+			hasil = append(hasil, event)
+			continue
+		}
+		for _, k := range kejadian {
+			salinan := event
+			salinan.Start = formatWaktuEvent(event.AllDay, k.Start)
+			salinan.End = formatWaktuEvent(event.AllDay, k.End)
+			salinan.RecurrenceID = k.RecurrenceID
+			if k.Title != nil {
+				salinan.Title = *k.Title
+			}
+			hasil = append(hasil, salinan)
+		}
+	}
+	return hasil
+}
+
+func expandBookingRapat(events []models.BookingRapat, dari, sampai time.Time, saring bool) []models.BookingRapat {
+	var berulang []uint
+	for _, event := range events {
+		if event.RRule != "" {
+			berulang = append(berulang, event.ID)
+		}
+	}
+	pengecualian := muatPengecualian(sumberBookingRapat, berulang)
+	hasil := []models.BookingRapat{}
+	for _, event := range events {
+		if event.RRule == "" && !saring {
+			hasil = append(hasil, event)
+			continue
+		}
+		kejadian, err := kejadianEventKalender(event.AllDay, event.Start, event.End, event.RRule, pengecualian[event.ID], dari, sampai)
+		if err != nil {
+			hasil = append(hasil, event)
+			continue
+		}
+		for _, k := range kejadian {
+			salinan := event
+			salinan.Start = formatWaktuEvent(event.AllDay, k.Start)
+			salinan.End = formatWaktuEvent(event.AllDay, k.End)
+			salinan.StartAt = &k.Start
+			salinan.EndAt = &k.End
+			salinan.RecurrenceID = k.RecurrenceID
+			if k.Title != nil {
+				salinan.Title = *k.Title
+			}
+			hasil = append(hasil, salinan)
+		}
+	}
+	return hasil
+}
+
+// kejadianBookingRapat mengembalikan kejadian satu booking di [dari, sampai) untuk cek bentrok
+func kejadianBookingRapat(db *gorm.DB, event models.BookingRapat, dari, sampai time.Time) ([]kejadianEvent, error) {
+	var pengecualian []models.PengecualianJadwal
+	if event.ID != 0 && event.RRule != "" {
+		db.Where("sumber = ? AND event_id = ?", sumberBookingRapat, event.ID).Find(&pengecualian)
+	}
+	return kejadianEventKalender(event.AllDay, event.Start, event.End, event.RRule, pengecualian, dari, sampai)
+}
+
+// cekBentrokRuangRapat membandingkan kejadian baru dengan semua booking lain di ruang yang sama
+func cekBentrokRuangRapat(tx *gorm.DB, ruangID, kecualiID uint, baru []kejadianEvent, dari, sampai time.Time) error {
+	var lain []models.BookingRapat
+	if err := tx.Where("ruang_rapat_id = ? AND id <> ?", ruangID, kecualiID).
+		Where("(COALESCE(rrule, '') = '' AND start_at < ? AND end_at > ?) OR (COALESCE(rrule, '') <> '' AND start_at < ?)", sampai, dari, sampai).
+		Find(&lain).Error; err != nil {
+		return err
+	}
+
+	for _, booking := range lain {
+		kejadian, err := kejadianBookingRapat(tx, booking, dari, sampai)
+		if err != nil {
+			continue
+		}
+		if k, ok := kejadianBentrok(kejadian, baru); ok {
+			bentrok := booking
+			bentrok.Start = formatWaktuEvent(booking.AllDay, k.Start)
+			bentrok.End = formatWaktuEvent(booking.AllDay, k.End)
+			bentrok.RecurrenceID = k.RecurrenceID
+			return &errBookingBentrok{Booking: bentrok}
+		}
+	}
+	return nil
+}
+
+// kejadianBentrok mencari kejadian lama yang beririsan dengan salah satu kejadian baru. Rentang dianggap
+// [Start, End) sehingga booking yang bersambung (selesai 10.00, berikutnya mulai 10.00) tidak bentrok.
+func kejadianBentrok(lama, baru []kejadianEvent) (kejadianEvent, bool) {
+	for _, k := range lama {
+		for _, b := range baru {
+			if k.Start.Before(b.End) && k.End.After(b.Start) {
+				return k, true
+			}
+		}
+	}
+	return kejadianEvent{}, false
+}
+
+func muatPengecualian(sumber string, eventIDs []uint) map[uint][]models.PengecualianJadwal {
+	var semua []models.PengecualianJadwal
+	if len(eventIDs) > 0 {
+		initializers.DB.Where("sumber = ? AND event_id IN ?", sumber, eventIDs).Find(&semua)
+	}
+	hasil := make(map[uint][]models.PengecualianJadwal)
+	for _, p := range semua {
+		hasil[p.EventID] = append(hasil[p.EventID], p)
+	}
+	return hasil
+}
+
+// kejadianEventKalender menerapkan aturan dan pengecualian lalu menyisakan kejadian yang beririsan dengan [dari, sampai)
+func kejadianEventKalender(allDay bool, start, end, rrule string, pengecualian []models.PengecualianJadwal, dari, sampai time.Time) ([]kejadianEvent, error) {
+	mulai, selesai, err := rentangEventKalender(allDay, start, end)
+	if err != nil {
+		return nil, err
+	}
+	beririsan := func(k kejadianEvent) bool {
+		return k.Start.Before(sampai) && k.End.After(dari)
+	}
+
+	if rrule == "" {
+		k := kejadianEvent{Start: mulai, End: selesai}
+		if !beririsan(k) {
+			return nil, nil
+		}
+		return []kejadianEvent{k}, nil
+	}
+
+	aturan, err := parseRRule(rrule, mulai.Location())
+	if err != nil {
+		return nil, err
+	}
+
+	perAsli := make(map[int64]models.PengecualianJadwal)
+	for _, p := range pengecualian {
+		perAsli[p.TanggalAsli.Unix()] = p
+	}
+	dipakai := make(map[int64]bool)
+
+	durasi := selesai.Sub(mulai)
+	hasil := []kejadianEvent{}
+	for _, t := range kejadianRRule(mulai, aturan, sampai) {
+		k := kejadianEvent{Start: t, End: t.Add(durasi), RecurrenceID: formatWaktuEvent(allDay, t)}
+		if p, ok := perAsli[t.Unix()]; ok {
+			dipakai[t.Unix()] = true
+			if p.Batal {
+				continue
+			}
+			if s, e, err := rentangEventKalender(allDay, p.Start, p.End); err == nil {
+				k.Start, k.End = s, e
+			}
+			k.Title = p.Title
+		}
+		if beririsan(k) {
+			hasil = append(hasil, k)
+		}
+	}
+
+	// Kejadian yang dipindah ke dalam rentang dari luar rentang tetap ditampilkan
+	for _, p := range pengecualian {
+		if p.Batal || dipakai[p.TanggalAsli.Unix()] {
+			continue
+		}
+		s, e, err := rentangEventKalender(allDay, p.Start, p.End)
+		if err != nil {
+			continue
+		}
+		k := kejadianEvent{Start: s, End: e, RecurrenceID: formatWaktuEvent(allDay, p.TanggalAsli.In(mulai.Location())), Title: p.Title}
+		if beririsan(k) {
+			hasil = append(hasil, k)
+		}
+	}
+
+	sort.Slice(hasil, func(i, j int) bool { return hasil[i].Start.Before(hasil[j].Start) })
+	return hasil, nil
+}
+
+// rentangEventKalender memakai aturan parseEventTime; end kosong berarti satu hari (all day) atau satu jam
+func rentangEventKalender(allDay bool, start, end string) (time.Time, time.Time, error) {
+	if end == "" && !allDay {
+		if t, err := time.Parse(time.RFC3339, start); err == nil {
+			end = t.Add(time.Hour).Format(time.RFC3339)
+		}
+	}
+	return rentangBookingRapat(allDay, start, end)
+}
+
+func parseWaktuEvent(allDay bool, s string, loc *time.Location) (time.Time, error) {
+	if allDay {
+		return time.ParseInLocation("2006-01-02", s, loc)
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return t, err
+	}
+	return t.In(loc), nil
+}
+
+func formatWaktuEvent(allDay bool, t time.Time) string {
+	if allDay {
+		return t.Format("2006-01-02")
+	}
+	return t.Format(time.RFC3339)
+}
+
+// validasiRRule dipakai saat event dibuat atau diubah
+func validasiRRule(rrule string) error {
+	if rrule == "" {
+		return nil
+	}
+	_, err := parseRRule(rrule, jakartaLocation())
+	return err
+}
+
+func parseRRule(rrule string, loc *time.Location) (*aturanRRule, error) {
+	rrule = strings.TrimPrefix(strings.TrimSpace(rrule), "RRULE:")
+	aturan := &aturanRRule{Interval: 1}
+
+	for _, bagian := range strings.Split(rrule, ";") {
+		if bagian == "" {
+			continue
+		}
+		kv := strings.SplitN(bagian, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("RRULE tidak valid: %s", bagian)
+		}
+		kunci, nilai := strings.ToUpper(kv[0]), strings.ToUpper(kv[1])
+
+		switch kunci {
+		case "FREQ":
+			switch nilai {
+			case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+				aturan.Freq = nilai
+			default:
+				return nil, fmt.Errorf("FREQ %s tidak didukung", nilai)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(nilai)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("INTERVAL harus angka positif")
+			}
+			aturan.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(nilai)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("COUNT harus angka positif")
+			}
+			aturan.Count = n
+		case "UNTIL":
+			until, err := parseUntilRRule(nilai, loc)
+			if err != nil {
+				return nil, err
+			}
+			aturan.Until = &until
+		case "BYDAY":
+			for _, h := range strings.Split(nilai, ",") {
+				hari, err := parseHariRRule(h)
+				if err != nil {
+					return nil, err
+				}
+				aturan.ByDay = append(aturan.ByDay, hari)
+			}
+		case "BYMONTHDAY":
+			for _, d := range strings.Split(nilai, ",") {
+				n, err := strconv.Atoi(d)
+				if err != nil || n == 0 || n < -31 || n > 31 {
+					return nil, fmt.Errorf("BYMONTHDAY tidak valid: %s", d)
+				}
+				aturan.ByMonthDay = append(aturan.ByMonthDay, n)
+			}
+		case "WKST":
+			// minggu selalu dimulai Senin
+		default:
+			return nil, fmt.Errorf("Bagian RRULE %s tidak didukung", kunci)
+		}
+	}
+
+	if aturan.Freq == "" {
+		return nil, errors.New("RRULE wajib memiliki FREQ")
+	}
+	if aturan.Count > 0 && aturan.Until != nil {
+		return nil, errors.New("COUNT dan UNTIL tidak boleh dipakai bersamaan")
+	}
+	return aturan, nil
+}
+
+// parseUntilRRule menerima tanggal saja (berlaku sampai akhir hari), waktu lokal, atau UTC berakhiran Z
+func parseUntilRRule(nilai string, loc *time.Location) (time.Time, error) {
+	if t, err := time.ParseInLocation("20060102", nilai, loc); err == nil {
+		return t.Add(24*time.Hour - time.Second), nil
+	}
+	if t, err := time.Parse("20060102T150405Z", nilai); err == nil {
+		return t.In(loc), nil
+	}
+	if t, err := time.ParseInLocation("20060102T150405", nilai, loc); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("UNTIL tidak valid: %s", nilai)
+}
+
+func parseHariRRule(nilai string) (hariRRule, error) {
+	nilai = strings.TrimSpace(nilai)
+	if len(nilai) < 2 {
+		return hariRRule{}, fmt.Errorf("BYDAY tidak valid: %s", nilai)
+	}
+	weekday, ok := hariRRuleMap[nilai[len(nilai)-2:]]
+	if !ok {
+		return hariRRule{}, fmt.Errorf("BYDAY tidak valid: %s", nilai)
+	}
+	hari := hariRRule{Weekday: weekday}
+	if prefix := nilai[:len(nilai)-2]; prefix != "" {
+		n, err := strconv.Atoi(prefix)
+		if err != nil || n == 0 || n < -5 || n > 5 {
+			return hariRRule{}, fmt.Errorf("BYDAY tidak valid: %s", nilai)
+		}
+		hari.Ordinal = n
+	}
+	return hari, nil
+}
+
+// kejadianRRule menghasilkan awal setiap kejadian mulai dtstart sampai sebelum batas.
+// COUNT dihitung dari dtstart sehingga kejadian di luar rentang tetap ikut terhitung.
+func kejadianRRule(dtstart time.Time, aturan *aturanRRule, batas time.Time) []time.Time {
+	var hasil []time.Time
+	jumlah := 0
+	for k := 0; k < maksPeriodeRRule; k++ {
+		for _, t := range kandidatPeriodeRRule(dtstart, aturan, k) {
+			if t.Before(dtstart) {
+				continue
+			}
+			if aturan.Until != nil && t.After(*aturan.Until) {
+				return hasil
+			}
+			if !t.Before(batas) {
+				return hasil
+			}
+			jumlah++
+			if aturan.Count > 0 && jumlah > aturan.Count {
+				return hasil
+			}
+			hasil = append(hasil, t)
+		}
+	}
+	return hasil
+}
+
+// kandidatPeriodeRRule mengembalikan kandidat kejadian pada periode ke-k, terurut
+func kandidatPeriodeRRule(dtstart time.Time, aturan *aturanRRule, k int) []time.Time {
+	loc := dtstart.Location()
+	jam, menit, detik := dtstart.Clock()
+	langkah := k * aturan.Interval
+
+	switch aturan.Freq {
+	case "DAILY":
+		return []time.Time{time.Date(dtstart.Year(), dtstart.Month(), dtstart.Day()+langkah, jam, menit, detik, 0, loc)}
+
+	case "WEEKLY":
+		offset := (int(dtstart.Weekday()) + 6) % 7
+		senin := time.Date(dtstart.Year(), dtstart.Month(), dtstart.Day()-offset+7*langkah, jam, menit, detik, 0, loc)
+		hari := aturan.ByDay
+		if len(hari) == 0 {
+			hari = []hariRRule{{Weekday: dtstart.Weekday()}}
+		}
+		var kandidat []time.Time
+		for _, h := range hari {
+			kandidat = append(kandidat, senin.AddDate(0, 0, (int(h.Weekday)+6)%7))
+		}
+		return urutkanUnikWaktu(kandidat)
+
+	case "MONTHLY":
+		awal := time.Date(dtstart.Year(), dtstart.Month()+time.Month(langkah), 1, jam, menit, detik, 0, loc)
+		return kandidatBulanRRule(awal, aturan, dtstart.Day())
+
+	case "YEARLY":
+		t := time.Date(dtstart.Year()+langkah, dtstart.Month(), dtstart.Day(), jam, menit, detik, 0, loc)
+		if t.Month() != dtstart.Month() {
+			// 29 Februari dilewati pada tahun yang bukan kabisat
+			return nil
+		}
+		return []time.Time{t}
+	}
+	return nil
+}
+
+func kandidatBulanRRule(awal time.Time, aturan *aturanRRule, hariDtstart int) []time.Time {
+	jumlahHari := awal.AddDate(0, 1, -1).Day()
+	var kandidat []time.Time
+
+	switch {
+	case len(aturan.ByMonthDay) > 0:
+		for _, d := range aturan.ByMonthDay {
+			if d < 0 {
+				d = jumlahHari + d + 1
+			}
+			if d >= 1 && d <= jumlahHari {
+				kandidat = append(kandidat, awal.AddDate(0, 0, d-1))
+			}
+		}
+	case len(aturan.ByDay) > 0:
+		for _, h := range aturan.ByDay {
+			var cocok []time.Time
+			for d := 0; d < jumlahHari; d++ {
+				t := awal.AddDate(0, 0, d)
+				if t.Weekday() == h.Weekday {
+					cocok = append(cocok, t)
+				}
+			}
+			switch {
+			case h.Ordinal == 0:
+				kandidat = append(kandidat, cocok...)
+			case h.Ordinal > 0 && h.Ordinal <= len(cocok):
+				kandidat = append(kandidat, cocok[h.Ordinal-1])
+			case h.Ordinal < 0 && -h.Ordinal <= len(cocok):
+				kandidat = append(kandidat, cocok[len(cocok)+h.Ordinal])
+			}
+		}
+	default:
+		// bulan yang tidak punya tanggal tersebut (misal 31) dilewati
+		if hariDtstart <= jumlahHari {
+			kandidat = append(kandidat, awal.AddDate(0, 0, hariDtstart-1))
+		}
+	}
+	return urutkanUnikWaktu(kandidat)
+}
+
+func urutkanUnikWaktu(waktu []time.Time) []time.Time {
+	sort.Slice(waktu, func(i, j int) bool { return waktu[i].Before(waktu[j]) })
+	hasil := waktu[:0]
+	for i, t := range waktu {
+		if i > 0 && t.Equal(waktu[i-1]) {
+			continue
+		}
+		hasil = append(hasil, t)
+	}
+	return hasil
+}
+
+// rentangKalender membaca ?start=&end= (tanggal atau RFC3339) untuk ekspansi event berulang.
+// Tanpa parameter dipakai satu tahun ke belakang sampai satu tahun ke depan.
+func rentangKalender(c *gin.Context) (time.Time, time.Time, bool, error) {
+	loc := jakartaLocation()
+	startStr, endStr := c.Query("start"), c.Query("end")
+	if startStr == "" || endStr == "" {
+		now := time.Now().In(loc)
+		return now.AddDate(-1, 0, 0), now.AddDate(1, 0, 0), false, nil
+	}
+
+	parse := func(s string) (time.Time, error) {
+		if t, err := time.ParseInLocation("2006-01-02", s, loc); err == nil {
+			return t, nil
+		}
+		return time.Parse(time.RFC3339, s)
+	}
+	dari, err := parse(startStr)
+	if err != nil {
+		return dari, dari, false, fmt.Errorf("Format start tidak valid")
+	}
+	sampai, err := parse(endStr)
+	if err != nil {
+		return dari, sampai, false, fmt.Errorf("Format end tidak valid")
+	}
+	if !sampai.After(dari) {
+		return dari, sampai, false, fmt.Errorf("end harus setelah start")
+	}
+	return dari, sampai, true, nil
+}
+
+// rentangTahunExport adalah rentang lembar kalender tahunan pada export Excel
+func rentangTahunExport(tahun int) (time.Time, time.Time) {
+	dari := time.Date(tahun, 1, 1, 0, 0, 0, 0, jakartaLocation())
+	return dari, dari.AddDate(1, 0, 0)
+}
@@ -0,0 +1,200 @@
+package controllers
+
+import (
+	"project-its/models"
+	"testing"
+	"time"
+)
+
+func TestKejadianRRule(t *testing.T) {
+	tgl := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 9, 0, 0, 0, time.UTC)
+	}
+	jauh := tgl(2100, time.January, 1)
+
+	tests := []struct {
+		nama    string
+		rrule   string
+		dtstart time.Time
+		batas   time.Time
+		want    []time.Time
+	}{
+		{
+			nama:    "harian dengan COUNT",
+			rrule:   "FREQ=DAILY;COUNT=3",
+			dtstart: tgl(2024, time.January, 1),
+			batas:   jauh,
+			want:    []time.Time{tgl(2024, time.January, 1), tgl(2024, time.January, 2), tgl(2024, time.January, 3)},
+		},
+		{
+			nama:    "harian dengan INTERVAL dan UNTIL tanggal saja",
+			rrule:   "RRULE:FREQ=DAILY;INTERVAL=2;UNTIL=20240107",
+			dtstart: tgl(2024, time.January, 1),
+			batas:   jauh,
+			want:    []time.Time{tgl(2024, time.January, 1), tgl(2024, time.January, 3), tgl(2024, time.January, 5), tgl(2024, time.January, 7)},
+		},
+		{
+			nama:    "COUNT tetap dihitung dari dtstart walau batas lebih awal",
+			rrule:   "FREQ=DAILY;COUNT=10",
+			dtstart: tgl(2024, time.January, 1),
+			batas:   tgl(2024, time.January, 4),
+			want:    []time.Time{tgl(2024, time.January, 1), tgl(2024, time.January, 2), tgl(2024, time.January, 3)},
+		},
+		{
+			nama:    "mingguan BYDAY mulai di tengah minggu",
+			rrule:   "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=4",
+			dtstart: tgl(2024, time.January, 3),
+			batas:   jauh,
+			want:    []time.Time{tgl(2024, time.January, 3), tgl(2024, time.January, 8), tgl(2024, time.January, 10), tgl(2024, time.January, 15)},
+		},
+		{
+			nama:    "bulanan tanggal 31 melewati bulan pendek",
+			rrule:   "FREQ=MONTHLY;COUNT=3",
+			dtstart: tgl(2024, time.January, 31),
+			batas:   jauh,
+			want:    []time.Time{tgl(2024, time.January, 31), tgl(2024, time.March, 31), tgl(2024, time.May, 31)},
+		},
+		{
+			nama:    "bulanan jumat terakhir",
+			rrule:   "FREQ=MONTHLY;BYDAY=-1FR;COUNT=2",
+			dtstart: tgl(2024, time.January, 1),
+			batas:   jauh,
+			want:    []time.Time{tgl(2024, time.January, 26), tgl(2024, time.February, 23)},
+		},
+		{
+			nama:    "bulanan hari terakhir lewat BYMONTHDAY negatif",
+			rrule:   "FREQ=MONTHLY;BYMONTHDAY=-1;COUNT=3",
+			dtstart: tgl(2024, time.January, 1),
+			batas:   jauh,
+			want:    []time.Time{tgl(2024, time.January, 31), tgl(2024, time.February, 29), tgl(2024, time.March, 31)},
+		},
+		{
+			nama:    "tahunan 29 Februari hanya di tahun kabisat",
+			rrule:   "FREQ=YEARLY;COUNT=2",
+			dtstart: tgl(2024, time.February, 29),
+			batas:   jauh,
+			want:    []time.Time{tgl(2024, time.February, 29), tgl(2028, time.February, 29)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			aturan, err := parseRRule(tt.rrule, time.UTC)
+			if err != nil {
+				t.Fatalf("parseRRule(%q) error: %v", tt.rrule, err)
+			}
+			got := kejadianRRule(tt.dtstart, aturan, tt.batas)
+			if len(got) != len(tt.want) {
+				t.Fatalf("jumlah kejadian = %d, ingin %d: %v", len(got), len(tt.want), got)
+			}
+			for i := range got {
+				if !got[i].Equal(tt.want[i]) {
+					t.Errorf("kejadian ke-%d = %v, ingin %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseRRuleTidakValid(t *testing.T) {
+	tests := []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=2;UNTIL=20240101",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=MONTHLY;BYDAY=6MO",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=DAILY;UNTIL=besok",
+		"FREQ=DAILY;BYSETPOS=1",
+	}
+
+	for _, rrule := range tests {
+		if _, err := parseRRule(rrule, time.UTC); err == nil {
+			t.Errorf("parseRRule(%q) seharusnya error", rrule)
+		}
+	}
+}
+
+func TestKejadianBentrok(t *testing.T) {
+	loc := jakartaLocation()
+	dari := time.Date(2024, time.January, 1, 0, 0, 0, 0, loc)
+	sampai := time.Date(2024, time.February, 1, 0, 0, 0, 0, loc)
+	kejadian := func(allDay bool, start, end, rrule string, pengecualian ...models.PengecualianJadwal) []kejadianEvent {
+		hasil, err := kejadianEventKalender(allDay, start, end, rrule, pengecualian, dari, sampai)
+		if err != nil {
+			t.Fatalf("kejadianEventKalender(%q, %q, %q): %v", start, end, rrule, err)
+		}
+		return hasil
+	}
+	// Rapat mingguan setiap Senin 09.00-10.00: 1, 8, 15 dan 22 Januari 2024
+	senin15 := time.Date(2024, time.January, 15, 9, 0, 0, 0, loc)
+	mingguan := func(pengecualian ...models.PengecualianJadwal) []kejadianEvent {
+		return kejadian(false, "2024-01-01T09:00:00+07:00", "2024-01-01T10:00:00+07:00", "FREQ=WEEKLY;COUNT=4", pengecualian...)
+	}
+
+	tests := []struct {
+		nama      string
+		lama      []kejadianEvent
+		baru      []kejadianEvent
+		want      bool
+		wantStart time.Time
+	}{
+		{
+			nama: "beririsan sebagian",
+			lama: kejadian(false, "2024-01-03T09:00:00+07:00", "2024-01-03T10:00:00+07:00", ""),
+			baru: kejadian(false, "2024-01-03T09:30:00+07:00", "2024-01-03T10:30:00+07:00", ""),
+			want: true, wantStart: time.Date(2024, time.January, 3, 9, 0, 0, 0, loc),
+		},
+		{
+			nama: "bersambung tidak bentrok",
+			lama: kejadian(false, "2024-01-03T09:00:00+07:00", "2024-01-03T10:00:00+07:00", ""),
+			baru: kejadian(false, "2024-01-03T10:00:00+07:00", "2024-01-03T11:00:00+07:00", ""),
+		},
+		{
+			nama: "kejadian ketiga seri berulang",
+			lama: mingguan(),
+			baru: kejadian(false, "2024-01-15T09:30:00+07:00", "2024-01-15T10:30:00+07:00", ""),
+			want: true, wantStart: senin15,
+		},
+		{
+			nama: "kejadian seri dipindah ke siang",
+			lama: mingguan(models.PengecualianJadwal{TanggalAsli: senin15, Start: "2024-01-15T13:00:00+07:00", End: "2024-01-15T14:00:00+07:00"}),
+			baru: kejadian(false, "2024-01-15T09:30:00+07:00", "2024-01-15T10:30:00+07:00", ""),
+		},
+		{
+			nama: "kejadian seri dibatalkan",
+			lama: mingguan(models.PengecualianJadwal{TanggalAsli: senin15, Batal: true}),
+			baru: kejadian(false, "2024-01-15T09:30:00+07:00", "2024-01-15T10:30:00+07:00", ""),
+		},
+		{
+			nama: "seri baru bentrok dengan booking tunggal",
+			lama: kejadian(false, "2024-01-22T09:45:00+07:00", "2024-01-22T11:00:00+07:00", ""),
+			baru: mingguan(),
+			want: true, wantStart: time.Date(2024, time.January, 22, 9, 45, 0, 0, loc),
+		},
+		{
+			nama: "all day dengan end eksklusif",
+			lama: kejadian(true, "2024-01-15", "2024-01-16", ""),
+			baru: kejadian(false, "2024-01-16T08:00:00+07:00", "2024-01-16T09:00:00+07:00", ""),
+		},
+		{
+			nama: "all day bentrok di hari yang sama",
+			lama: kejadian(true, "2024-01-15", "2024-01-16", ""),
+			baru: kejadian(false, "2024-01-15T16:00:00+07:00", "2024-01-15T17:00:00+07:00", ""),
+			want: true, wantStart: time.Date(2024, time.January, 15, 0, 0, 0, 0, loc),
+		},
+	}
+
+	for _, tt := range tests {
+		k, got := kejadianBentrok(tt.lama, tt.baru)
+		if got != tt.want {
+			t.Errorf("%s: bentrok = %v, ingin %v", tt.nama, got, tt.want)
+			continue
+		}
+		if got && !k.Start.Equal(tt.wantStart) {
+			t.Errorf("%s: kejadian bentrok mulai %v, ingin %v", tt.nama, k.Start, tt.wantStart)
+		}
+	}
+}
@@ -0,0 +1,363 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"project-its/initializers"
+	"project-its/models"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ruangRapatRequest struct {
+	Nama       *string `json:"nama"`
+	Lokasi     *string `json:"lokasi"`
+	Kapasitas  *int    `json:"kapasitas"`
+	Fasilitas  *string `json:"fasilitas"`
+	Aktif      *bool   `json:"aktif"`
+	Keterangan *string `json:"keterangan"`
+}
+
+type bookingRapatRequest struct {
+	Title        *string `json:"title"`
+	Start        *string `json:"start"`
+	End          *string `json:"end"`
+	AllDay       *bool   `json:"allDay"`
+	Color        *string `json:"color"`
+	RuangRapatID *uint   `json:"ruang_rapat_id"`
+	RRule        *string `json:"rrule"`
+}
+
+// errBookingBentrok dipakai untuk membedakan bentrok jadwal dari error database
+type errBookingBentrok struct {
+	Booking models.BookingRapat
+}
+
+func (e *errBookingBentrok) Error() string {
+	return fmt.Sprintf("Ruang sudah dibooking untuk \"%s\" (%s s/d %s)", e.Booking.Title, e.Booking.Start, e.Booking.End)
+}
+
+func RuangRapatIndex(c *gin.Context) {
+	query := initializers.DB.Order("nama asc")
+	if c.Query("aktif") == "true" {
+		query = query.Where("aktif = ?", true)
+	}
+
+	var ruang []models.RuangRapat
+	if err := query.Find(&ruang).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ruang": ruang})
+}
+
+func RuangRapatShow(c *gin.Context) {
+	var ruang models.RuangRapat
+	if err := initializers.DB.First(&ruang, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ruang rapat tidak ditemukan"})
+		return
+	}
+
+	// Jadwal ruang tiga bulan ke depan, termasuk kejadian booking berulang
+	now := time.Now()
+	var booking []models.BookingRapat
+	initializers.DB.Where("ruang_rapat_id = ? AND (end_at >= ? OR COALESCE(rrule, '') <> '')", ruang.ID, now).Find(&booking)
+	booking = expandBookingRapat(booking, now, now.AddDate(0, 3, 0), true)
+	sort.Slice(booking, func(i, j int) bool { return booking[i].StartAt.Before(*booking[j].StartAt) })
+
+	c.JSON(http.StatusOK, gin.H{"ruang": ruang, "booking": booking})
+}
+
+func RuangRapatCreate(c *gin.Context) {
+	var requestBody ruangRapatRequest
+	if err := c.BindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+	if requestBody.Nama == nil || strings.TrimSpace(*requestBody.Nama) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "nama harus diisi"})
+		return
+	}
+
+	ruang := models.RuangRapat{
+		Nama:       strings.TrimSpace(*requestBody.Nama),
+		Lokasi:     requestBody.Lokasi,
+		Fasilitas:  requestBody.Fasilitas,
+		Aktif:      true,
+		Keterangan: requestBody.Keterangan,
+		CreateBy:   c.MustGet("username").(string),
+	}
+	if requestBody.Kapasitas != nil {
+		if *requestBody.Kapasitas < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Kapasitas tidak boleh negatif"})
+			return
+		}
+		ruang.Kapasitas = *requestBody.Kapasitas
+	}
+
+	if err := initializers.DB.Create(&ruang).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat ruang rapat, nama mungkin sudah dipakai"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"ruang": ruang})
+}
+
+func RuangRapatUpdate(c *gin.Context) {
+	var requestBody ruangRapatRequest
+	if err := c.BindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+
+	var ruang models.RuangRapat
+	if err := initializers.DB.First(&ruang, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ruang rapat tidak ditemukan"})
+		return
+	}
+
+	if requestBody.Nama != nil && strings.TrimSpace(*requestBody.Nama) != "" {
+		ruang.Nama = strings.TrimSpace(*requestBody.Nama)
+	}
+	if requestBody.Lokasi != nil {
+		ruang.Lokasi = requestBody.Lokasi
+	}
+	if requestBody.Kapasitas != nil {
+		if *requestBody.Kapasitas < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Kapasitas tidak boleh negatif"})
+			return
+		}
+		ruang.Kapasitas = *requestBody.Kapasitas
+	}
+	if requestBody.Fasilitas != nil {
+		ruang.Fasilitas = requestBody.Fasilitas
+	}
+	if requestBody.Aktif != nil {
+		ruang.Aktif = *requestBody.Aktif
+	}
+	if requestBody.Keterangan != nil {
+		ruang.Keterangan = requestBody.Keterangan
+	}
+
+	if err := initializers.DB.Save(&ruang).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memperbarui ruang rapat"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ruang": ruang})
+}
+
+func RuangRapatDelete(c *gin.Context) {
+	var ruang models.RuangRapat
+	if err := initializers.DB.First(&ruang, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ruang rapat tidak ditemukan"})
+		return
+	}
+
+	var jumlah int64
+	initializers.DB.Model(&models.BookingRapat{}).Where("ruang_rapat_id = ?", ruang.ID).Count(&jumlah)
+	if jumlah > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Ruang masih dipakai %d booking, nonaktifkan saja", jumlah)})
+		return
+	}
+
+	if err := initializers.DB.Delete(&ruang).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghapus ruang rapat"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Ruang rapat deleted successfully"})
+}
+
+// RuangRapatTersedia mengembalikan ruang aktif yang kosong pada slot ?start=&end=
+// (format sama dengan booking; all_day=true untuk tanggal saja), filter opsional ?kapasitas=
+func RuangRapatTersedia(c *gin.Context) {
+	allDay := c.Query("all_day") == "true"
+	startAt, endAt, err := rentangBookingRapat(allDay, c.Query("start"), c.Query("end"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Booking berulang diperluas dulu karena StartAt hanya menyimpan kejadian pertama
+	var booking []models.BookingRapat
+	if err := initializers.DB.Where("ruang_rapat_id IS NOT NULL").
+		Where("(COALESCE(rrule, '') = '' AND start_at < ? AND end_at > ?) OR (COALESCE(rrule, '') <> '' AND start_at < ?)", endAt, startAt, endAt).
+		Find(&booking).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	terpakai := []uint{0}
+	for _, b := range expandBookingRapat(booking, startAt, endAt, true) {
+		terpakai = append(terpakai, *b.RuangRapatID)
+	}
+
+	query := initializers.DB.Where("aktif = ?", true).Where("id NOT IN ?", terpakai)
+	if kapasitas := c.Query("kapasitas"); kapasitas != "" {
+		minimal, err := strconv.Atoi(kapasitas)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Kapasitas harus berupa angka"})
+			return
+		}
+		query = query.Where("kapasitas >= ?", minimal)
+	}
+
+	var ruang []models.RuangRapat
+	if err := query.Order("kapasitas asc, nama asc").Find(&ruang).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ruang": ruang})
+}
+
+// UpdateEventBookingRapat mengubah booking dengan pengecekan bentrok yang sama seperti saat dibuat
+func UpdateEventBookingRapat(c *gin.Context) {
+	var requestBody bookingRapatRequest
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var event models.BookingRapat
+	if err := initializers.DB.First(&event, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Booking tidak ditemukan"})
+		return
+	}
+
+	titleLama, startLama := event.Title, event.StartAt
+	// Pengecualian dicatat per tanggal kejadian asli, tidak berlaku lagi bila aturan atau awal seri berubah
+	rruleLama, startStrLama, allDayLama := event.RRule, event.Start, event.AllDay
+
+	if requestBody.Title != nil {
+		event.Title = *requestBody.Title
+	}
+	if requestBody.Start != nil {
+		event.Start = *requestBody.Start
+	}
+	if requestBody.End != nil {
+		event.End = *requestBody.End
+	}
+	if requestBody.AllDay != nil {
+		event.AllDay = *requestBody.AllDay
+	}
+	if requestBody.Color != nil {
+		event.Color = *requestBody.Color
+	}
+	if requestBody.RRule != nil {
+		event.RRule = strings.TrimSpace(*requestBody.RRule)
+	}
+	if requestBody.RuangRapatID != nil {
+		if *requestBody.RuangRapatID == 0 {
+			event.RuangRapatID = nil
+		} else {
+			event.RuangRapatID = requestBody.RuangRapatID
+		}
+	}
+
+	resetPengecualian := event.RRule != rruleLama || event.Start != startStrLama || event.AllDay != allDayLama
+	if err := simpanBookingRapat(&event, resetPengecualian); err != nil {
+		respondBookingRapatError(c, err)
+		return
+	}
+
+	// Pengingat lama dibatalkan dan dijadwalkan ulang bila judul atau waktu mulai berubah
+	if event.Title != titleLama || startLama == nil || !event.StartAt.Equal(*startLama) {
+		if err := batalkanPengingatEvent(initializers.DB, sumberBookingRapat, event.ID); err != nil {
+			log.Printf("Error cancelling reminders for booking %d: %v", event.ID, err)
+		}
+		SetNotificationEvent(event.Title, event.StartAt.In(jakartaLocation()), "BookingRapat", sumberBookingRapat, event.ID)
+	}
+	c.JSON(http.StatusOK, event)
+}
+
+// simpanBookingRapat menghitung ulang StartAt/EndAt lalu menyimpan booking.
+// Baris ruang dikunci selama transaksi agar dua booking bersamaan tidak lolos cek bentrok.
+// Booking berulang dicek untuk kejadian satu tahun ke depan. resetPengecualian menghapus pengecualian seri lama.
+func simpanBookingRapat(event *models.BookingRapat, resetPengecualian bool) error {
+	return initializers.DB.Transaction(func(tx *gorm.DB) error {
+		return simpanBookingRapatTx(tx, event, resetPengecualian, nil)
+	})
+}
+
+// simpanBookingRapatTx sama dengan simpanBookingRapat di dalam transaksi pemanggil (mis. import .ics).
+// Bila gantiPengecualian, pengecualian lama diganti dengan pengecualian yang diberikan dan cek bentrok memakai daftar baru itu.
+func simpanBookingRapatTx(tx *gorm.DB, event *models.BookingRapat, gantiPengecualian bool, pengecualian []models.PengecualianJadwal) error {
+	if err := validasiRRule(event.RRule); err != nil {
+		return err
+	}
+	startAt, endAt, err := rentangBookingRapat(event.AllDay, event.Start, event.End)
+	if err != nil {
+		return err
+	}
+	event.StartAt = &startAt
+	event.EndAt = &endAt
+
+	if gantiPengecualian && event.ID != 0 {
+		if err := tx.Where("sumber = ? AND event_id = ?", sumberBookingRapat, event.ID).Delete(&models.PengecualianJadwal{}).Error; err != nil {
+			return err
+		}
+	}
+	if event.RuangRapatID != nil {
+		var ruang models.RuangRapat
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&ruang, *event.RuangRapatID).Error; err != nil {
+			return fmt.Errorf("Ruang rapat tidak ditemukan")
+		}
+		if !ruang.Aktif {
+			return fmt.Errorf("Ruang rapat %s sedang nonaktif", ruang.Nama)
+		}
+
+		sampai := endAt
+		if event.RRule != "" {
+			sampai = startAt.AddDate(1, 0, 0)
+		}
+		var kejadian []kejadianEvent
+		if gantiPengecualian {
+			kejadian, err = kejadianEventKalender(event.AllDay, event.Start, event.End, event.RRule, pengecualian, startAt, sampai)
+		} else {
+			kejadian, err = kejadianBookingRapat(tx, *event, startAt, sampai)
+		}
+		if err != nil {
+			return err
+		}
+		if err := cekBentrokRuangRapat(tx, ruang.ID, event.ID, kejadian, startAt, sampai); err != nil {
+			return err
+		}
+	}
+	if err := tx.Save(event).Error; err != nil {
+		return err
+	}
+	for _, p := range pengecualian {
+		p.Sumber = sumberBookingRapat
+		p.EventID = event.ID
+		if err := tx.Create(&p).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func respondBookingRapatError(c *gin.Context, err error) {
+	var bentrok *errBookingBentrok
+	if errors.As(err, &bentrok) {
+		c.JSON(http.StatusConflict, gin.H{"error": bentrok.Error(), "bentrok": bentrok.Booking})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
+
+// rentangBookingRapat memakai format yang sama dengan parseEventTime; end all day eksklusif, kosong berarti satu hari
+func rentangBookingRapat(allDay bool, start, end string) (time.Time, time.Time, error) {
+	startAt, endAt, err := parseEventTime(allDay, start, end)
+	if err != nil {
+		return startAt, endAt, fmt.Errorf("Format waktu booking tidak valid")
+	}
+	if !endAt.After(startAt) {
+		return startAt, endAt, fmt.Errorf("Waktu selesai harus setelah waktu mulai")
+	}
+	return startAt, endAt, nil
+}
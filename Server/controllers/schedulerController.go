@@ -0,0 +1,33 @@
+package controllers
+
+import (
+	"log"
+	"time"
+)
+
+// StartSchedulers menjalankan semua job berkala di background, dipanggil sekali dari main
+func StartSchedulers() {
+	go runPeriodically("cek SLA surat masuk", time.Hour, CheckSuratMasukOverdue)
+	go runPeriodically("cek peminjaman arsip", time.Hour, CheckPeminjamanArsipOverdue)
+	go runPeriodically("cek retensi arsip", 24*time.Hour, CheckRetensiArsip)
+	go runPeriodically("cek task meeting overdue", time.Hour, CheckMeetingOverdue)
+	go runPeriodically("kirim pengingat notifikasi", time.Minute, ProsesPengingatJatuhTempo)
+	go runPeriodically("kirim email notifikasi", time.Minute, ProsesEmailKeluar)
+	go runPeriodically("kirim webhook", 30*time.Second, ProsesPengirimanWebhook)
+	go runPeriodically("kirim ringkasan harian", 15*time.Minute, KirimRingkasanHarian)
+}
+
+func runPeriodically(name string, interval time.Duration, job func()) {
+	for {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("Job %s panic: %v", name, r)
+				}
+			}()
+			log.Printf("Menjalankan job %s", name)
+			job()
+		}()
+		time.Sleep(interval)
+	}
+}
@@ -0,0 +1,306 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"project-its/initializers"
+	"project-its/models"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// batas balasan jika jenis surat belum diatur di tabel SLA
+const defaultHariResponSurat = 7
+
+type SlaSuratRequest struct {
+	JenisSurat *string `json:"jenis_surat"`
+	HariRespon *int    `json:"hari_respon"`
+	Keterangan *string `json:"keterangan"`
+}
+
+type suratMasukOverdue struct {
+	SuratMasuk    models.SuratMasuk `json:"surat_masuk"`
+	BatasRespon   string            `json:"batas_respon"`
+	HariTerlambat int               `json:"hari_terlambat"`
+}
+
+type divisiOverdue struct {
+	Divisi     string              `json:"divisi"`
+	Jumlah     int                 `json:"jumlah"`
+	SuratMasuk []suratMasukOverdue `json:"surat_masuk"`
+}
+
+func SlaSuratIndex(c *gin.Context) {
+	var sla []models.SlaSurat
+	if err := initializers.DB.Order("jenis_surat asc").Find(&sla).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"sla": sla, "default_hari_respon": defaultHariResponSurat})
+}
+
+func SlaSuratCreate(c *gin.Context) {
+	var requestBody SlaSuratRequest
+	if err := c.BindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	if requestBody.JenisSurat == nil || *requestBody.JenisSurat == "" || requestBody.HariRespon == nil || *requestBody.HariRespon <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "jenis_surat dan hari_respon (> 0) harus diisi"})
+		return
+	}
+
+	sla := models.SlaSurat{
+		JenisSurat: *requestBody.JenisSurat,
+		HariRespon: *requestBody.HariRespon,
+		Keterangan: requestBody.Keterangan,
+	}
+	if err := initializers.DB.Create(&sla).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan SLA, jenis surat mungkin sudah terdaftar"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"sla": sla})
+}
+
+func SlaSuratUpdate(c *gin.Context) {
+	var requestBody SlaSuratRequest
+	if err := c.BindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	var sla models.SlaSurat
+	if err := initializers.DB.First(&sla, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "SLA tidak ditemukan"})
+		return
+	}
+
+	if requestBody.JenisSurat != nil && *requestBody.JenisSurat != "" {
+		sla.JenisSurat = *requestBody.JenisSurat
+	}
+	if requestBody.HariRespon != nil {
+		if *requestBody.HariRespon <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "hari_respon harus lebih dari 0"})
+			return
+		}
+		sla.HariRespon = *requestBody.HariRespon
+	}
+	if requestBody.Keterangan != nil {
+		sla.Keterangan = requestBody.Keterangan
+	}
+
+	if err := initializers.DB.Save(&sla).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan SLA"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"sla": sla})
+}
+
+func SlaSuratDelete(c *gin.Context) {
+	if err := initializers.DB.Where("id = ?", c.Param("id")).Delete(&models.SlaSurat{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// SuratMasukBalasan menampilkan surat keluar yang membalas sebuah surat masuk beserta status SLA-nya
+func SuratMasukBalasan(c *gin.Context) {
+	var suratMasuk models.SuratMasuk
+	if err := initializers.DB.First(&suratMasuk, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Surat masuk tidak ditemukan"})
+		return
+	}
+
+	var balasan []models.SuratKeluar
+	if err := initializers.DB.Where("surat_masuk_id = ?", suratMasuk.ID).Order("tanggal asc").Find(&balasan).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	kalender, err := muatKalenderKerja(initializers.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	batas := batasResponSuratMasuk(suratMasuk, loadHariResponSurat(), kalender)
+
+	var status string
+	switch {
+	case len(balasan) == 0 && isLewatBatas(batas, time.Now()):
+		status = "Terlambat"
+	case len(balasan) == 0:
+		status = "Belum dibalas"
+	case balasan[0].Tanggal != nil && isLewatBatas(batas, *balasan[0].Tanggal):
+		status = "Dibalas terlambat"
+	default:
+		status = "Dibalas tepat waktu"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"SuratMasuk":   &suratMasuk,
+		"batas_respon": batas.Format("2006-01-02"),
+		"status":       status,
+		"balasan":      balasan,
+	})
+}
+
+// SuratMasukOverdueReport menampilkan surat masuk yang belum dibalas melewati batas, dikelompokkan per divisi
+func SuratMasukOverdueReport(c *gin.Context) {
+	overdue, err := findSuratMasukOverdue(time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	filterDiv := c.Query("divisi")
+	perDivisi := make(map[string][]suratMasukOverdue)
+	for _, item := range overdue {
+		divisi := derefString(item.SuratMasuk.DestinyDiv)
+		if divisi == "" {
+			divisi = "Tanpa Divisi"
+		}
+		if filterDiv != "" && filterDiv != divisi {
+			continue
+		}
+		perDivisi[divisi] = append(perDivisi[divisi], item)
+	}
+
+	report := []divisiOverdue{}
+	total := 0
+	for divisi, items := range perDivisi {
+		report = append(report, divisiOverdue{Divisi: divisi, Jumlah: len(items), SuratMasuk: items})
+		total += len(items)
+	}
+	sort.Slice(report, func(i, j int) bool { return report[i].Divisi < report[j].Divisi })
+
+	c.JSON(http.StatusOK, gin.H{"overdue": report, "total": total})
+}
+
+// CheckSuratMasukOverdue dijalankan berkala dan menandai surat masuk yang terlambat lewat tabel Notification
+func CheckSuratMasukOverdue() {
+	overdue, err := findSuratMasukOverdue(time.Now())
+	if err != nil {
+		log.Printf("Error checking surat masuk overdue: %v", err)
+		return
+	}
+
+	// Hanya jenis surat yang punya SLA dan surat yang masuk setelah SLA-nya dibuat yang diingatkan,
+	// supaya surat lama yang memang tidak pernah dibalas tidak menjadi notifikasi massal
+	var sla []models.SlaSurat
+	if err := initializers.DB.Find(&sla).Error; err != nil {
+		log.Printf("Error loading SLA surat: %v", err)
+		return
+	}
+	mulaiSla := make(map[string]time.Time)
+	for _, s := range sla {
+		if s.CreatedAt != nil {
+			mulaiSla[s.JenisSurat] = *s.CreatedAt
+		}
+	}
+
+	for _, item := range overdue {
+		suratMasuk := item.SuratMasuk
+		mulai, ok := mulaiSla[derefString(suratMasuk.JenisSurat)]
+		if !ok || suratMasuk.CreatedAt == nil || suratMasuk.CreatedAt.Before(mulai) {
+			continue
+		}
+
+		title := fmt.Sprintf("Surat masuk %s belum dibalas, batas respon %s", derefString(suratMasuk.NoSurat), item.BatasRespon)
+		kunci := fmt.Sprintf("sla-surat-%d", suratMasuk.ID)
+
+		// Dikirim ke divisi tujuan surat; surat tanpa divisi yang dikenal diteruskan ke admin
+		penerima := anggotaDivisi(derefString(suratMasuk.DestinyDiv))
+		for _, userID := range penerima {
+			userID := userID
+			createNotificationUnik(title, time.Now(), "SuratMasukOverdue", &userID, fmt.Sprintf("%s:%d", kunci, userID))
+		}
+		if len(penerima) == 0 {
+			role := roleAdmin
+			kirimNotifikasi(&models.Notification{
+				Title:     title,
+				Start:     time.Now(),
+				Category:  "SuratMasukOverdue",
+				Role:      &role,
+				KunciUnik: &kunci,
+			})
+		}
+	}
+}
+
+func findSuratMasukOverdue(now time.Time) ([]suratMasukOverdue, error) {
+	var belumDibalas []models.SuratMasuk
+	err := initializers.DB.
+		Where("NOT EXISTS (SELECT 1 FROM surat_keluars WHERE surat_keluars.surat_masuk_id = surat_masuks.id)").
+		Order("tanggal asc").
+		Find(&belumDibalas).Error
+	if err != nil {
+		return nil, err
+	}
+
+	hariRespon := loadHariResponSurat()
+	kalender, err := muatKalenderKerja(initializers.DB)
+	if err != nil {
+		return nil, err
+	}
+	today := truncateToDate(now)
+
+	overdue := []suratMasukOverdue{}
+	for _, suratMasuk := range belumDibalas {
+		batas := batasResponSuratMasuk(suratMasuk, hariRespon, kalender)
+		if !isLewatBatas(batas, now) {
+			continue
+		}
+		overdue = append(overdue, suratMasukOverdue{
+			SuratMasuk:    suratMasuk,
+			BatasRespon:   batas.Format("2006-01-02"),
+			HariTerlambat: int(today.Sub(truncateToDate(batas)).Hours() / 24),
+		})
+	}
+	return overdue, nil
+}
+
+// loadHariResponSurat memetakan jenis surat ke jumlah hari respon yang dikonfigurasi
+func loadHariResponSurat() map[string]int {
+	var sla []models.SlaSurat
+	initializers.DB.Find(&sla)
+
+	hariRespon := make(map[string]int)
+	for _, s := range sla {
+		hariRespon[s.JenisSurat] = s.HariRespon
+	}
+	return hariRespon
+}
+
+// batasResponSuratMasuk menghitung batas waktu balasan dari tanggal surat (atau tanggal input jika kosong).
+// Hari respon dihitung dalam hari kerja, melewati akhir pekan, libur nasional dan cuti bersama.
+func batasResponSuratMasuk(suratMasuk models.SuratMasuk, hariRespon map[string]int, kalender *kalenderKerja) time.Time {
+	var base time.Time
+	if suratMasuk.Tanggal != nil {
+		base = *suratMasuk.Tanggal
+	} else if suratMasuk.CreatedAt != nil {
+		base = *suratMasuk.CreatedAt
+	} else {
+		base = time.Now()
+	}
+
+	hari := defaultHariResponSurat
+	if suratMasuk.JenisSurat != nil {
+		if h, ok := hariRespon[*suratMasuk.JenisSurat]; ok {
+			hari = h
+		}
+	}
+	return kalender.tambahHariKerja(base, hari)
+}
+
+// isLewatBatas bernilai true jika waktu t sudah melewati hari batas (batas masih berlaku sepanjang harinya)
+func isLewatBatas(batas, t time.Time) bool {
+	return truncateToDate(t).After(truncateToDate(batas))
+}
+
+func truncateToDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
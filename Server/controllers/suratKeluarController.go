@@ -18,13 +18,14 @@ import (
 )
 
 type SuratKeluarRequest struct {
-	ID       uint    `gorm:"primaryKey"`
-	NoSurat  *string `json:"no_surat"`
-	Title    *string `json:"title"`
-	From     *string `json:"from"`
-	Pic      *string `json:"pic"`
-	Tanggal  *string `json:"tanggal"`
-	CreateBy string  `json:"create_by"`
+	ID           uint    `gorm:"primaryKey"`
+	NoSurat      *string `json:"no_surat"`
+	Title        *string `json:"title"`
+	From         *string `json:"from"`
+	Pic          *string `json:"pic"`
+	Tanggal      *string `json:"tanggal"`
+	CreateBy     string  `json:"create_by"`
+	SuratMasukID *uint   `json:"surat_masuk_id"`
 }
 
 func UploadHandlerSuratKeluar(c *gin.Context) {
@@ -180,13 +181,23 @@ func SuratKeluarCreate(c *gin.Context) {
 		tanggal = &parsedTanggal
 	}
 
+	// Pastikan surat masuk yang dibalas memang ada
+	if requestBody.SuratMasukID != nil {
+		var surat_masuk models.SuratMasuk
+		if err := initializers.DB.First(&surat_masuk, *requestBody.SuratMasukID).Error; err != nil {
+			c.JSON(400, gin.H{"error": "Surat masuk yang dibalas tidak ditemukan"})
+			return
+		}
+	}
+
 	surat_keluar := models.SuratKeluar{
-		NoSurat:  requestBody.NoSurat,
-		Title:    requestBody.Title,
-		From:     requestBody.From,
-		Pic:      requestBody.Pic,
-		Tanggal:  tanggal, // Gunakan tanggal yang telah diparsing, bisa jadi nil jika input kosong
-		CreateBy: requestBody.CreateBy,
+		NoSurat:      requestBody.NoSurat,
+		Title:        requestBody.Title,
+		From:         requestBody.From,
+		Pic:          requestBody.Pic,
+		Tanggal:      tanggal, // Gunakan tanggal yang telah diparsing, bisa jadi nil jika input kosong
+		CreateBy:     requestBody.CreateBy,
+		SuratMasukID: requestBody.SuratMasukID,
 	}
 
 	result := initializers.DB.Create(&surat_keluar)
@@ -195,6 +206,7 @@ func SuratKeluarCreate(c *gin.Context) {
 		c.Status(400)
 		return
 	}
+	emitWebhook(eventSuratKeluarDibuat, surat_keluar)
 
 	// Return it
 	c.JSON(200, gin.H{
@@ -278,6 +290,24 @@ func SuratKeluarUpdate(c *gin.Context) {
 		surat_keluar.Pic = surat_keluar.Pic // gunakan nilai yang ada dari database
 	}
 
+	// surat_masuk_id 0 melepas tautan balasan
+	lepasBalasan := requestBody.SuratMasukID != nil && *requestBody.SuratMasukID == 0
+	if requestBody.SuratMasukID != nil && !lepasBalasan {
+		var surat_masuk models.SuratMasuk
+		if err := initializers.DB.First(&surat_masuk, *requestBody.SuratMasukID).Error; err != nil {
+			c.JSON(400, gin.H{"error": "Surat masuk yang dibalas tidak ditemukan"})
+			return
+		}
+		surat_keluar.SuratMasukID = requestBody.SuratMasukID
+	}
+	if lepasBalasan {
+		if err := initializers.DB.Model(&surat_keluar).Update("surat_masuk_id", nil).Error; err != nil {
+			c.JSON(500, gin.H{"error": "Gagal melepas surat masuk yang dibalas"})
+			return
+		}
+		surat_keluar.SuratMasukID = nil
+	}
+
 	if requestBody.CreateBy != "" {
 		surat_keluar.CreateBy = requestBody.CreateBy
 	} else {
@@ -23,6 +23,7 @@ type SuratMasukRequest struct {
 	Title      *string `json:"title"`
 	RelatedDiv *string `json:"related_div"`
 	DestinyDiv *string `json:"destiny_div"`
+	JenisSurat *string `json:"jenis_surat"`
 	Tanggal    *string `json:"tanggal"`
 	CreateBy   string  `json:"create_by"`
 }
@@ -185,6 +186,7 @@ func SuratMasukCreate(c *gin.Context) {
 		Title:      requestBody.Title,
 		RelatedDiv: requestBody.RelatedDiv,
 		DestinyDiv: requestBody.DestinyDiv,
+		JenisSurat: requestBody.JenisSurat,
 		Tanggal:    tanggal, // Gunakan tanggal yang telah diparsing, bisa jadi nil jika input kosong
 		CreateBy:   requestBody.CreateBy,
 	}
@@ -195,6 +197,7 @@ func SuratMasukCreate(c *gin.Context) {
 		c.Status(400)
 		return
 	}
+	emitWebhook(eventSuratMasukDibuat, surat_masuk)
 
 	// Return it
 	c.JSON(200, gin.H{
@@ -283,6 +286,10 @@ func SuratMasukUpdate(c *gin.Context) {
 		surat_masuk.DestinyDiv = surat_masuk.DestinyDiv // gunakan nilai yang ada dari database
 	}
 
+	if requestBody.JenisSurat != nil {
+		surat_masuk.JenisSurat = requestBody.JenisSurat
+	}
+
 	if requestBody.CreateBy != "" {
 		surat_masuk.CreateBy = requestBody.CreateBy
 	} else {
@@ -309,6 +316,18 @@ func SuratMasukDelete(c *gin.Context) {
 		return
 	}
 
+	// hapus juga rantai disposisinya
+	if err := initializers.DB.Where("surat_masuk_id = ?", surat_masuk.ID).Delete(&models.Disposisi{}).Error; err != nil {
+		c.JSON(500, gin.H{"error": "Gagal menghapus disposisi surat masuk"})
+		return
+	}
+
+	// surat keluar yang membalasnya tidak lagi menunjuk ke surat yang dihapus
+	if err := initializers.DB.Model(&models.SuratKeluar{}).Where("surat_masuk_id = ?", surat_masuk.ID).Update("surat_masuk_id", nil).Error; err != nil {
+		c.JSON(500, gin.H{"error": "Gagal melepas balasan surat masuk"})
+		return
+	}
+
 	/// delete it
 	if err := initializers.DB.Delete(&surat_masuk).Error; err != nil {
 		c.JSON(404, gin.H{"error": "Surat Masuk Failed to Delete"})
@@ -0,0 +1,485 @@
+package controllers
+
+import (
+	"net/http"
+	"project-its/initializers"
+	"project-its/models"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	tahapTor         = "TOR"
+	tahapIzinPrinsip = "Izin Prinsip"
+
+	// batas default lama satu tahap sebelum project dianggap tertahan
+	defaultBatasHariTahap = 30
+)
+
+// pipeline dipakai jika jenis pengadaan belum punya konfigurasi tahap sendiri
+var defaultTahapPengadaan = []string{tahapTor, tahapIzinPrinsip, "RFP", "Pemilihan Vendor", "Kontrak", "Delivery", "BAST", "Pembayaran"}
+
+type TahapPengadaanRequest struct {
+	JenisPengadaan *string `json:"jenis_pengadaan"`
+	Urutan         *int    `json:"urutan"`
+	Nama           *string `json:"nama"`
+	BatasHari      *int    `json:"batas_hari"`
+	Keterangan     *string `json:"keterangan"`
+}
+
+// tanggal kosong ("") menghapus tanggal yang sudah terisi
+type TahapProjectRequest struct {
+	RencanaMulai   *string `json:"rencana_mulai"`
+	RencanaSelesai *string `json:"rencana_selesai"`
+	MulaiAktual    *string `json:"mulai_aktual"`
+	SelesaiAktual  *string `json:"selesai_aktual"`
+	BatasHari      *int    `json:"batas_hari"`
+	Catatan        *string `json:"catatan"`
+}
+
+type durasiTahap struct {
+	Nama        string `json:"nama"`
+	Status      string `json:"status"`
+	DurasiHari  *int   `json:"durasi_hari"`
+	RencanaHari *int   `json:"rencana_hari"`
+	Terlambat   bool   `json:"terlambat"`
+}
+
+type laporanTahapProject struct {
+	ProjectID      uint          `json:"project_id"`
+	KodeProject    string        `json:"kode_project"`
+	NamaPengadaan  string        `json:"nama_pengadaan"`
+	JenisPengadaan string        `json:"jenis_pengadaan"`
+	TahapSaatIni   string        `json:"tahap_saat_ini"`
+	MulaiTahap     string        `json:"mulai_tahap"`
+	HariDiTahap    int           `json:"hari_di_tahap"`
+	BatasHari      int           `json:"batas_hari"`
+	Tertahan       bool          `json:"tertahan"`
+	Tahap          []durasiTahap `json:"tahap"`
+}
+
+func TahapPengadaanIndex(c *gin.Context) {
+	query := initializers.DB.Order("jenis_pengadaan asc, urutan asc")
+	if jenis := c.Query("jenis_pengadaan"); jenis != "" {
+		query = query.Where("jenis_pengadaan = ?", jenis)
+	}
+
+	var tahap []models.TahapPengadaan
+	if err := query.Find(&tahap).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tahap": tahap, "default": defaultTahapPengadaan})
+}
+
+func TahapPengadaanCreate(c *gin.Context) {
+	var requestBody TahapPengadaanRequest
+	if err := c.BindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+	if requestBody.JenisPengadaan == nil || *requestBody.JenisPengadaan == "" || requestBody.Nama == nil || *requestBody.Nama == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "jenis_pengadaan dan nama harus diisi"})
+		return
+	}
+
+	tahap := models.TahapPengadaan{
+		JenisPengadaan: *requestBody.JenisPengadaan,
+		Nama:           *requestBody.Nama,
+		Keterangan:     requestBody.Keterangan,
+	}
+	if requestBody.Urutan != nil {
+		tahap.Urutan = *requestBody.Urutan
+	} else {
+		// Tambahkan di akhir pipeline
+		var last models.TahapPengadaan
+		if err := initializers.DB.Where("jenis_pengadaan = ?", tahap.JenisPengadaan).Order("urutan desc").First(&last).Error; err == nil {
+			tahap.Urutan = last.Urutan + 1
+		} else {
+			tahap.Urutan = 1
+		}
+	}
+	if requestBody.BatasHari != nil {
+		tahap.BatasHari = *requestBody.BatasHari
+	}
+
+	if err := initializers.DB.Create(&tahap).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan tahap"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"tahap": tahap})
+}
+
+func TahapPengadaanUpdate(c *gin.Context) {
+	var tahap models.TahapPengadaan
+	if err := initializers.DB.First(&tahap, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tahap tidak ditemukan"})
+		return
+	}
+
+	var requestBody TahapPengadaanRequest
+	if err := c.BindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+
+	if requestBody.JenisPengadaan != nil && *requestBody.JenisPengadaan != "" {
+		tahap.JenisPengadaan = *requestBody.JenisPengadaan
+	}
+	if requestBody.Urutan != nil {
+		tahap.Urutan = *requestBody.Urutan
+	}
+	if requestBody.Nama != nil && *requestBody.Nama != "" {
+		tahap.Nama = *requestBody.Nama
+	}
+	if requestBody.BatasHari != nil {
+		tahap.BatasHari = *requestBody.BatasHari
+	}
+	if requestBody.Keterangan != nil {
+		tahap.Keterangan = requestBody.Keterangan
+	}
+
+	if err := initializers.DB.Save(&tahap).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan tahap"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tahap": tahap})
+}
+
+func TahapPengadaanDelete(c *gin.Context) {
+	if err := initializers.DB.Delete(&models.TahapPengadaan{}, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghapus tahap"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Tahap deleted successfully"})
+}
+
+func ProjectTahapIndex(c *gin.Context) {
+	var project models.Project
+	if err := initializers.DB.First(&project, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+
+	var tahap []models.TahapProject
+	initializers.DB.Where("project_id = ?", project.ID).Order("urutan asc").Find(&tahap)
+	c.JSON(http.StatusOK, gin.H{"project": &project, "tahap": tahap})
+}
+
+// ProjectTahapGenerate membuat tahap project dari pipeline jenis pengadaannya
+func ProjectTahapGenerate(c *gin.Context) {
+	var project models.Project
+	if err := initializers.DB.First(&project, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+
+	var jumlah int64
+	initializers.DB.Model(&models.TahapProject{}).Where("project_id = ?", project.ID).Count(&jumlah)
+	if jumlah > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Project sudah memiliki tahap pengadaan"})
+		return
+	}
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		return generateTahapProject(tx, &project, c.MustGet("username").(string))
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat tahap pengadaan"})
+		return
+	}
+
+	var tahap []models.TahapProject
+	initializers.DB.Where("project_id = ?", project.ID).Order("urutan asc").Find(&tahap)
+	c.JSON(http.StatusCreated, gin.H{"project": &project, "tahap": tahap})
+}
+
+func TahapProjectUpdate(c *gin.Context) {
+	var tahap models.TahapProject
+	if err := initializers.DB.First(&tahap, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tahap tidak ditemukan"})
+		return
+	}
+
+	var requestBody TahapProjectRequest
+	if err := c.BindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+
+	for _, field := range []struct {
+		value  *string
+		target **time.Time
+	}{
+		{requestBody.RencanaMulai, &tahap.RencanaMulai},
+		{requestBody.RencanaSelesai, &tahap.RencanaSelesai},
+		{requestBody.MulaiAktual, &tahap.MulaiAktual},
+		{requestBody.SelesaiAktual, &tahap.SelesaiAktual},
+	} {
+		if field.value == nil {
+			continue
+		}
+		if *field.value == "" {
+			*field.target = nil
+			continue
+		}
+		parsed, err := time.Parse("2006-01-02", *field.value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format: " + err.Error()})
+			return
+		}
+		*field.target = &parsed
+	}
+	if requestBody.BatasHari != nil {
+		tahap.BatasHari = *requestBody.BatasHari
+	}
+	if requestBody.Catatan != nil {
+		tahap.Catatan = requestBody.Catatan
+	}
+
+	// Tahap yang selesai pasti sudah dimulai
+	if tahap.SelesaiAktual != nil && tahap.MulaiAktual == nil {
+		tahap.MulaiAktual = tahap.SelesaiAktual
+	}
+	if tahap.SelesaiAktual != nil && tahap.SelesaiAktual.Before(*tahap.MulaiAktual) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tanggal selesai tidak boleh sebelum tanggal mulai"})
+		return
+	}
+	if tahap.RencanaMulai != nil && tahap.RencanaSelesai != nil && tahap.RencanaSelesai.Before(*tahap.RencanaMulai) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Rencana selesai tidak boleh sebelum rencana mulai"})
+		return
+	}
+	tahap.UpdateBy = c.MustGet("username").(string)
+
+	var project models.Project
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&tahap).Error; err != nil {
+			return err
+		}
+
+		if err := mulaiTahapBerikutnya(tx, &tahap); err != nil {
+			return err
+		}
+
+		if err := tx.First(&project, tahap.ProjectID).Error; err != nil {
+			return err
+		}
+		// Tanggal TOR dan izin di project mengikuti tahapnya
+		switch tahap.Nama {
+		case tahapTor:
+			project.TanggalTor = tahap.SelesaiAktual
+		case tahapIzinPrinsip:
+			project.TanggalIzin = tahap.SelesaiAktual
+		}
+		return syncTahapSaatIni(tx, &project)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan tahap"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tahap": &tahap, "project": &project})
+}
+
+// LaporanTahapProject menampilkan durasi setiap tahap dan project yang tertahan lebih lama dari batas
+func LaporanTahapProject(c *gin.Context) {
+	batasDefault := defaultBatasHariTahap
+	if v := c.Query("batas_hari"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "batas_hari harus angka lebih dari 0"})
+			return
+		}
+		batasDefault = parsed
+	}
+	hanyaTertahan := c.Query("tertahan") == "true"
+
+	var projects []models.Project
+	initializers.DB.Order("id asc").Find(&projects)
+	var semuaTahap []models.TahapProject
+	initializers.DB.Order("project_id asc, urutan asc").Find(&semuaTahap)
+	tahapPerProject := make(map[uint][]models.TahapProject)
+	for _, t := range semuaTahap {
+		tahapPerProject[t.ProjectID] = append(tahapPerProject[t.ProjectID], t)
+	}
+
+	today := truncateToDate(time.Now())
+	laporan := []laporanTahapProject{}
+	for _, project := range projects {
+		tahap := tahapPerProject[project.ID]
+		if len(tahap) == 0 {
+			continue
+		}
+
+		row := laporanTahapProject{
+			ProjectID:      project.ID,
+			KodeProject:    derefString(project.KodeProject),
+			NamaPengadaan:  derefString(project.NamaPengadaan),
+			JenisPengadaan: derefString(project.JenisPengadaan),
+			TahapSaatIni:   derefString(project.TahapSaatIni),
+			Tahap:          []durasiTahap{},
+		}
+		for i := range tahap {
+			t := &tahap[i]
+			row.Tahap = append(row.Tahap, hitungDurasiTahap(t, today))
+
+			// Tahap aktif pertama yang belum selesai menentukan lama tertahan
+			if row.MulaiTahap == "" && t.SelesaiAktual == nil && t.MulaiAktual != nil {
+				row.MulaiTahap = t.MulaiAktual.Format("2006-01-02")
+				row.HariDiTahap = selisihHari(*t.MulaiAktual, today)
+				row.BatasHari = batasDefault
+				if t.BatasHari > 0 {
+					row.BatasHari = t.BatasHari
+				}
+				row.Tertahan = row.HariDiTahap > row.BatasHari
+			}
+		}
+
+		if hanyaTertahan && !row.Tertahan {
+			continue
+		}
+		laporan = append(laporan, row)
+	}
+	sort.SliceStable(laporan, func(i, j int) bool { return laporan[i].HariDiTahap > laporan[j].HariDiTahap })
+
+	c.JSON(http.StatusOK, gin.H{"laporan": laporan, "batas_hari": batasDefault})
+}
+
+// generateTahapProject menyalin pipeline jenis pengadaan ke project, tanggal TOR dan izin yang sudah ada ikut terisi
+func generateTahapProject(tx *gorm.DB, project *models.Project, username string) error {
+	var pipeline []models.TahapPengadaan
+	if project.JenisPengadaan != nil {
+		tx.Where("jenis_pengadaan = ?", *project.JenisPengadaan).Order("urutan asc").Find(&pipeline)
+	}
+	if len(pipeline) == 0 {
+		for i, nama := range defaultTahapPengadaan {
+			pipeline = append(pipeline, models.TahapPengadaan{Urutan: i + 1, Nama: nama})
+		}
+	}
+
+	for _, p := range pipeline {
+		tahap := models.TahapProject{
+			ProjectID: project.ID,
+			Urutan:    p.Urutan,
+			Nama:      p.Nama,
+			BatasHari: p.BatasHari,
+			UpdateBy:  username,
+		}
+		switch p.Nama {
+		case tahapTor:
+			tahap.MulaiAktual = project.TanggalTor
+			tahap.SelesaiAktual = project.TanggalTor
+		case tahapIzinPrinsip:
+			tahap.MulaiAktual = project.TanggalIzin
+			tahap.SelesaiAktual = project.TanggalIzin
+		}
+		if err := tx.Create(&tahap).Error; err != nil {
+			return err
+		}
+	}
+	return syncTahapSaatIni(tx, project)
+}
+
+// mulaiTahapBerikutnya memulai tahap sesudahnya saat tahap ini selesai
+func mulaiTahapBerikutnya(tx *gorm.DB, tahap *models.TahapProject) error {
+	if tahap.SelesaiAktual == nil {
+		return nil
+	}
+	var next models.TahapProject
+	err := tx.Where("project_id = ? AND urutan > ?", tahap.ProjectID, tahap.Urutan).Order("urutan asc").First(&next).Error
+	if err != nil || next.MulaiAktual != nil {
+		return nil
+	}
+	return tx.Model(&next).Update("mulai_aktual", tahap.SelesaiAktual).Error
+}
+
+// syncTahapDariProject menyalin tanggal TOR dan izin yang diubah langsung di project ke tahapnya,
+// supaya kedua sumber tanggal itu tidak berbeda
+func syncTahapDariProject(tx *gorm.DB, project *models.Project, username string) error {
+	var tahap []models.TahapProject
+	if err := tx.Where("project_id = ? AND nama IN ?", project.ID, []string{tahapTor, tahapIzinPrinsip}).Find(&tahap).Error; err != nil {
+		return err
+	}
+	if len(tahap) == 0 {
+		return nil
+	}
+	for i := range tahap {
+		t := &tahap[i]
+		tanggal := project.TanggalTor
+		if t.Nama == tahapIzinPrinsip {
+			tanggal = project.TanggalIzin
+		}
+		if tanggal == nil || (t.SelesaiAktual != nil && t.SelesaiAktual.Equal(*tanggal)) {
+			continue
+		}
+		t.SelesaiAktual = tanggal
+		if t.MulaiAktual == nil || t.MulaiAktual.After(*tanggal) {
+			t.MulaiAktual = tanggal
+		}
+		t.UpdateBy = username
+		if err := tx.Save(t).Error; err != nil {
+			return err
+		}
+		if err := mulaiTahapBerikutnya(tx, t); err != nil {
+			return err
+		}
+	}
+	return syncTahapSaatIni(tx, project)
+}
+
+// syncTahapSaatIni mengisi Project.TahapSaatIni dengan tahap pertama yang belum selesai
+func syncTahapSaatIni(tx *gorm.DB, project *models.Project) error {
+	var tahap []models.TahapProject
+	if err := tx.Where("project_id = ?", project.ID).Order("urutan asc").Find(&tahap).Error; err != nil {
+		return err
+	}
+
+	var saatIni *string
+	if len(tahap) > 0 {
+		selesai := "Selesai"
+		saatIni = &selesai
+		for i := range tahap {
+			if tahap[i].SelesaiAktual == nil {
+				saatIni = &tahap[i].Nama
+				break
+			}
+		}
+	}
+	project.TahapSaatIni = saatIni
+	return tx.Model(project).Updates(map[string]interface{}{
+		"tahap_saat_ini": saatIni,
+		"tanggal_tor":    project.TanggalTor,
+		"tanggal_izin":   project.TanggalIzin,
+	}).Error
+}
+
+func hitungDurasiTahap(t *models.TahapProject, today time.Time) durasiTahap {
+	d := durasiTahap{Nama: t.Nama, Status: t.Status()}
+	if t.MulaiAktual != nil {
+		akhir := today
+		if t.SelesaiAktual != nil {
+			akhir = *t.SelesaiAktual
+		}
+		hari := selisihHari(*t.MulaiAktual, akhir)
+		d.DurasiHari = &hari
+	}
+	if t.RencanaMulai != nil && t.RencanaSelesai != nil {
+		hari := selisihHari(*t.RencanaMulai, *t.RencanaSelesai)
+		d.RencanaHari = &hari
+	}
+	if t.RencanaSelesai != nil {
+		if t.SelesaiAktual != nil {
+			d.Terlambat = isLewatBatas(*t.RencanaSelesai, *t.SelesaiAktual)
+		} else {
+			d.Terlambat = isLewatBatas(*t.RencanaSelesai, today)
+		}
+	}
+	return d
+}
+
+func selisihHari(dari, sampai time.Time) int {
+	return int(truncateToDate(sampai).Sub(truncateToDate(dari)).Hours() / 24)
+}
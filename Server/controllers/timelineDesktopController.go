@@ -14,6 +14,8 @@ import (
 	"github.com/xuri/excelize/v2"
 )
 
+const sumberTimelineDesktop = "TimelineDesktop"
+
 // GetEventsTimeline retrieves all timeline events
 func GetEventsDesktop(c *gin.Context) {
 	var events []models.TimelineDesktop
@@ -47,13 +49,14 @@ func CreateEventDesktop(c *gin.Context) {
 		return
 	}
 
-	// Panggil fungsi SetNotification
-	SetNotification(event.Title, startTime, "TimelineWallpaperDesktop")
-
 	if err := initializers.DB.Create(&event).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+
+	// Panggil fungsi SetNotification
+	SetNotificationEvent(event.Title, startTime, "TimelineWallpaperDesktop", sumberTimelineDesktop, event.ID)
+	emitWebhook(eventTimelineDesktopDibuat, event)
 	c.JSON(http.StatusOK, event)
 }
 
@@ -75,6 +78,9 @@ func DeleteEventDesktop(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if err := batalkanPengingatEvent(initializers.DB, sumberTimelineDesktop, uint(id)); err != nil {
+		log.Printf("Error cancelling reminders for timeline desktop %d: %v", id, err)
+	}
 	c.Status(http.StatusNoContent)
 }
 
@@ -135,6 +141,12 @@ func ExportTimelineDesktopToExcel(c *gin.Context) {
 		resourceMap[resource.ID] = resource.Name
 	}
 
+	// Hari libur nasional dan cuti bersama diberi warna pada kalender
+	kalender, err := muatKalenderKerja(initializers.DB)
+	if err != nil {
+		log.Printf("Error loading hari libur for export: %v", err)
+	}
+
 	f := excelize.NewFile()
 	sheet := "Calendar 2024"
 	f.NewSheet(sheet)
@@ -148,7 +160,7 @@ func ExportTimelineDesktopToExcel(c *gin.Context) {
 	rowOffset := 0
 	colOffset := 0
 	for i, month := range months {
-		setMonthDataDesktop(f, sheet, month, rowOffset, colOffset, events_timeline, resourceMap)
+		setMonthDataDesktop(f, sheet, month, rowOffset, colOffset, events_timeline, resourceMap, kalender)
 		colOffset += 9 // Sesuaikan offset untuk bulan berikutnya dalam baris yang sama
 		if (i+1)%3 == 0 {
 			rowOffset += 18 // Pindah ke baris berikutnya setiap 3 bulan
@@ -173,7 +185,7 @@ func ExportTimelineDesktopToExcel(c *gin.Context) {
 	c.Writer.Write(buffer.Bytes())
 }
 
-func setMonthDataDesktop(f *excelize.File, sheet, month string, rowOffset, colOffset int, events []models.TimelineDesktop, resourceMap map[uint]string) {
+func setMonthDataDesktop(f *excelize.File, sheet, month string, rowOffset, colOffset int, events []models.TimelineDesktop, resourceMap map[uint]string, kalender *kalenderKerja) {
 	var (
 		monthStyle, titleStyle, dataStyle, blankStyle,
 		grayBlankStyle, grayDataStyle, wrapTextStyle int // Tambahkan variabel untuk wrapTextStyle
@@ -379,6 +391,7 @@ func setMonthDataDesktop(f *excelize.File, sheet, month string, rowOffset, colOf
 		fmt.Println(err)
 		return
 	}
+	tandaiHariLiburBulan(f, sheet, monthTime, rowOffset, colOffset, kalender)
 	// hide gridlines for the worksheet
 	disable := false
 	if err := f.SetSheetView(sheet, 0, &excelize.ViewOptions{
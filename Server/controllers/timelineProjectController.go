@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"fmt"
 	"log"
 	"net/http"
 	"project-its/initializers"
@@ -9,12 +10,15 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+const sumberTimelineProject = "TimelineProject"
+
 // GetEventsTimeline retrieves all timeline events
 func GetEventsProject(c *gin.Context) {
 	var events []models.TimelineProject
-	if err := initializers.DB.Find(&events).Error; err != nil {
+	if err := scopeTimelineProjectAktif(c, initializers.DB).Find(&events).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -44,13 +48,16 @@ func CreateEventProject(c *gin.Context) {
 		return
 	}
 
-	// Panggil fungsi SetNotification
-	SetNotification(event.Title, startTime, "TimelineProject")
+	// Event mengikuti project pemilik resource-nya
+	event.ProjectID = projectIDResource(uint(event.ResourceId))
 
 	if err := initializers.DB.Create(&event).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+
+	// Panggil fungsi SetNotification
+	SetNotificationEvent(event.Title, startTime, "TimelineProject", sumberTimelineProject, event.ID)
 	c.JSON(http.StatusOK, event)
 }
 
@@ -73,6 +80,9 @@ func DeleteEventProject(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if err := batalkanPengingatEvent(initializers.DB, sumberTimelineProject, uint(id)); err != nil {
+		log.Printf("Error cancelling reminders for timeline project %d: %v", id, err)
+	}
 	c.Status(http.StatusNoContent)
 }
 
@@ -81,7 +91,7 @@ func DeleteEventProject(c *gin.Context) {
 // GetResources retrieves all resources
 func GetResourcesProject(c *gin.Context) {
 	var resources []models.ResourceProject
-	if err := initializers.DB.Find(&resources).Error; err != nil {
+	if err := scopeTimelineProjectAktif(c, initializers.DB).Find(&resources).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -96,6 +106,11 @@ func CreateResourceProject(c *gin.Context) {
 		return
 	}
 
+	// Sub-resource ikut dimiliki project induknya
+	if resource.ProjectID == nil && resource.ParentID != 0 {
+		resource.ProjectID = projectIDResource(resource.ParentID)
+	}
+
 	if err := initializers.DB.Create(&resource).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -128,3 +143,127 @@ func DeleteResourceProject(c *gin.Context) {
 	log.Printf("Successfully deleted ResourceProject with ID: %d", id)
 	c.Status(http.StatusNoContent)
 }
+
+type projectTimelineRequest struct {
+	ParentID uint `json:"parent_id"`
+}
+
+// ProjectTimelineShow menampilkan resource dan event timeline milik satu project
+func ProjectTimelineShow(c *gin.Context) {
+	var project models.Project
+	if err := initializers.DB.First(&project, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+
+	resources, events := timelineProject(project.ID)
+	c.JSON(http.StatusOK, gin.H{"resources": resources, "events": events})
+}
+
+// ProjectTimelineCreate membuat resource timeline untuk project yang belum memilikinya
+func ProjectTimelineCreate(c *gin.Context) {
+	var requestBody projectTimelineRequest
+	c.ShouldBindJSON(&requestBody)
+
+	var project models.Project
+	if err := initializers.DB.First(&project, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+
+	var jumlah int64
+	initializers.DB.Model(&models.ResourceProject{}).Where("project_id = ?", project.ID).Count(&jumlah)
+	if jumlah > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Project sudah memiliki resource timeline"})
+		return
+	}
+
+	resource, err := buatResourceProject(initializers.DB, project, requestBody.ParentID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resource)
+}
+
+// ProjectArsipkan menyembunyikan project beserta timeline-nya tanpa menghapus data
+func ProjectArsipkan(c *gin.Context) {
+	setProjectDiarsipkan(c, true)
+}
+
+func ProjectAktifkan(c *gin.Context) {
+	setProjectDiarsipkan(c, false)
+}
+
+func setProjectDiarsipkan(c *gin.Context, diarsipkan bool) {
+	var project models.Project
+	if err := initializers.DB.First(&project, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+
+	if err := initializers.DB.Model(&project).Update("diarsipkan", diarsipkan).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update project"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"project": &project})
+}
+
+// buatResourceProject membuat resource timeline atas nama project
+func buatResourceProject(tx *gorm.DB, project models.Project, parentID uint) (models.ResourceProject, error) {
+	name := derefString(project.NamaPengadaan)
+	if name == "" {
+		name = derefString(project.KodeProject)
+	}
+	if name == "" {
+		name = fmt.Sprintf("Project %d", project.ID)
+	}
+
+	resource := models.ResourceProject{Name: name, ParentID: parentID, ProjectID: &project.ID}
+	err := tx.Create(&resource).Error
+	return resource, err
+}
+
+// hapusTimelineProject menghapus semua event dan resource milik project
+func hapusTimelineProject(tx *gorm.DB, projectID uint) error {
+	resourceIDs := tx.Model(&models.ResourceProject{}).Select("id").Where("project_id = ?", projectID)
+	var eventIDs []uint
+	if err := tx.Model(&models.TimelineProject{}).Where("project_id = ? OR resource_id IN (?)", projectID, resourceIDs).Pluck("id", &eventIDs).Error; err != nil {
+		return err
+	}
+	for _, id := range eventIDs {
+		if err := batalkanPengingatEvent(tx, sumberTimelineProject, id); err != nil {
+			return err
+		}
+	}
+	if err := tx.Where("project_id = ? OR resource_id IN (?)", projectID, resourceIDs).Delete(&models.TimelineProject{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("project_id = ?", projectID).Delete(&models.ResourceProject{}).Error
+}
+
+func timelineProject(projectID uint) ([]models.ResourceProject, []models.TimelineProject) {
+	resources := []models.ResourceProject{}
+	initializers.DB.Where("project_id = ?", projectID).Order("id asc").Find(&resources)
+
+	events := []models.TimelineProject{}
+	initializers.DB.Where("project_id = ?", projectID).Order("start asc").Find(&events)
+	return resources, events
+}
+
+func projectIDResource(resourceID uint) *uint {
+	var resource models.ResourceProject
+	if err := initializers.DB.First(&resource, resourceID).Error; err != nil {
+		return nil
+	}
+	return resource.ProjectID
+}
+
+// scopeTimelineProjectAktif menyembunyikan timeline project yang diarsipkan kecuali diminta dengan ?arsip=true
+func scopeTimelineProjectAktif(c *gin.Context, db *gorm.DB) *gorm.DB {
+	if c.Query("arsip") == "true" {
+		return db
+	}
+	return db.Where("project_id IS NULL OR project_id NOT IN (?)",
+		initializers.DB.Model(&models.Project{}).Select("id").Where("diarsipkan = ?", true))
+}
@@ -3,7 +3,9 @@ package controllers
 import (
 	"net/http"
 	"project-its/initializers"
+	"project-its/middleware"
 	"project-its/models"
+	"strings"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
@@ -12,10 +14,13 @@ import (
 )
 
 type requestUser struct {
-	Username string `json:"username"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
-	Info     string `json:"info"`
+	Username string  `json:"username"`
+	Email    string  `json:"email"`
+	Password string  `json:"password"`
+	Info     string  `json:"info"`
+	AtasanID *uint   `json:"atasan_id"` // 0 untuk menghapus atasan
+	Golongan string  `json:"golongan"`
+	Div      *string `json:"div"`
 }
 
 func Login(c *gin.Context) {
@@ -107,6 +112,9 @@ func Register(c *gin.Context) {
 	}
 
 	newUser.Password = string(hashedPassword)
+	// Divisi dan atasan diatur admin lewat UserUpdate
+	newUser.Div = ""
+	newUser.AtasanID = nil
 
 	result := initializers.DB.Create(&newUser)
 	if result.Error != nil {
@@ -199,6 +207,43 @@ func UserUpdate(c *gin.Context) {
 		users.Password = users.Password // gunakan nilai yang ada dari database
 	}
 
+	if requestBody.Golongan != "" {
+		users.Golongan = requestBody.Golongan
+	}
+
+	if requestBody.Div != nil {
+		// Divisi menentukan disposisi yang boleh diterima, hanya admin yang boleh mengubahnya
+		if !middleware.HasRole(c, roleAdmin) {
+			c.JSON(403, gin.H{"error": "Hanya admin yang dapat mengubah divisi"})
+			return
+		}
+		users.Div = strings.TrimSpace(*requestBody.Div)
+		initializers.DB.Model(&users).Update("div", users.Div)
+	}
+
+	if requestBody.AtasanID != nil {
+		// Atasan menentukan siapa yang menyetujui cuti, hanya admin yang boleh mengubahnya
+		if !middleware.HasRole(c, roleAdmin) {
+			c.JSON(403, gin.H{"error": "Hanya admin yang dapat mengubah atasan"})
+			return
+		}
+		if *requestBody.AtasanID == 0 {
+			users.AtasanID = nil
+			initializers.DB.Model(&users).Update("atasan_id", nil)
+		} else {
+			if *requestBody.AtasanID == users.ID {
+				c.JSON(400, gin.H{"error": "Atasan tidak boleh user itu sendiri"})
+				return
+			}
+			var atasan models.User
+			if err := initializers.DB.First(&atasan, *requestBody.AtasanID).Error; err != nil {
+				c.JSON(400, gin.H{"error": "Atasan tidak ditemukan"})
+				return
+			}
+			users.AtasanID = requestBody.AtasanID
+		}
+	}
+
 	initializers.DB.Model(&users).Updates(users)
 
 	c.JSON(200, gin.H{
@@ -0,0 +1,663 @@
+package controllers
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"project-its/initializers"
+	"project-its/models"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Tipe event yang dapat dilanggan webhook
+const (
+	eventMemoDibuat            = "memo.dibuat"
+	eventSuratMasukDibuat      = "surat_masuk.dibuat"
+	eventSuratKeluarDibuat     = "surat_keluar.dibuat"
+	eventBookingRapatDibuat    = "booking_rapat.dibuat"
+	eventBookingRapatDihapus   = "booking_rapat.dihapus"
+	eventJadwalRapatDibuat     = "jadwal_rapat.dibuat"
+	eventJadwalCutiDibuat      = "jadwal_cuti.dibuat"
+	eventTimelineDesktopDibuat = "timeline_desktop.dibuat"
+	eventPengajuanCutiDiputus  = "pengajuan_cuti.diputuskan"
+
+	// event untuk webhook pribadi pengguna, tidak dapat dilanggan webhook admin
+	eventNotifikasiBaru = "notifikasi.baru"
+)
+
+var daftarEventWebhook = []string{
+	eventMemoDibuat,
+	eventSuratMasukDibuat,
+	eventSuratKeluarDibuat,
+	eventBookingRapatDibuat,
+	eventBookingRapatDihapus,
+	eventJadwalRapatDibuat,
+	eventJadwalCutiDibuat,
+	eventTimelineDesktopDibuat,
+	eventPengajuanCutiDiputus,
+}
+
+const (
+	statusWebhookMenunggu = "Menunggu"
+	statusWebhookDiproses = "Diproses"
+	statusWebhookTerkirim = "Terkirim"
+	statusWebhookGagal    = "Gagal"
+
+	maksPercobaanWebhook = 10
+	batchWebhook         = 20
+	leaseWebhook         = 5 * time.Minute
+	maksResponWebhook    = 1024 // byte body respon yang disimpan di log
+)
+
+var httpClientWebhook = &http.Client{Timeout: 15 * time.Second}
+
+// httpClientWebhookPribadi dipakai webhook milik pengguna; alamat yang dituju dicek lagi saat koneksi dibuka
+// (termasuk setelah redirect) agar nama host yang di-resolve ulang ke jaringan internal tetap ditolak
+var httpClientWebhookPribadi = &http.Client{
+	Timeout: 15 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 10 * time.Second,
+			Control: func(network, address string, _ syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				if ip := net.ParseIP(host); ip == nil || !ipPublik(ip) {
+					return fmt.Errorf("alamat %s tidak diizinkan untuk webhook pribadi", host)
+				}
+				return nil
+			},
+		}).DialContext,
+	},
+}
+
+type webhookRequest struct {
+	Nama   *string  `json:"nama"`
+	URL    *string  `json:"url"`
+	Secret *string  `json:"secret"`
+	Events []string `json:"events"`
+	Aktif  *bool    `json:"aktif"`
+}
+
+// payloadWebhook adalah isi JSON yang dikirim ke setiap endpoint
+type payloadWebhook struct {
+	ID          string      `json:"id"`
+	Event       string      `json:"event"`
+	TerjadiPada time.Time   `json:"terjadi_pada"`
+	Data        interface{} `json:"data"`
+}
+
+// emitWebhook mengantrekan event untuk semua webhook aktif yang berlangganan; dipanggil controller setelah data tersimpan
+func emitWebhook(event string, data interface{}) {
+	var webhooks []models.Webhook
+	if err := initializers.DB.Where("aktif = ? AND user_id IS NULL", true).Find(&webhooks).Error; err != nil {
+		log.Printf("Error loading webhooks: %v", err)
+		return
+	}
+
+	var pengiriman []models.PengirimanWebhook
+	var payload []byte
+	eventID := idEventWebhook()
+	now := time.Now()
+	for _, w := range webhooks {
+		if !webhookBerlangganan(w, event) {
+			continue
+		}
+		if payload == nil {
+			var err error
+			payload, err = json.Marshal(payloadWebhook{ID: eventID, Event: event, TerjadiPada: now, Data: data})
+			if err != nil {
+				log.Printf("Error encoding webhook payload %s: %v", event, err)
+				return
+			}
+		}
+		pengiriman = append(pengiriman, models.PengirimanWebhook{
+			WebhookID:     w.ID,
+			EventID:       eventID,
+			Event:         event,
+			Payload:       string(payload),
+			JadwalKirim:   now,
+			Status:        statusWebhookMenunggu,
+			MaksPercobaan: maksPercobaanWebhook,
+		})
+	}
+	if len(pengiriman) == 0 {
+		return
+	}
+	if err := initializers.DB.Create(&pengiriman).Error; err != nil {
+		log.Printf("Error queueing webhook %s: %v", event, err)
+	}
+}
+
+func webhookBerlangganan(w models.Webhook, event string) bool {
+	for _, e := range strings.Split(w.Events, ",") {
+		e = strings.TrimSpace(e)
+		if e == "*" || e == event {
+			return true
+		}
+	}
+	return false
+}
+
+func idEventWebhook() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
+
+// tandaTanganWebhook = hex(HMAC-SHA256(secret, "<timestamp>.<body>")), dikirim di header X-Webhook-Signature
+func tandaTanganWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// ProsesPengirimanWebhook mengirim pengiriman yang jatuh tempo dengan pola klaim yang sama dengan worker pengingat
+func ProsesPengirimanWebhook() {
+	for {
+		pengiriman, err := klaimPengirimanWebhook()
+		if err != nil {
+			log.Printf("Error claiming webhook deliveries: %v", err)
+			return
+		}
+		for _, p := range pengiriman {
+			kirimPengirimanWebhook(p)
+		}
+		if len(pengiriman) < batchWebhook {
+			return
+		}
+	}
+}
+
+func klaimPengirimanWebhook() ([]models.PengirimanWebhook, error) {
+	var pengiriman []models.PengirimanWebhook
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("(status = ? AND jadwal_kirim <= ?) OR (status = ? AND dikunci_sampai < ?)",
+				statusWebhookMenunggu, now, statusWebhookDiproses, now).
+			Order("jadwal_kirim").Limit(batchWebhook).
+			Find(&pengiriman).Error; err != nil {
+			return err
+		}
+		if len(pengiriman) == 0 {
+			return nil
+		}
+		ids := make([]uint, 0, len(pengiriman))
+		for _, p := range pengiriman {
+			ids = append(ids, p.ID)
+		}
+		return tx.Model(&models.PengirimanWebhook{}).Where("id IN ?", ids).
+			Updates(map[string]interface{}{
+				"status":         statusWebhookDiproses,
+				"dikunci_oleh":   workerPengingatID,
+				"dikunci_sampai": now.Add(leaseWebhook),
+			}).Error
+	})
+	return pengiriman, err
+}
+
+func kirimPengirimanWebhook(p models.PengirimanWebhook) {
+	var webhook models.Webhook
+	var statusHTTP *int
+	var respon *string
+	var errKirim error
+	if err := initializers.DB.First(&webhook, p.WebhookID).Error; err != nil {
+		errKirim = fmt.Errorf("webhook tidak ditemukan: %w", err)
+	} else {
+		statusHTTP, respon, errKirim = postWebhook(webhook, p)
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"percobaan":      p.Percobaan + 1,
+		"status_http":    statusHTTP,
+		"respon":         respon,
+		"dikunci_oleh":   nil,
+		"dikunci_sampai": nil,
+	}
+	if errKirim == nil {
+		updates["status"] = statusWebhookTerkirim
+		updates["terkirim_pada"] = now
+		updates["error_terakhir"] = nil
+	} else {
+		log.Printf("Error delivering webhook %d (%s): %v", p.ID, p.Event, errKirim)
+		updates["error_terakhir"] = errKirim.Error()
+		if p.Percobaan+1 >= p.MaksPercobaan || !webhook.Aktif {
+			updates["status"] = statusWebhookGagal
+		} else {
+			updates["status"] = statusWebhookMenunggu
+			updates["jadwal_kirim"] = now.Add(backoffAntrean(p.Percobaan + 1))
+		}
+	}
+	if err := initializers.DB.Model(&models.PengirimanWebhook{}).
+		Where("id = ? AND status = ? AND dikunci_oleh = ?", p.ID, statusWebhookDiproses, workerPengingatID).
+		Updates(updates).Error; err != nil {
+		log.Printf("Error updating webhook delivery %d: %v", p.ID, err)
+	}
+}
+
+// postWebhook mengirim payload; hanya respon 2xx yang dianggap berhasil
+func postWebhook(webhook models.Webhook, p models.PengirimanWebhook) (*int, *string, error) {
+	body := []byte(p.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "project-its-webhook")
+	req.Header.Set("X-Webhook-Event", p.Event)
+	req.Header.Set("X-Webhook-Id", p.EventID)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatUint(uint64(p.ID), 10))
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", tandaTanganWebhook(webhook.Secret, timestamp, body))
+
+	client := httpClientWebhook
+	if webhook.UserID != nil {
+		client = httpClientWebhookPribadi
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	isi, _ := io.ReadAll(io.LimitReader(resp.Body, maksResponWebhook))
+	status := resp.StatusCode
+	respon := string(isi)
+	if status < 200 || status > 299 {
+		return &status, &respon, fmt.Errorf("endpoint membalas HTTP %d", status)
+	}
+	return &status, &respon, nil
+}
+
+// terapkanWebhookRequest memvalidasi dan menyalin field request ke webhook
+func terapkanWebhookRequest(w *models.Webhook, req webhookRequest) error {
+	if req.Nama != nil {
+		w.Nama = strings.TrimSpace(*req.Nama)
+	}
+	if req.URL != nil {
+		u, err := url.Parse(strings.TrimSpace(*req.URL))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New("URL webhook harus berupa http:// atau https://")
+		}
+		w.URL = u.String()
+	}
+	if req.Secret != nil && strings.TrimSpace(*req.Secret) != "" {
+		w.Secret = strings.TrimSpace(*req.Secret)
+	}
+	if req.Events != nil {
+		events := make([]string, 0, len(req.Events))
+		for _, e := range req.Events {
+			e = strings.TrimSpace(e)
+			if e == "" {
+				continue
+			}
+			if e != "*" && !containsString(daftarEventWebhook, e) {
+				return fmt.Errorf("tipe event %s tidak dikenal", e)
+			}
+			events = append(events, e)
+		}
+		w.Events = strings.Join(events, ",")
+	}
+	if req.Aktif != nil {
+		w.Aktif = *req.Aktif
+	}
+	if w.Nama == "" || w.URL == "" {
+		return errors.New("Nama dan URL webhook wajib diisi")
+	}
+	if w.Events == "" {
+		return errors.New("Minimal satu tipe event harus dipilih")
+	}
+	return nil
+}
+
+func containsString(daftar []string, s string) bool {
+	for _, d := range daftar {
+		if d == s {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookEventTypes menampilkan tipe event yang dapat dilanggan
+func WebhookEventTypes(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"events": daftarEventWebhook})
+}
+
+func WebhookIndex(c *gin.Context) {
+	var webhooks []models.Webhook
+	if err := initializers.DB.Where("user_id IS NULL").Order("id").Find(&webhooks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, webhooks)
+}
+
+// WebhookCreate mendaftarkan endpoint; secret dibuat otomatis bila kosong dan hanya ditampilkan sekali di respon ini
+func WebhookCreate(c *gin.Context) {
+	var req webhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	webhook := models.Webhook{Aktif: true, Secret: idEventWebhook() + idEventWebhook(), CreateBy: c.MustGet("username").(string)}
+	if err := terapkanWebhookRequest(&webhook, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := initializers.DB.Create(&webhook).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"webhook": webhook, "secret": webhook.Secret})
+}
+
+func WebhookUpdate(c *gin.Context) {
+	var req webhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var webhook models.Webhook
+	if err := initializers.DB.Where("user_id IS NULL").First(&webhook, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook tidak ditemukan"})
+		return
+	}
+	if err := terapkanWebhookRequest(&webhook, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := initializers.DB.Save(&webhook).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"webhook": webhook})
+}
+
+// WebhookDelete hanya untuk webhook admin; webhook pribadi dihapus pemiliknya lewat WebhookSayaDelete
+func WebhookDelete(c *gin.Context) {
+	var ditemukan bool
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("user_id IS NULL").Delete(&models.Webhook{}, c.Param("id"))
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		ditemukan = true
+		return tx.Where("webhook_id = ? AND status = ?", c.Param("id"), statusWebhookMenunggu).
+			Delete(&models.PengirimanWebhook{}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !ditemukan {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook tidak ditemukan"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// WebhookTest mengirim event webhook.test ke satu endpoint melalui antrean biasa
+func WebhookTest(c *gin.Context) {
+	var webhook models.Webhook
+	if err := initializers.DB.Where("user_id IS NULL").First(&webhook, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook tidak ditemukan"})
+		return
+	}
+	now := time.Now()
+	eventID := idEventWebhook()
+	payload, _ := json.Marshal(payloadWebhook{ID: eventID, Event: "webhook.test", TerjadiPada: now, Data: gin.H{"webhook_id": webhook.ID}})
+	pengiriman := models.PengirimanWebhook{
+		WebhookID:     webhook.ID,
+		EventID:       eventID,
+		Event:         "webhook.test",
+		Payload:       string(payload),
+		JadwalKirim:   now,
+		Status:        statusWebhookMenunggu,
+		MaksPercobaan: 1,
+	}
+	if err := initializers.DB.Create(&pengiriman).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, pengiriman)
+}
+
+// PengirimanWebhookIndex menampilkan log pengiriman, disaring dengan ?webhook_id=&status=&event=
+func PengirimanWebhookIndex(c *gin.Context) {
+	query := initializers.DB.Model(&models.PengirimanWebhook{})
+	if webhookID := c.Query("webhook_id"); webhookID != "" {
+		query = query.Where("webhook_id = ?", webhookID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if event := c.Query("event"); event != "" {
+		query = query.Where("event = ?", event)
+	}
+
+	var pengiriman []models.PengirimanWebhook
+	if err := query.Order("created_at desc").Limit(500).Find(&pengiriman).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, pengiriman)
+}
+
+// PengirimanWebhookKirimUlang membuat pengiriman baru dengan payload dan event ID yang sama,
+// log pengiriman lama tetap disimpan
+func PengirimanWebhookKirimUlang(c *gin.Context) {
+	var lama models.PengirimanWebhook
+	if err := initializers.DB.First(&lama, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pengiriman webhook tidak ditemukan"})
+		return
+	}
+	if lama.Status == statusWebhookMenunggu || lama.Status == statusWebhookDiproses {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Pengiriman masih dalam antrean"})
+		return
+	}
+
+	baru := models.PengirimanWebhook{
+		WebhookID:     lama.WebhookID,
+		EventID:       lama.EventID,
+		Event:         lama.Event,
+		Payload:       lama.Payload,
+		JadwalKirim:   time.Now(),
+		Status:        statusWebhookMenunggu,
+		MaksPercobaan: maksPercobaanWebhook,
+	}
+	if err := initializers.DB.Create(&baru).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, baru)
+}
+
+// antreWebhookNotifikasi mengirim notifikasi ke webhook pribadi penerima yang kanal webhook-nya langsung.
+// Untuk pengingat, event ID dibuat tetap agar pengiriman ulang pengingat tidak menggandakan antrean.
+func antreWebhookNotifikasi(tx *gorm.DB, n models.Notification, pengingat *models.PengingatNotifikasi) error {
+	var webhooks []models.Webhook
+	if err := tx.Where("aktif = ? AND user_id IS NOT NULL", true).Find(&webhooks).Error; err != nil || len(webhooks) == 0 {
+		return err
+	}
+
+	users, err := penggunaPenerimaNotifikasi(tx, n)
+	if err != nil {
+		return err
+	}
+	penerima := make(map[uint]bool, len(users))
+	ids := make([]uint, 0, len(users))
+	for _, u := range users {
+		penerima[u.ID] = true
+		ids = append(ids, u.ID)
+	}
+	prefs, err := muatPreferensi(tx, ids)
+	if err != nil {
+		return err
+	}
+
+	eventID := idEventWebhook()
+	data := gin.H{
+		"notification_id": n.ID,
+		"title":           n.Title,
+		"category":        n.Category,
+		"start":           n.Start,
+		"isi":             n.Isi,
+	}
+	if pengingat != nil {
+		eventID = fmt.Sprintf("pengingat-%d", pengingat.ID)
+		data["notification_id"] = pengingat.NotificationID
+		data["pengingat"] = labelOffsetPengingat(pengingat.OffsetMenit)
+	}
+	now := time.Now()
+	payload, err := json.Marshal(payloadWebhook{ID: eventID, Event: eventNotifikasiBaru, TerjadiPada: now, Data: data})
+	if err != nil {
+		return err
+	}
+
+	var pengiriman []models.PengirimanWebhook
+	for _, w := range webhooks {
+		if !penerima[*w.UserID] || prefs[*w.UserID].mode(kanalWebhook, n.Category) != modeLangsung {
+			continue
+		}
+		if pengingat != nil {
+			var ada int64
+			tx.Model(&models.PengirimanWebhook{}).Where("webhook_id = ? AND event_id = ?", w.ID, eventID).Count(&ada)
+			if ada > 0 {
+				continue
+			}
+		}
+		pengiriman = append(pengiriman, models.PengirimanWebhook{
+			WebhookID:     w.ID,
+			EventID:       eventID,
+			Event:         eventNotifikasiBaru,
+			Payload:       string(payload),
+			JadwalKirim:   now,
+			Status:        statusWebhookMenunggu,
+			MaksPercobaan: maksPercobaanWebhook,
+		})
+	}
+	if len(pengiriman) == 0 {
+		return nil
+	}
+	return tx.Create(&pengiriman).Error
+}
+
+// kirimPengingatWebhook adalah kanal pengingat ke webhook pribadi penerima
+func kirimPengingatWebhook(p models.PengingatNotifikasi) error {
+	n := models.Notification{
+		Title:    p.Title,
+		Start:    p.WaktuEvent,
+		Category: p.Category,
+		UserID:   p.UserID,
+		Role:     p.Role,
+	}
+	return antreWebhookNotifikasi(initializers.DB, n, &p)
+}
+
+// WebhookSayaIndex menampilkan webhook pribadi pengguna yang login
+func WebhookSayaIndex(c *gin.Context) {
+	var webhooks []models.Webhook
+	if err := initializers.DB.Where("user_id = ?", c.MustGet("userID").(uint)).Order("id").Find(&webhooks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, webhooks)
+}
+
+// WebhookSayaCreate mendaftarkan webhook pribadi yang menerima event notifikasi.baru milik pengguna
+func WebhookSayaCreate(c *gin.Context) {
+	var req webhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID := c.MustGet("userID").(uint)
+	req.Events = nil
+
+	webhook := models.Webhook{
+		Aktif:    true,
+		Secret:   idEventWebhook() + idEventWebhook(),
+		Events:   eventNotifikasiBaru,
+		UserID:   &userID,
+		CreateBy: c.MustGet("username").(string),
+	}
+	if err := terapkanWebhookRequest(&webhook, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := cekHostWebhookPublik(webhook.URL); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := initializers.DB.Create(&webhook).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"webhook": webhook, "secret": webhook.Secret})
+}
+
+// cekHostWebhookPublik menolak URL webhook pribadi yang mengarah ke loopback, link-local atau jaringan privat
+func cekHostWebhookPublik(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	ips, err := net.LookupIP(u.Hostname())
+	if err != nil || len(ips) == 0 {
+		return fmt.Errorf("host webhook %s tidak dapat di-resolve", u.Hostname())
+	}
+	for _, ip := range ips {
+		if !ipPublik(ip) {
+			return fmt.Errorf("host webhook %s mengarah ke alamat internal", u.Hostname())
+		}
+	}
+	return nil
+}
+
+func ipPublik(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified())
+}
+
+func WebhookSayaDelete(c *gin.Context) {
+	res := initializers.DB.Where("user_id = ?", c.MustGet("userID").(uint)).Delete(&models.Webhook{}, c.Param("id"))
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": res.Error.Error()})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook tidak ditemukan"})
+		return
+	}
+	initializers.DB.Where("webhook_id = ? AND status = ?", c.Param("id"), statusWebhookMenunggu).Delete(&models.PengirimanWebhook{})
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,23 @@
+package controllers
+
+import "testing"
+
+func TestTandaTanganWebhook(t *testing.T) {
+	tests := []struct {
+		secret    string
+		timestamp string
+		body      string
+		want      string
+	}{
+		{"rahasia", "1700000000", `{"event":"booking_rapat.dibuat"}`, "sha256=ec5fcb8dca13ed3f85325fe8d9091c404781ec76ffba5c19ce740a5da20bf1d1"},
+		{"rahasia", "1700000001", `{"event":"booking_rapat.dibuat"}`, "sha256=728957e28a805df98f0f023015d0d93fa1a5160cae60b94cbddaeef64f771002"},
+		{"key", "1700000000", "The quick brown fox", "sha256=94a2d29c55d07d9333ce48f2c48db8237d363908152c26bf57af92cd850f9901"},
+		{"", "0", "", "sha256=b849d5a581847b281957065739df36df2463d1977ea8d6e1e4e6cf33fadc68c3"},
+	}
+
+	for _, tt := range tests {
+		if got := tandaTanganWebhook(tt.secret, tt.timestamp, []byte(tt.body)); got != tt.want {
+			t.Errorf("tandaTanganWebhook(%q, %q, %q) = %q, ingin %q", tt.secret, tt.timestamp, tt.body, got, tt.want)
+		}
+	}
+}
@@ -39,9 +39,13 @@ func main() {
 	// Route yang tidak memerlukan autentikasi
 	r.POST("/register", controllers.Register)
 	r.POST("/login", controllers.Login)
+	// Feed kalender diakses aplikasi kalender, autentikasinya memakai token di URL
+	r.GET("/ics/:token/:kalender", controllers.FeedKalenderIcs)
 
 	// Terapkan middleware autentikasi ke semua route selanjutnya
 	r.Use(middleware.TokenAuthMiddleware())
+	// Route yang mengubah data master hanya untuk admin
+	admin := middleware.RequireRole("admin")
 
 	// Routes for User
 	store := cookie.NewStore([]byte("secret"))
@@ -78,9 +82,11 @@ func main() {
 
 	// Routes for Meeting
 	r.GET("/meetings", controllers.MeetingIndex)
+	r.GET("/meetings/overdue", controllers.MeetingOverdueReport)
 	r.POST("/meetings", controllers.MeetingCreate)
 	r.GET("/meetings/:id", controllers.MeetingShow)
 	r.PUT("/meetings/:id", controllers.MeetingUpdate)
+	r.PUT("/meetings/:id/status", controllers.MeetingUbahStatus)
 	r.DELETE("/meetings/:id", controllers.MeetingDelete)
 	r.GET("/exportMeeting", controllers.CreateExcelMeeting)
 	r.GET("/updateMeeting", controllers.UpdateSheetMeeting)
@@ -163,15 +169,72 @@ func main() {
 	r.DELETE("/deleteProject/:id/:filename", controllers.DeleteFileHandlerProject)
 	r.GET("/filesProject/:id", controllers.GetFilesByIDProject)
 
+	// Anggaran & realisasi project
+	r.GET("/Project/ringkasanAnggaran", controllers.ProjectRingkasanAnggaran)
+	r.GET("/Project/:id/anggaran", controllers.ProjectAnggaranShow)
+	r.POST("/Project/:id/anggaran", controllers.AnggaranProjectCreate)
+	r.PUT("/AnggaranProject/:id", controllers.AnggaranProjectUpdate)
+	r.DELETE("/AnggaranProject/:id", controllers.AnggaranProjectDelete)
+	r.POST("/Project/:id/realisasi", controllers.RealisasiProjectCreate)
+	r.PUT("/RealisasiProject/:id", controllers.RealisasiProjectUpdate)
+	r.DELETE("/RealisasiProject/:id", controllers.RealisasiProjectDelete)
+
+	// Tahapan pengadaan project
+	r.GET("/TahapPengadaan", controllers.TahapPengadaanIndex)
+	r.POST("/TahapPengadaan", admin, controllers.TahapPengadaanCreate)
+	r.PUT("/TahapPengadaan/:id", admin, controllers.TahapPengadaanUpdate)
+	r.DELETE("/TahapPengadaan/:id", admin, controllers.TahapPengadaanDelete)
+	r.GET("/Project/laporanTahap", controllers.LaporanTahapProject)
+	r.GET("/Project/:id/tahap", controllers.ProjectTahapIndex)
+	r.POST("/Project/:id/tahap", controllers.ProjectTahapGenerate)
+	r.PUT("/TahapProject/:id", controllers.TahapProjectUpdate)
+
+	// Timeline & arsip project
+	r.GET("/Project/:id/timeline", controllers.ProjectTimelineShow)
+	r.POST("/Project/:id/timeline", controllers.ProjectTimelineCreate)
+	r.PUT("/Project/:id/arsipkan", controllers.ProjectArsipkan)
+	r.PUT("/Project/:id/aktifkan", controllers.ProjectAktifkan)
+
+	// Stream realtime notifikasi dan perubahan data
+	r.GET("/realtime", controllers.StreamRealtime)
+
 	// Notif Calendar
 	r.GET("/notifications", controllers.GetNotifications)
+	r.GET("/notifications/unread-count", controllers.GetNotificationsUnreadCount)
+	r.PUT("/notifications/read-all", controllers.MarkAllNotificationsRead)
+	r.PUT("/notifications/:id/read", controllers.MarkNotificationRead)
+	r.PUT("/notifications/:id/unread", controllers.MarkNotificationUnread)
 	r.DELETE("/notifications/:id", controllers.DeleteNotification)
+	r.GET("/pengingat-notifikasi", controllers.GetPengingatNotifikasi)
+	r.POST("/pengingat-notifikasi/:id/ulang", controllers.UlangiPengingatNotifikasi)
+
+	// Antrean email dan webhook keluar, hanya admin
+	r.GET("/email-keluar", admin, controllers.GetEmailKeluar)
+	r.POST("/email-keluar/uji", admin, controllers.KirimEmailUji)
+	r.POST("/email-keluar/:id/ulang", admin, controllers.UlangiEmailKeluar)
+	r.GET("/webhooks/events", admin, controllers.WebhookEventTypes)
+	r.GET("/webhooks", admin, controllers.WebhookIndex)
+	r.POST("/webhooks", admin, controllers.WebhookCreate)
+	r.PUT("/webhooks/:id", admin, controllers.WebhookUpdate)
+	r.DELETE("/webhooks/:id", admin, controllers.WebhookDelete)
+	r.POST("/webhooks/:id/test", admin, controllers.WebhookTest)
+	r.GET("/webhook-pengiriman", admin, controllers.PengirimanWebhookIndex)
+	r.POST("/webhook-pengiriman/:id/kirim-ulang", admin, controllers.PengirimanWebhookKirimUlang)
+
+	// Preferensi notifikasi, ringkasan harian dan webhook pribadi
+	r.GET("/preferensi-notifikasi", controllers.GetPreferensiNotifikasi)
+	r.PUT("/preferensi-notifikasi", controllers.SetPreferensiNotifikasi)
+	r.GET("/ringkasan-harian/pratinjau", controllers.PratinjauRingkasanHarian)
+	r.GET("/webhook-saya", controllers.WebhookSayaIndex)
+	r.POST("/webhook-saya", controllers.WebhookSayaCreate)
+	r.DELETE("/webhook-saya/:id", controllers.WebhookSayaDelete)
 
 	//Timeline Project routes
 	r.GET("/timelineProject", controllers.GetEventsProject)
 	r.POST("/timelineProject", controllers.CreateEventProject)
 	r.DELETE("/timelineProject/:id", controllers.DeleteEventProject)
 	r.GET("/resourceProject", controllers.GetResourcesProject)
+	r.GET("/exportTimelineProject", controllers.ExportTimelineProjectGantt)
 	r.POST("/resourceProject", controllers.CreateResourceProject)
 	r.DELETE("/resourceProject/:id", controllers.DeleteResourceProject)
 
@@ -187,13 +250,31 @@ func main() {
 	//Booking Rapat routes
 	r.GET("/booking-rapat", controllers.GetEventsBookingRapat)
 	r.POST("/booking-rapat", controllers.CreateEventBookingRapat)
+	r.PUT("/booking-rapat/:id", controllers.UpdateEventBookingRapat)
 	r.DELETE("/booking-rapat/:id", controllers.DeleteEventBookingRapat)
+	r.POST("/booking-rapat/:id/pengecualian", controllers.PengecualianBookingRapatCreate)
+	r.DELETE("/booking-rapat/:id/pengecualian/:pid", controllers.PengecualianBookingRapatDelete)
 	r.GET("/exportBookingRapat", controllers.ExportBookingRapatToExcel)
 
+	// Feed iCalendar milik user login
+	r.GET("/kalender/feed", controllers.FeedKalenderShow)
+	r.POST("/kalender/feed/reset", controllers.FeedKalenderReset)
+	r.POST("/kalender/import", controllers.ImportKalenderIcs)
+
+	// Ruang rapat routes
+	r.GET("/RuangRapat", controllers.RuangRapatIndex)
+	r.GET("/RuangRapat/tersedia", controllers.RuangRapatTersedia)
+	r.POST("/RuangRapat", admin, controllers.RuangRapatCreate)
+	r.GET("/RuangRapat/:id", controllers.RuangRapatShow)
+	r.PUT("/RuangRapat/:id", admin, controllers.RuangRapatUpdate)
+	r.DELETE("/RuangRapat/:id", admin, controllers.RuangRapatDelete)
+
 	// jadwal Rapat routes
 	r.GET("/jadwal-rapat", controllers.GetEventsRapat)
 	r.POST("/jadwal-rapat", controllers.CreateEventRapat)
 	r.DELETE("/jadwal-rapat/:id", controllers.DeleteEventRapat)
+	r.POST("/jadwal-rapat/:id/pengecualian", controllers.PengecualianJadwalRapatCreate)
+	r.DELETE("/jadwal-rapat/:id/pengecualian/:pid", controllers.PengecualianJadwalRapatDelete)
 	r.GET("/exportRapat", controllers.ExportJadwalRapatToExcel)
 
 	// Jadwal Cuti routes
@@ -202,12 +283,51 @@ func main() {
 	r.DELETE("/jadwal-cuti/:id", controllers.DeleteEventCuti)
 	r.GET("/exportCuti", controllers.ExportJadwalCutiToExcel)
 
+	// Pengajuan Cuti routes
+	r.GET("/jenis-cuti", controllers.JenisCutiIndex)
+	r.POST("/jenis-cuti", admin, controllers.JenisCutiCreate)
+	r.PUT("/jenis-cuti/:id", admin, controllers.JenisCutiUpdate)
+	r.DELETE("/jenis-cuti/:id", admin, controllers.JenisCutiDelete)
+	r.GET("/kuota-cuti", controllers.KuotaCutiIndex)
+	r.PUT("/kuota-cuti", admin, controllers.KuotaCutiSet)
+	r.GET("/pengajuan-cuti", controllers.PengajuanCutiIndex)
+	r.POST("/pengajuan-cuti", controllers.PengajuanCutiCreate)
+	r.GET("/pengajuan-cuti/:id", controllers.PengajuanCutiShow)
+	r.PUT("/pengajuan-cuti/:id/setujui", controllers.PengajuanCutiSetujui)
+	r.PUT("/pengajuan-cuti/:id/tolak", controllers.PengajuanCutiTolak)
+	r.PUT("/pengajuan-cuti/:id/batalkan", controllers.PengajuanCutiBatalkan)
+	r.GET("/saldo-cuti", controllers.SaldoCutiSaya)
+	r.GET("/saldo-cuti/:user_id", controllers.SaldoCutiUser)
+
+	// Hari Libur routes
+	r.GET("/hari-libur", controllers.HariLiburIndex)
+	r.POST("/hari-libur", admin, controllers.HariLiburCreate)
+	r.PUT("/hari-libur/:id", admin, controllers.HariLiburUpdate)
+	r.DELETE("/hari-libur/:id", admin, controllers.HariLiburDelete)
+	r.POST("/importHariLibur", admin, controllers.ImportHariLibur)
+	r.GET("/hari-kerja", controllers.HitungHariKerja)
+
+	// Ketersediaan tim routes
+	r.GET("/ketersediaan", controllers.KetersediaanTim)
+	r.GET("/exportKetersediaan", controllers.ExportKetersediaanTim)
+
 	//Perdin routes
 	r.POST("/Perdin", controllers.PerdinCreate)
 	r.PUT("/Perdin/:id", controllers.PerdinUpdate)
 	r.GET("/Perdin", controllers.PerdinIndex)
 	r.DELETE("/Perdin/:id", controllers.PerdinDelete)
 	r.GET("/Perdin/:id", controllers.PerdinShow)
+	r.GET("/Perdin/:id/rincian", controllers.PerdinRincian)
+	r.POST("/Perdin/:id/hitung-ulang", controllers.PerdinHitungUlang)
+	r.GET("/Perdin/:id/penyelesaian", controllers.PenyelesaianPerdinShow)
+	r.PUT("/Perdin/:id/penyelesaian", controllers.PenyelesaianPerdinSimpan)
+	r.POST("/Perdin/:id/penyelesaian/ajukan", controllers.PenyelesaianPerdinAjukan)
+	r.PUT("/Perdin/:id/penyelesaian/setujui", controllers.PenyelesaianPerdinSetujui)
+	r.PUT("/Perdin/:id/penyelesaian/tolak", controllers.PenyelesaianPerdinTolak)
+	r.GET("/exportPenyelesaianPerdin/:id", controllers.ExportPenyelesaianPerdin)
+	r.GET("/tarif-uang-harian", controllers.TarifUangHarianIndex)
+	r.PUT("/tarif-uang-harian", admin, controllers.TarifUangHarianSet)
+	r.DELETE("/tarif-uang-harian/:id", admin, controllers.TarifUangHarianDelete)
 	r.GET("/exportPerdin", controllers.CreateExcelPerdin)
 	r.GET("/updatePerdin", controllers.UpdateSheetPerdin)
 	r.POST("/uploadPerdin", controllers.ImportExcelPerdin)
@@ -232,6 +352,26 @@ func main() {
 	r.DELETE("/deleteSuratMasuk/:id/:filename", controllers.DeleteFileHandlerSuratMasuk)
 	r.GET("/filesSuratMasuk/:id", controllers.GetFilesByIDSuratMasuk)
 
+	// Disposisi Surat Masuk routes
+	r.GET("/SuratMasuk/:id/disposisi", controllers.DisposisiIndex)
+	r.POST("/SuratMasuk/:id/disposisi", controllers.DisposisiCreate)
+	r.GET("/exportDisposisi/:id", controllers.ExportDisposisiHandler)
+	r.GET("/disposisiSaya", controllers.DisposisiInbox)
+	r.GET("/Disposisi/:id", controllers.DisposisiShow)
+	r.DELETE("/Disposisi/:id", controllers.DisposisiDelete)
+	r.PUT("/Disposisi/:id/terima", controllers.DisposisiTerima)
+	r.PUT("/Disposisi/:id/tindakLanjut", controllers.DisposisiTindakLanjut)
+	r.PUT("/Disposisi/:id/laporan", controllers.DisposisiLaporan)
+	r.POST("/Disposisi/:id/teruskan", controllers.DisposisiTeruskan)
+
+	// SLA balasan Surat Masuk routes
+	r.GET("/SuratMasuk/overdue", controllers.SuratMasukOverdueReport)
+	r.GET("/SuratMasuk/:id/balasan", controllers.SuratMasukBalasan)
+	r.GET("/SlaSurat", controllers.SlaSuratIndex)
+	r.POST("/SlaSurat", admin, controllers.SlaSuratCreate)
+	r.PUT("/SlaSurat/:id", admin, controllers.SlaSuratUpdate)
+	r.DELETE("/SlaSurat/:id", admin, controllers.SlaSuratDelete)
+
 	//Surat  Keluar routes
 	r.POST("/SuratKeluar", controllers.SuratKeluarCreate)
 	r.PUT("/SuratKeluar/:id", controllers.SuratKeluarUpdate)
@@ -261,5 +401,50 @@ func main() {
 	r.GET("/download/:id/:filename", controllers.DownloadFileHandlerArsip)
 	r.DELETE("/delete/:id/:filename", controllers.DeleteFileHandlerArsip)
 
+	// Routes for Box Arsip & lokasi fisik
+	r.GET("/BoxArsip", controllers.BoxArsipIndex)
+	r.POST("/BoxArsip", controllers.BoxArsipCreate)
+	r.GET("/BoxArsip/:id", controllers.BoxArsipShow)
+	r.PUT("/BoxArsip/:id", controllers.BoxArsipUpdate)
+	r.DELETE("/BoxArsip/:id", controllers.BoxArsipDelete)
+	r.GET("/BoxArsip/:id/isi", controllers.BoxArsipIsi)
+	r.GET("/Arsip/:id/lokasi", controllers.ArsipLokasi)
+	r.PUT("/Arsip/:id/pindah", controllers.ArsipPindah)
+	r.GET("/exportBoxArsip/:id", controllers.ExportBoxArsipHandler)
+	r.GET("/exportLokasiArsip/:id", controllers.ExportLokasiArsipHandler)
+
+	// Routes for Peminjaman Arsip
+	r.GET("/PeminjamanArsip", controllers.PeminjamanArsipIndex)
+	r.POST("/PeminjamanArsip", controllers.PeminjamanArsipAjukan)
+	r.GET("/PeminjamanArsip/overdue", controllers.PeminjamanArsipOverdueReport)
+	r.GET("/PeminjamanArsip/:id", controllers.PeminjamanArsipShow)
+	r.DELETE("/PeminjamanArsip/:id", controllers.PeminjamanArsipDelete)
+	r.PUT("/PeminjamanArsip/:id/setujui", admin, controllers.PeminjamanArsipSetujui)
+	r.PUT("/PeminjamanArsip/:id/tolak", admin, controllers.PeminjamanArsipTolak)
+	r.PUT("/PeminjamanArsip/:id/serahkan", admin, controllers.PeminjamanArsipSerahkan)
+	r.PUT("/PeminjamanArsip/:id/kembalikan", admin, controllers.PeminjamanArsipKembalikan)
+	r.GET("/Arsip/:id/peminjam", controllers.ArsipPeminjam)
+	r.GET("/Arsip/:id/peminjaman", controllers.ArsipRiwayatPeminjaman)
+
+	// Routes for Retensi (JRA) & Pemusnahan Arsip
+	r.GET("/RetensiArsip", controllers.RetensiArsipIndex)
+	r.POST("/RetensiArsip", admin, controllers.RetensiArsipCreate)
+	r.GET("/RetensiArsip/jatuhTempo", controllers.RetensiArsipJatuhTempo)
+	r.POST("/RetensiArsip/proses", admin, controllers.RetensiArsipProses)
+	r.PUT("/RetensiArsip/:id", admin, controllers.RetensiArsipUpdate)
+	r.DELETE("/RetensiArsip/:id", admin, controllers.RetensiArsipDelete)
+	r.GET("/PemusnahanArsip", controllers.PemusnahanArsipIndex)
+	r.POST("/PemusnahanArsip", controllers.PemusnahanArsipAjukan)
+	r.GET("/PemusnahanArsip/:id", controllers.PemusnahanArsipShow)
+	r.DELETE("/PemusnahanArsip/:id", controllers.PemusnahanArsipDelete)
+	r.PUT("/PemusnahanArsip/:id/setujui", admin, controllers.PemusnahanArsipSetujui)
+	r.PUT("/PemusnahanArsip/:id/tolak", admin, controllers.PemusnahanArsipTolak)
+
+	// Event realtime (SSE) lintas instance lewat LISTEN/NOTIFY PostgreSQL
+	controllers.StartRealtime()
+
+	// Jalankan job berkala (cek SLA, pengingat, dll)
+	controllers.StartSchedulers()
+
 	r.Run()
 }
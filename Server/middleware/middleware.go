@@ -44,23 +44,18 @@ func TokenAuthMiddleware() gin.HandlerFunc {
 		}
 	}
 }
+
+// RequireRole dipasang setelah TokenAuthMiddleware, yang sudah menaruh role dari token ke context
 func RequireRole(role string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		claims, exists := c.Get("claims")
+		claim, exists := c.Get("role")
 		if !exists {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 			c.Abort()
 			return
 		}
 
-		mapClaims, ok := claims.(jwt.MapClaims)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-			c.Abort()
-			return
-		}
-
-		userRole, ok := mapClaims["role"].(string)
+		userRole, ok := claim.(string)
 		if !ok || userRole != role {
 			c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
 			c.Abort()
@@ -68,3 +63,10 @@ func RequireRole(role string) gin.HandlerFunc {
 		}
 	}
 }
+
+// HasRole dipakai controller yang hanya sebagian aksinya dibatasi role, mis. mengubah atasan user
+func HasRole(c *gin.Context, role string) bool {
+	claim, _ := c.Get("role")
+	userRole, ok := claim.(string)
+	return ok && userRole == role
+}
@@ -15,6 +15,14 @@ func init() {
 
 func main() {
 
+	// Anggaran project dulu disimpan sebagai teks; sisakan angkanya saja sebelum kolom diubah ke numeric
+	initializers.DB.Exec(`DO $$
+BEGIN
+	IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'projects' AND column_name = 'anggaran' AND data_type = 'text') THEN
+		UPDATE projects SET anggaran = NULLIF(regexp_replace(anggaran, '[^0-9]', '', 'g'), '');
+	END IF;
+END $$;`)
+
 	initializers.DB.AutoMigrate(
 		&models.User{},
 		&models.UserToken{},
@@ -38,6 +46,95 @@ func main() {
 		&models.Meeting{},
 		&models.MeetingSchedule{},
 		&models.File{},
+		&models.Disposisi{},
+		&models.SlaSurat{},
+		&models.BoxArsip{},
+		&models.MutasiArsip{},
+		&models.PeminjamanArsip{},
+		&models.RetensiArsip{},
+		&models.PemusnahanArsip{},
+		&models.PemusnahanArsipItem{},
+		&models.AnggaranProject{},
+		&models.RealisasiProject{},
+		&models.TahapPengadaan{},
+		&models.TahapProject{},
+		&models.RuangRapat{},
+		&models.PengecualianJadwal{},
+		&models.FeedKalender{},
+		&models.ImportKalender{},
+		&models.JenisCuti{},
+		&models.KuotaCuti{},
+		&models.PengajuanCuti{},
+		&models.HariLibur{},
+		&models.PerdinPeserta{},
+		&models.PerdinBiaya{},
+		&models.TarifUangHarian{},
+		&models.PenyelesaianPerdin{},
+		&models.RealisasiPerdin{},
+		&models.PengingatNotifikasi{},
+		&models.StatusNotifikasi{},
+		&models.EmailKeluar{},
+		&models.Webhook{},
+		&models.PengirimanWebhook{},
+		&models.PreferensiNotifikasi{},
+		&models.RingkasanHarian{},
+	)
+
+	// No box arsip lama berupa teks bebas; buat box untuk setiap no box yang belum ada lalu tautkan arsipnya
+	initializers.DB.Exec(`INSERT INTO box_arsips (created_at, updated_at, no_box, kapasitas, status, create_by)
+	SELECT now(), now(), a.no_box, 0, 'Aktif', 'migrasi'
+	FROM (SELECT DISTINCT TRIM(no_box) AS no_box FROM arsips
+		WHERE deleted_at IS NULL AND box_id IS NULL AND TRIM(COALESCE(no_box, '')) <> '') a
+	WHERE NOT EXISTS (SELECT 1 FROM box_arsips b WHERE b.no_box = a.no_box AND b.deleted_at IS NULL)`)
+	initializers.DB.Exec(`UPDATE arsips a SET box_id = b.id, no_box = b.no_box
+	FROM box_arsips b
+	WHERE a.box_id IS NULL AND a.deleted_at IS NULL AND b.deleted_at IS NULL AND b.no_box = TRIM(a.no_box)`)
+
+	// End event all day disimpan eksklusif seperti endStr FullCalendar. Booking lama yang belum punya
+	// start_at/end_at diisi agar ikut cek bentrok; booking all day dihitung ulang dari Start/End.
+	initializers.DB.Exec(`UPDATE booking_rapats
+	SET start_at = start::date::timestamp AT TIME ZONE 'Asia/Jakarta',
+		end_at = GREATEST(NULLIF("end", '')::date, start::date + 1)::timestamp AT TIME ZONE 'Asia/Jakarta'
+	WHERE all_day AND start ~ '^\d{4}-\d{2}-\d{2}$' AND COALESCE("end", '') ~ '^(\d{4}-\d{2}-\d{2})?$'`)
+	initializers.DB.Exec(`UPDATE booking_rapats
+	SET start_at = start::timestamptz,
+		end_at = COALESCE(NULLIF("end", '')::timestamptz, start::timestamptz + interval '1 hour')
+	WHERE NOT all_day AND start_at IS NULL AND start ~ '^\d{4}-\d{2}-\d{2}T' AND COALESCE("end", '') ~ '^(\d{4}-\d{2}-\d{2}T.*)?$'`)
+	// Timeline project lama belum punya project_id: resource teratas ditautkan lewat nama yang cocok dengan
+	// tepat satu project, turunannya mewarisi project induk, lalu event mengikuti resource-nya
+	initializers.DB.Exec(`UPDATE resource_projects r SET project_id = m.project_id
+	FROM (SELECT r2.id, MIN(p.id) AS project_id
+		FROM resource_projects r2 JOIN projects p ON r2.name IN (p.nama_pengadaan, p.kode_project)
+		WHERE r2.project_id IS NULL AND r2.parent_id = 0
+		GROUP BY r2.id HAVING COUNT(DISTINCT p.id) = 1) m
+	WHERE r.id = m.id`)
+	initializers.DB.Exec(`WITH RECURSIVE turunan AS (
+		SELECT id, project_id FROM resource_projects WHERE project_id IS NOT NULL
+		UNION
+		SELECT r.id, t.project_id FROM resource_projects r JOIN turunan t ON r.parent_id = t.id WHERE r.project_id IS NULL
 	)
+	UPDATE resource_projects r SET project_id = t.project_id FROM turunan t WHERE r.id = t.id AND r.project_id IS NULL`)
+	initializers.DB.Exec(`UPDATE timeline_projects e SET project_id = r.project_id
+	FROM resource_projects r
+	WHERE e.resource_id = r.id AND e.project_id IS NULL AND r.project_id IS NOT NULL`)
+
+	// Pengingat dulu hanya goroutine di memori; buat antrean pengingat untuk notifikasi event yang masih akan datang.
+	// Notifikasi hasil pengingat itu sendiri dilewati, konflik berarti pengingat sudah ada.
+	initializers.DB.Exec(`INSERT INTO pengingat_notifikasis
+		(created_at, updated_at, notification_id, title, category, user_id, role, waktu_event, offset_menit, jadwal_kirim, status, percobaan, maks_percobaan, kanal_terkirim)
+	SELECT now(), now(), n.id, n.title, n.category, n.user_id, n.role, n.start, o.menit, n.start - o.menit * interval '1 minute', 'Menunggu', 0, 5, ''
+	FROM notifications n CROSS JOIN (VALUES (1440), (60)) AS o(menit)
+	WHERE n.start - o.menit * interval '1 minute' > now()
+		AND COALESCE(n.sumber, '') <> 'PengingatNotifikasi' AND n.title NOT LIKE 'Pengingat % lagi: %'
+	ON CONFLICT DO NOTHING`)
+
+	// Jenis cuti bawaan, kuota bisa diubah lewat menu jenis cuti
+	for _, jenis := range []models.JenisCuti{
+		{Kode: "TAHUNAN", Nama: "Cuti Tahunan", KuotaTahunan: 12, Color: "#2596be"},
+		{Kode: "SAKIT", Nama: "Cuti Sakit", KuotaTahunan: 0, Color: "#e74c3c"},
+		{Kode: "KHUSUS", Nama: "Cuti Khusus", KuotaTahunan: 3, Color: "#8e44ad"},
+	} {
+		initializers.DB.Where(models.JenisCuti{Kode: jenis.Kode}).FirstOrCreate(&jenis)
+	}
 
 }
@@ -1,7 +1,12 @@
 package models
 
 import (
+	"database/sql/driver"
 	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -15,6 +20,9 @@ type User struct {
 	Password string
 	Role     string
 	Info     string
+	AtasanID *uint  `json:"atasan_id"` // atasan yang menyetujui cuti
+	Golongan string `json:"golongan"`  // golongan/grade untuk tarif uang harian perdin
+	Div      string `json:"div"`       // divisi, penerima disposisi yang ditujukan ke divisi
 }
 
 type UserToken struct {
@@ -205,51 +213,85 @@ type Meeting struct {
 
 func (i *Meeting) MarshalJSON() ([]byte, error) {
 	type Alias Meeting
-	tanggalTargetFormatted := i.TanggalTarget.Format("2006-01-02")
-	tanggalActualFormatted := i.TanggalActual.Format("2006-01-02")
+	// TanggalActual kosong selama task belum Done
 	return json.Marshal(&struct {
-		TanggalTarget *string `json:"tanggal_target"`
-		TanggalActual *string `json:"tanggal_actual"`
+		TanggalTarget string `json:"tanggal_target"`
+		TanggalActual string `json:"tanggal_actual"`
 		*Alias
 	}{
-		TanggalTarget: &tanggalTargetFormatted,
-		TanggalActual: &tanggalActualFormatted,
+		TanggalTarget: FormatTanggal(i.TanggalTarget, "2006-01-02"),
+		TanggalActual: FormatTanggal(i.TanggalActual, "2006-01-02"),
 		Alias:         (*Alias)(i),
 	})
 }
 
 // model for perdin
 type Perdin struct {
-	ID        uint       `gorm:"primaryKey"`
-	CreatedAt *time.Time `gorm:"autoCreateTime"`
-	UpdatedAt *time.Time `gorm:"autoUpdateTime"`
-	NoPerdin  *string    `json:"no_perdin"`
-	Tanggal   *time.Time `json:"-"`
-	Hotel     *string    `json:"hotel"`
-	Transport *string    `json:"transport"`
-	CreateBy  string     `json:"create_by"`
+	ID               uint       `gorm:"primaryKey"`
+	CreatedAt        *time.Time `gorm:"autoCreateTime"`
+	UpdatedAt        *time.Time `gorm:"autoUpdateTime"`
+	NoPerdin         *string    `json:"no_perdin"`
+	Tanggal          *time.Time `json:"-"`
+	Hotel            *string    `json:"hotel"`
+	Transport        *string    `json:"transport"`
+	CreateBy         string     `json:"create_by"`
+	Tujuan           *string    `json:"tujuan"`
+	Keperluan        *string    `json:"keperluan"`
+	TanggalBerangkat *time.Time `json:"-"`
+	TanggalKembali   *time.Time `json:"-"`
+	TotalEstimasi    Amount     `gorm:"type:numeric(18,2);default:0" json:"total_estimasi"` // dihitung server dari PerdinBiaya
 }
 
 func (i *Perdin) MarshalJSON() ([]byte, error) {
 	type Alias Perdin
-	if i.Tanggal == nil {
-		return json.Marshal(&struct {
-			Tanggal string `json:"tanggal"`
-			*Alias
-		}{
-			Tanggal: "",
-			Alias:   (*Alias)(i),
-		})
-	} else {
-		tanggalFormatted := i.Tanggal.Format("2006-01-02")
-		return json.Marshal(&struct {
-			Tanggal string `json:"tanggal"`
-			*Alias
-		}{
-			Tanggal: tanggalFormatted,
-			Alias:   (*Alias)(i),
-		})
-	}
+	return json.Marshal(&struct {
+		Tanggal          string `json:"tanggal"`
+		TanggalBerangkat string `json:"tanggal_berangkat"`
+		TanggalKembali   string `json:"tanggal_kembali"`
+		*Alias
+	}{
+		Tanggal:          FormatTanggal(i.Tanggal, "2006-01-02"),
+		TanggalBerangkat: FormatTanggal(i.TanggalBerangkat, "2006-01-02"),
+		TanggalKembali:   FormatTanggal(i.TanggalKembali, "2006-01-02"),
+		Alias:            (*Alias)(i),
+	})
+}
+
+// peserta perjalanan dinas; golongan disalin dari user saat pengajuan
+type PerdinPeserta struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt *time.Time `gorm:"autoCreateTime" json:"created_at"`
+	PerdinID  uint       `gorm:"index;not null" json:"perdin_id"`
+	UserID    uint       `gorm:"index;not null" json:"user_id"`
+	Nama      string     `json:"nama"`
+	Golongan  string     `json:"golongan"`
+	// Peringatan terisi bila uang harian peserta tidak bisa dihitung (golongan/tarif belum ada)
+	Peringatan *string `json:"peringatan"`
+}
+
+// rincian estimasi biaya perdin per kategori
+type PerdinBiaya struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt   *time.Time `gorm:"autoCreateTime" json:"created_at"`
+	PerdinID    uint       `gorm:"index;not null" json:"perdin_id"`
+	PesertaID   *uint      `gorm:"index" json:"peserta_id"` // diisi untuk uang harian
+	Kategori    string     `json:"kategori"`                // Transport, Hotel, Uang Harian, Transport Lokal
+	Keterangan  *string    `json:"keterangan"`
+	Jumlah      int        `json:"jumlah"` // jumlah satuan, misal malam hotel atau hari
+	HargaSatuan Amount     `gorm:"type:numeric(18,2);not null" json:"harga_satuan"`
+	Total       Amount     `gorm:"type:numeric(18,2);not null" json:"total"`
+}
+
+// tarif uang harian per tujuan dan golongan; Tujuan "*" berlaku untuk tujuan yang tidak terdaftar
+type TarifUangHarian struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt  *time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  *time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	Tujuan     string     `gorm:"uniqueIndex:idx_tarif_uang_harian;not null" json:"tujuan"`
+	Golongan   string     `gorm:"uniqueIndex:idx_tarif_uang_harian;not null" json:"golongan"`
+	Tarif      Amount     `gorm:"type:numeric(18,2);not null" json:"tarif"`
+	Keterangan *string    `json:"keterangan"`
+	UpdateBy   string     `json:"update_by"`
 }
 
 // model for project
@@ -263,11 +305,14 @@ type Project struct {
 	DivInisiasi     *string    `json:"div_inisiasi"`
 	Bulan           *time.Time `json:"-"`
 	SumberPendanaan *string    `json:"sumber_pendanaan"`
-	Anggaran        *string    `json:"anggaran"`
+	Anggaran        *Amount    `gorm:"type:numeric(18,2)" json:"anggaran"`
+	MataUang        string     `gorm:"default:IDR" json:"mata_uang"`
 	NoIzin          *string    `json:"no_izin"`
 	TanggalIzin     *time.Time `json:"-"`
 	TanggalTor      *time.Time `json:"-"`
 	Pic             *string    `json:"pic"`
+	TahapSaatIni    *string    `json:"tahap_saat_ini"` // tahap pengadaan yang sedang berjalan
+	Diarsipkan      bool       `gorm:"default:false" json:"diarsipkan"`
 	CreateBy        string     `json:"create_by"`
 }
 
@@ -311,19 +356,41 @@ func (p *Project) MarshalJSON() ([]byte, error) {
 
 // model jadwal-rapat
 type Notification struct {
-	ID       uint      `gorm:"primaryKey" json:"id"`
-	Title    string    `json:"title"`
-	Start    time.Time `json:"start"`
-	Category string    `json:"category"`
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt *time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+	Title     string     `json:"title"`
+	Start     time.Time  `json:"start"`
+	Category  string     `json:"category"`
+	UserID    *uint      `gorm:"index" json:"user_id"` // penerima; UserID dan Role kosong berarti untuk semua pengguna
+	Role      *string    `gorm:"index" json:"role"`
+	Isi       *string    `gorm:"type:text" json:"isi"`                        // isi panjang, mis. ringkasan harian
+	Sumber    *string    `gorm:"index:idx_notification_sumber" json:"sumber"` // event asal notifikasi, dipakai untuk membatalkan pengingat
+	SumberID  *uint      `gorm:"index:idx_notification_sumber" json:"sumber_id"`
+	KunciUnik *string    `gorm:"uniqueIndex" json:"-"` // mencegah notifikasi terjadwal yang sama dibuat dua kali
+}
+
+// status baca/dismiss notifikasi per pengguna, baris dibuat saat pengguna pertama kali menandai notifikasi
+type StatusNotifikasi struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	NotificationID uint       `gorm:"uniqueIndex:idx_status_notifikasi" json:"notification_id"`
+	UserID         uint       `gorm:"uniqueIndex:idx_status_notifikasi" json:"user_id"`
+	DibacaPada     *time.Time `json:"dibaca_pada"`
+	DihapusPada    *time.Time `json:"dihapus_pada"`
 }
 
 type BookingRapat struct {
-	ID     uint   `gorm:"primaryKey" json:"id"`
-	Title  string `json:"title"`
-	Start  string `json:"start"`
-	End    string `json:"end"`
-	AllDay bool   `json:"allDay"`
-	Color  string `json:"color"` // Tambahkan field ini untuk warna
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	Title        string     `json:"title"`
+	Start        string     `json:"start"`
+	End          string     `json:"end"`
+	AllDay       bool       `json:"allDay"`
+	Color        string     `json:"color"` // Tambahkan field ini untuk warna
+	RuangRapatID *uint      `gorm:"index" json:"ruang_rapat_id"`
+	StartAt      *time.Time `gorm:"index" json:"-"` // hasil parse Start/End untuk cek bentrok
+	EndAt        *time.Time `gorm:"index" json:"-"`
+	CreateBy     string     `json:"create_by"`
+	RRule        string     `gorm:"column:rrule" json:"rrule"`        // aturan pengulangan RFC 5545, kosong berarti sekali
+	RecurrenceID string     `gorm:"-" json:"recurrence_id,omitempty"` // awal kejadian asli saat event diulang
 }
 
 func (BookingRapat) TableName() string {
@@ -331,12 +398,14 @@ func (BookingRapat) TableName() string {
 }
 
 type JadwalRapat struct {
-	ID     uint   `gorm:"primaryKey" json:"id"`
-	Title  string `json:"title"`
-	Start  string `json:"start"`
-	End    string `json:"end"`
-	AllDay bool   `json:"allDay"`
-	Color  string `json:"color"`
+	ID           uint   `gorm:"primaryKey" json:"id"`
+	Title        string `json:"title"`
+	Start        string `json:"start"`
+	End          string `json:"end"`
+	AllDay       bool   `json:"allDay"`
+	Color        string `json:"color"`
+	RRule        string `gorm:"column:rrule" json:"rrule"`        // aturan pengulangan RFC 5545, kosong berarti sekali
+	RecurrenceID string `gorm:"-" json:"recurrence_id,omitempty"` // awal kejadian asli saat event diulang
 }
 
 func (JadwalRapat) TableName() string {
@@ -359,6 +428,7 @@ type TimelineProject struct {
 	ResourceId int    `json:"resourceId"` // Ubah tipe data dari string ke int
 	Title      string `json:"title"`
 	BgColor    string `json:"bgColor"`
+	ProjectID  *uint  `gorm:"index" json:"project_id"` // diisi dari resource pemiliknya
 }
 
 func (TimelineProject) TableName() string {
@@ -366,9 +436,10 @@ func (TimelineProject) TableName() string {
 }
 
 type ResourceProject struct {
-	ID       uint   `gorm:"primaryKey" json:"id"`
-	Name     string `json:"name"`
-	ParentID uint   `json:"parent_id"`
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	Name      string `json:"name"`
+	ParentID  uint   `json:"parent_id"`
+	ProjectID *uint  `gorm:"index" json:"project_id"`
 }
 
 type TimelineDesktop struct {
@@ -399,6 +470,7 @@ type SuratMasuk struct {
 	Title      *string    `json:"title"`
 	RelatedDiv *string    `json:"related_div"`
 	DestinyDiv *string    `json:"destiny_div"`
+	JenisSurat *string    `json:"jenis_surat"`
 	Tanggal    *time.Time `json:"-"`
 	CreateBy   string     `json:"create_by"`
 }
@@ -428,15 +500,16 @@ func (i *SuratMasuk) MarshalJSON() ([]byte, error) {
 
 // model for suratKeluar
 type SuratKeluar struct {
-	ID        uint       `gorm:"primaryKey"`
-	CreatedAt *time.Time `gorm:"autoCreateTime"`
-	UpdatedAt *time.Time `gorm:"autoUpdateTime"`
-	NoSurat   *string    `json:"no_surat"`
-	Title     *string    `json:"title"`
-	From      *string    `json:"from"`
-	Pic       *string    `json:"pic"`
-	Tanggal   *time.Time `json:"-"`
-	CreateBy  string     `json:"create_by"`
+	ID           uint       `gorm:"primaryKey"`
+	CreatedAt    *time.Time `gorm:"autoCreateTime"`
+	UpdatedAt    *time.Time `gorm:"autoUpdateTime"`
+	NoSurat      *string    `json:"no_surat"`
+	Title        *string    `json:"title"`
+	From         *string    `json:"from"`
+	Pic          *string    `json:"pic"`
+	Tanggal      *time.Time `json:"-"`
+	CreateBy     string     `json:"create_by"`
+	SuratMasukID *uint      `gorm:"index" json:"surat_masuk_id"` // surat masuk yang dibalas
 }
 
 func (i *SuratKeluar) MarshalJSON() ([]byte, error) {
@@ -459,8 +532,11 @@ type Arsip struct {
 	TanggalDokumen    *time.Time `json:"-"`
 	Perihal           *string    `json:"perihal"`
 	NoBox             *string    `json:"no_box"`
+	BoxID             *uint      `gorm:"index" json:"box_id"`
 	TanggalPenyerahan *time.Time `json:"-"`
 	Keterangan        *string    `json:"keterangan"`
+	StatusRetensi     string     `gorm:"default:Aktif" json:"status_retensi"` // Aktif, Inaktif, Permanen atau Musnah
+	PemusnahanID      *uint      `gorm:"index" json:"pemusnahan_id"`
 	CreateBy          string     `json:"create_by"`
 }
 
@@ -508,3 +584,665 @@ type File struct {
 func (File) TableName() string {
 	return "files"
 }
+
+// FormatTanggal memformat tanggal opsional dengan layout yang diberikan, kosong jika nil
+func FormatTanggal(t *time.Time, layout string) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(layout)
+}
+
+// model for disposisi surat masuk
+type Disposisi struct {
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt       *time.Time `gorm:"autoCreateTime"`
+	UpdatedAt       *time.Time `gorm:"autoUpdateTime"`
+	SuratMasukID    uint       `gorm:"index;not null" json:"surat_masuk_id"`
+	ParentID        *uint      `gorm:"index" json:"parent_id"` // disposisi asal jika hasil penerusan
+	Dari            string     `json:"dari"`
+	TujuanDiv       *string    `json:"tujuan_div"`
+	TujuanUserID    *uint      `json:"tujuan_user_id"`
+	Instruksi       *string    `json:"instruksi"`
+	BatasWaktu      *time.Time `json:"-"`
+	Status          string     `json:"status"`
+	TanggalDiterima *time.Time `json:"-"`
+	TindakLanjut    *string    `json:"tindak_lanjut"`
+	Laporan         *string    `json:"laporan"`
+	TanggalSelesai  *time.Time `json:"-"`
+	CreateBy        string     `json:"create_by"`
+}
+
+func (d *Disposisi) MarshalJSON() ([]byte, error) {
+	type Alias Disposisi
+	return json.Marshal(&struct {
+		BatasWaktu      string `json:"batas_waktu"`
+		TanggalDiterima string `json:"tanggal_diterima"`
+		TanggalSelesai  string `json:"tanggal_selesai"`
+		*Alias
+	}{
+		BatasWaktu:      FormatTanggal(d.BatasWaktu, "2006-01-02"),
+		TanggalDiterima: FormatTanggal(d.TanggalDiterima, "2006-01-02"),
+		TanggalSelesai:  FormatTanggal(d.TanggalSelesai, "2006-01-02"),
+		Alias:           (*Alias)(d),
+	})
+}
+
+// batas waktu balasan surat masuk per jenis surat
+type SlaSurat struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt  *time.Time `gorm:"autoCreateTime"`
+	UpdatedAt  *time.Time `gorm:"autoUpdateTime"`
+	JenisSurat string     `gorm:"uniqueIndex;not null" json:"jenis_surat"`
+	HariRespon int        `json:"hari_respon"`
+	Keterangan *string    `json:"keterangan"`
+}
+
+// model for box arsip fisik beserta lokasinya
+type BoxArsip struct {
+	gorm.Model
+	NoBox      string  `gorm:"uniqueIndex:idx_box_arsip_no_box,where:deleted_at IS NULL;not null" json:"no_box"` // unik di antara box yang belum dihapus
+	Gedung     *string `json:"gedung"`
+	Ruang      *string `json:"ruang"`
+	Rak        *string `json:"rak"`
+	Ambalan    *string `json:"ambalan"`
+	Kapasitas  int     `json:"kapasitas"` // 0 berarti tidak dibatasi
+	Status     string  `json:"status"`
+	Keterangan *string `json:"keterangan"`
+	CreateBy   string  `json:"create_by"`
+}
+
+// riwayat perpindahan arsip antar box
+type MutasiArsip struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt  *time.Time `gorm:"autoCreateTime" json:"created_at"`
+	ArsipID    uint       `gorm:"index;not null" json:"arsip_id"`
+	DariBoxID  *uint      `json:"dari_box_id"`
+	KeBoxID    *uint      `json:"ke_box_id"`
+	Keterangan *string    `json:"keterangan"`
+	CreateBy   string     `json:"create_by"`
+}
+
+// peminjaman arsip fisik, dari pengajuan sampai pengembalian
+type PeminjamanArsip struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt      *time.Time `gorm:"autoCreateTime"`
+	UpdatedAt      *time.Time `gorm:"autoUpdateTime"`
+	ArsipID        uint       `gorm:"index;not null" json:"arsip_id"`
+	Peminjam       string     `json:"peminjam"`
+	PeminjamUserID *uint      `json:"peminjam_user_id"`
+	Divisi         *string    `json:"divisi"`
+	Keperluan      *string    `json:"keperluan"`
+	BatasKembali   *time.Time `json:"-"`
+	Status         string     `gorm:"index" json:"status"`
+	DisetujuiOleh  *string    `json:"disetujui_oleh"`
+	TanggalPinjam  *time.Time `json:"-"`
+	DiserahkanOleh *string    `json:"diserahkan_oleh"`
+	KondisiPinjam  *string    `json:"kondisi_pinjam"`
+	TanggalKembali *time.Time `json:"-"`
+	DiterimaOleh   *string    `json:"diterima_oleh"`
+	KondisiKembali *string    `json:"kondisi_kembali"`
+	Catatan        *string    `json:"catatan"`
+}
+
+func (p *PeminjamanArsip) MarshalJSON() ([]byte, error) {
+	type Alias PeminjamanArsip
+	return json.Marshal(&struct {
+		BatasKembali   string `json:"batas_kembali"`
+		TanggalPinjam  string `json:"tanggal_pinjam"`
+		TanggalKembali string `json:"tanggal_kembali"`
+		*Alias
+	}{
+		BatasKembali:   FormatTanggal(p.BatasKembali, "2006-01-02"),
+		TanggalPinjam:  FormatTanggal(p.TanggalPinjam, "2006-01-02"),
+		TanggalKembali: FormatTanggal(p.TanggalKembali, "2006-01-02"),
+		Alias:          (*Alias)(p),
+	})
+}
+
+// jadwal retensi arsip (JRA) per jenis dokumen, masa retensi dalam tahun
+type RetensiArsip struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt      *time.Time `gorm:"autoCreateTime"`
+	UpdatedAt      *time.Time `gorm:"autoUpdateTime"`
+	JenisDokumen   string     `gorm:"uniqueIndex;not null" json:"jenis_dokumen"`
+	RetensiAktif   int        `json:"retensi_aktif"`
+	RetensiInaktif int        `json:"retensi_inaktif"`
+	Nasib          string     `json:"nasib"` // Musnah atau Permanen setelah masa inaktif
+	Keterangan     *string    `json:"keterangan"`
+}
+
+// usulan pemusnahan arsip, menghasilkan berita acara pemusnahan saat disetujui
+type PemusnahanArsip struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt     *time.Time `gorm:"autoCreateTime"`
+	UpdatedAt     *time.Time `gorm:"autoUpdateTime"`
+	Perihal       *string    `json:"perihal"`
+	Status        string     `json:"status"`
+	DiajukanOleh  string     `json:"diajukan_oleh"`
+	DisetujuiOleh *string    `json:"disetujui_oleh"`
+	TanggalMusnah *time.Time `json:"-"`
+	BeritaAcaraID *uint      `json:"berita_acara_id"`
+	Catatan       *string    `json:"catatan"`
+}
+
+func (p *PemusnahanArsip) MarshalJSON() ([]byte, error) {
+	type Alias PemusnahanArsip
+	return json.Marshal(&struct {
+		TanggalMusnah string `json:"tanggal_musnah"`
+		*Alias
+	}{
+		TanggalMusnah: FormatTanggal(p.TanggalMusnah, "2006-01-02"),
+		Alias:         (*Alias)(p),
+	})
+}
+
+// daftar arsip yang diusulkan dalam satu pemusnahan
+type PemusnahanArsipItem struct {
+	ID           uint `gorm:"primaryKey" json:"id"`
+	PemusnahanID uint `gorm:"index;not null" json:"pemusnahan_id"`
+	ArsipID      uint `gorm:"index;not null" json:"arsip_id"`
+}
+
+// Amount menyimpan nilai uang dalam satuan sen (dua desimal) agar penjumlahan tidak terkena pembulatan float
+type Amount int64
+
+// ParseAmount membaca nilai uang dari teks seperti "1500000", "1.500.000,50", "Rp 1,500,000.50" atau "2500000.-"
+func ParseAmount(s string) (Amount, error) {
+	raw := strings.TrimSpace(s)
+	clean := strings.NewReplacer("Rp.", "", "Rp", "", "IDR", "", " ", "", ".-", "", ",-", "").Replace(raw)
+	negatif := strings.HasPrefix(clean, "-")
+	clean = strings.TrimPrefix(clean, "-")
+	if clean == "" {
+		return 0, fmt.Errorf("nilai uang kosong")
+	}
+
+	// Pemisah terakhir dianggap desimal hanya jika diikuti 1-2 digit, selebihnya pemisah ribuan
+	bulat, desimal := clean, ""
+	if i := strings.LastIndexAny(clean, ".,"); i >= 0 && len(clean)-i-1 <= 2 {
+		bulat, desimal = clean[:i], clean[i+1:]
+	}
+	bulat = strings.NewReplacer(".", "", ",", "").Replace(bulat)
+	if bulat == "" {
+		bulat = "0"
+	}
+	for len(desimal) < 2 {
+		desimal += "0"
+	}
+
+	rupiah, err := strconv.ParseInt(bulat, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("nilai uang tidak valid: %q", raw)
+	}
+	sen, err := strconv.ParseInt(desimal, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("nilai uang tidak valid: %q", raw)
+	}
+
+	amount := Amount(rupiah*100 + sen)
+	if negatif {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+func (a Amount) String() string {
+	v := int64(a)
+	tanda := ""
+	if v < 0 {
+		tanda, v = "-", -v
+	}
+	return fmt.Sprintf("%s%d.%02d", tanda, v/100, v%100)
+}
+
+// Float64 dipakai untuk menulis nilai ke sel Excel
+func (a Amount) Float64() float64 {
+	return float64(a) / 100
+}
+
+// MarshalJSON mengirim nilai sebagai string desimal agar presisi tidak hilang di sisi client
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+// UnmarshalJSON menerima angka maupun string
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	text := strings.Trim(string(data), `"`)
+	if text == "" || text == "null" {
+		*a = 0
+		return nil
+	}
+	parsed, err := ParseAmount(text)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+func (a *Amount) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*a = 0
+		return nil
+	case int64:
+		*a = Amount(v * 100)
+		return nil
+	case float64:
+		*a = Amount(math.Round(v * 100))
+		return nil
+	case []byte:
+		return a.scanText(string(v))
+	case string:
+		return a.scanText(v)
+	}
+	return fmt.Errorf("tidak bisa membaca %T sebagai Amount", value)
+}
+
+func (a *Amount) scanText(text string) error {
+	// Nilai dari database selalu memakai titik sebagai desimal, mis. "1500000.50"
+	bulat, desimal, _ := strings.Cut(text, ".")
+	parsed, err := ParseAmount(bulat + "," + (desimal + "00")[:2])
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
+
+// anggaran project per sumber pendanaan
+type AnggaranProject struct {
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt       *time.Time `gorm:"autoCreateTime"`
+	UpdatedAt       *time.Time `gorm:"autoUpdateTime"`
+	ProjectID       uint       `gorm:"index;not null" json:"project_id"`
+	SumberPendanaan string     `json:"sumber_pendanaan"`
+	Tahun           int        `json:"tahun"`
+	Nilai           Amount     `gorm:"type:numeric(18,2);not null" json:"nilai"`
+	MataUang        string     `gorm:"default:IDR" json:"mata_uang"`
+	Keterangan      *string    `json:"keterangan"`
+	CreateBy        string     `json:"create_by"`
+}
+
+// realisasi / pembayaran atas anggaran project
+type RealisasiProject struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt  *time.Time `gorm:"autoCreateTime"`
+	UpdatedAt  *time.Time `gorm:"autoUpdateTime"`
+	ProjectID  uint       `gorm:"index;not null" json:"project_id"`
+	AnggaranID *uint      `gorm:"index" json:"anggaran_id"`
+	Tanggal    *time.Time `json:"-"`
+	Nilai      Amount     `gorm:"type:numeric(18,2);not null" json:"nilai"`
+	MataUang   string     `gorm:"default:IDR" json:"mata_uang"`
+	NoDokumen  *string    `json:"no_dokumen"` // no invoice / SPP / bukti bayar
+	Keterangan *string    `json:"keterangan"`
+	CreateBy   string     `json:"create_by"`
+}
+
+func (r *RealisasiProject) MarshalJSON() ([]byte, error) {
+	type Alias RealisasiProject
+	return json.Marshal(&struct {
+		Tanggal string `json:"tanggal"`
+		*Alias
+	}{
+		Tanggal: FormatTanggal(r.Tanggal, "2006-01-02"),
+		Alias:   (*Alias)(r),
+	})
+}
+
+// tahapan pengadaan yang dikonfigurasi per jenis pengadaan
+type TahapPengadaan struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt      *time.Time `gorm:"autoCreateTime"`
+	UpdatedAt      *time.Time `gorm:"autoUpdateTime"`
+	JenisPengadaan string     `gorm:"index;not null" json:"jenis_pengadaan"`
+	Urutan         int        `json:"urutan"`
+	Nama           string     `json:"nama"`
+	BatasHari      int        `json:"batas_hari"` // lama maksimal tahap sebelum dianggap tertahan, 0 = pakai default laporan
+	Keterangan     *string    `json:"keterangan"`
+}
+
+// tahap pengadaan milik satu project dengan tanggal rencana dan realisasinya
+type TahapProject struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt      *time.Time `gorm:"autoCreateTime"`
+	UpdatedAt      *time.Time `gorm:"autoUpdateTime"`
+	ProjectID      uint       `gorm:"index;not null" json:"project_id"`
+	Urutan         int        `json:"urutan"`
+	Nama           string     `json:"nama"`
+	BatasHari      int        `json:"batas_hari"`
+	RencanaMulai   *time.Time `json:"-"`
+	RencanaSelesai *time.Time `json:"-"`
+	MulaiAktual    *time.Time `json:"-"`
+	SelesaiAktual  *time.Time `json:"-"`
+	Catatan        *string    `json:"catatan"`
+	UpdateBy       string     `json:"update_by"`
+}
+
+func (t *TahapProject) MarshalJSON() ([]byte, error) {
+	type Alias TahapProject
+	return json.Marshal(&struct {
+		RencanaMulai   string `json:"rencana_mulai"`
+		RencanaSelesai string `json:"rencana_selesai"`
+		MulaiAktual    string `json:"mulai_aktual"`
+		SelesaiAktual  string `json:"selesai_aktual"`
+		Status         string `json:"status"`
+		*Alias
+	}{
+		RencanaMulai:   FormatTanggal(t.RencanaMulai, "2006-01-02"),
+		RencanaSelesai: FormatTanggal(t.RencanaSelesai, "2006-01-02"),
+		MulaiAktual:    FormatTanggal(t.MulaiAktual, "2006-01-02"),
+		SelesaiAktual:  FormatTanggal(t.SelesaiAktual, "2006-01-02"),
+		Status:         t.Status(),
+		Alias:          (*Alias)(t),
+	})
+}
+
+// Status diturunkan dari tanggal aktual: Belum, Berjalan atau Selesai
+func (t *TahapProject) Status() string {
+	switch {
+	case t.SelesaiAktual != nil:
+		return "Selesai"
+	case t.MulaiAktual != nil:
+		return "Berjalan"
+	}
+	return "Belum"
+}
+
+// model for ruang rapat yang bisa dibooking
+type RuangRapat struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt  *time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  *time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	Nama       string     `gorm:"uniqueIndex;not null" json:"nama"`
+	Lokasi     *string    `json:"lokasi"`
+	Kapasitas  int        `json:"kapasitas"`
+	Fasilitas  *string    `json:"fasilitas"` // dipisah koma, misal "Proyektor, TV, Video Conference"
+	Aktif      bool       `gorm:"default:true" json:"aktif"`
+	Keterangan *string    `json:"keterangan"`
+	CreateBy   string     `json:"create_by"`
+}
+
+// pengecualian satu kejadian dari event berulang: dilewati atau dipindah waktunya
+type PengecualianJadwal struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt   *time.Time `gorm:"autoCreateTime" json:"created_at"`
+	Sumber      string     `gorm:"uniqueIndex:idx_pengecualian_kejadian;not null" json:"sumber"` // JadwalRapat atau BookingRapat
+	EventID     uint       `gorm:"uniqueIndex:idx_pengecualian_kejadian;not null" json:"event_id"`
+	TanggalAsli time.Time  `gorm:"uniqueIndex:idx_pengecualian_kejadian;not null" json:"tanggal_asli"`
+	Batal       bool       `json:"batal"`
+	Start       string     `json:"start"` // waktu baru bila dipindah, format sama dengan event
+	End         string     `json:"end"`
+	Title       *string    `json:"title"`
+	CreateBy    string     `json:"create_by"`
+}
+
+// token feed iCalendar per user; dipakai di URL karena aplikasi kalender tidak membawa cookie login
+type FeedKalender struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt     *time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UserID        uint       `gorm:"uniqueIndex;not null" json:"user_id"`
+	Token         string     `gorm:"uniqueIndex;not null" json:"-"`
+	TerakhirAkses *time.Time `json:"terakhir_akses"`
+}
+
+// pemetaan UID VEVENT hasil import .ics ke event yang dibuat, supaya import ulang tidak menggandakan
+type ImportKalender struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt *time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt *time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	Sumber    string     `gorm:"uniqueIndex:idx_import_kalender_uid;not null" json:"sumber"`
+	UID       string     `gorm:"uniqueIndex:idx_import_kalender_uid;not null" json:"uid"`
+	EventID   uint       `gorm:"index" json:"event_id"`
+	CreateBy  string     `json:"create_by"`
+}
+
+// model for jenis cuti beserta kuota tahunan bawaannya
+type JenisCuti struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt    *time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    *time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	Kode         string     `gorm:"uniqueIndex;not null" json:"kode"`
+	Nama         string     `json:"nama"`
+	KuotaTahunan int        `json:"kuota_tahunan"` // 0 berarti tidak memotong kuota, misal cuti sakit
+	Color        string     `json:"color"`         // warna event di kalender cuti
+	Aktif        bool       `gorm:"default:true" json:"aktif"`
+}
+
+// kuota cuti per user per tahun, menimpa KuotaTahunan jenis cuti
+type KuotaCuti struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt   *time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   *time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	UserID      uint       `gorm:"uniqueIndex:idx_kuota_cuti;not null" json:"user_id"`
+	JenisCutiID uint       `gorm:"uniqueIndex:idx_kuota_cuti;not null" json:"jenis_cuti_id"`
+	Tahun       int        `gorm:"uniqueIndex:idx_kuota_cuti;not null" json:"tahun"`
+	Kuota       int        `json:"kuota"`
+	Keterangan  *string    `json:"keterangan"`
+	UpdateBy    string     `json:"update_by"`
+}
+
+// model for pengajuan cuti pegawai
+type PengajuanCuti struct {
+	ID               uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt        *time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt        *time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	UserID           uint       `gorm:"index;not null" json:"user_id"`
+	Pemohon          string     `json:"pemohon"`
+	JenisCutiID      uint       `gorm:"index;not null" json:"jenis_cuti_id"`
+	TanggalMulai     *time.Time `json:"-"`
+	TanggalSelesai   *time.Time `json:"-"`
+	JumlahHari       int        `json:"jumlah_hari"` // hari kerja saja
+	Alasan           *string    `json:"alasan"`
+	Status           string     `gorm:"index" json:"status"`
+	AtasanID         *uint      `json:"atasan_id"`
+	DiputuskanOleh   *string    `json:"diputuskan_oleh"`
+	TanggalKeputusan *time.Time `json:"-"`
+	CatatanAtasan    *string    `json:"catatan_atasan"`
+	JadwalCutiID     *uint      `json:"jadwal_cuti_id"` // event kalender cuti setelah disetujui
+}
+
+func (p *PengajuanCuti) MarshalJSON() ([]byte, error) {
+	type Alias PengajuanCuti
+	return json.Marshal(&struct {
+		TanggalMulai     string `json:"tanggal_mulai"`
+		TanggalSelesai   string `json:"tanggal_selesai"`
+		TanggalKeputusan string `json:"tanggal_keputusan"`
+		*Alias
+	}{
+		TanggalMulai:     FormatTanggal(p.TanggalMulai, "2006-01-02"),
+		TanggalSelesai:   FormatTanggal(p.TanggalSelesai, "2006-01-02"),
+		TanggalKeputusan: FormatTanggal(p.TanggalKeputusan, "2006-01-02"),
+		Alias:            (*Alias)(p),
+	})
+}
+
+// model for hari libur nasional dan cuti bersama, dipakai untuk menghitung hari kerja
+type HariLibur struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt *time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt *time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	Tanggal   *time.Time `gorm:"uniqueIndex;not null" json:"-"`
+	Nama      string     `json:"nama"`
+	Jenis     string     `json:"jenis"` // Libur Nasional atau Cuti Bersama
+	CreateBy  string     `json:"create_by"`
+}
+
+func (h *HariLibur) MarshalJSON() ([]byte, error) {
+	type Alias HariLibur
+	return json.Marshal(&struct {
+		Tanggal string `json:"tanggal"`
+		*Alias
+	}{
+		Tanggal: FormatTanggal(h.Tanggal, "2006-01-02"),
+		Alias:   (*Alias)(h),
+	})
+}
+
+// penyelesaian (pertanggungjawaban) biaya perdin terhadap uang muka
+type PenyelesaianPerdin struct {
+	ID               uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt        *time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt        *time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	PerdinID         uint       `gorm:"uniqueIndex;not null" json:"perdin_id"`
+	UangMuka         Amount     `gorm:"type:numeric(18,2);not null" json:"uang_muka"`
+	TotalEstimasi    Amount     `gorm:"type:numeric(18,2);not null" json:"total_estimasi"`
+	TotalRealisasi   Amount     `gorm:"type:numeric(18,2);not null" json:"total_realisasi"`
+	SelisihEstimasi  Amount     `gorm:"type:numeric(18,2);not null" json:"selisih_estimasi"`  // realisasi - estimasi
+	SelisihUangMuka  Amount     `gorm:"type:numeric(18,2);not null" json:"selisih_uang_muka"` // positif kurang bayar, negatif lebih bayar
+	Status           string     `gorm:"index" json:"status"`
+	Catatan          *string    `json:"catatan"`
+	DiajukanOleh     *string    `json:"diajukan_oleh"`
+	PengajuID        *uint      `gorm:"index" json:"pengaju_id"`
+	AtasanID         *uint      `gorm:"index" json:"atasan_id"` // disalin dari atasan pengaju saat diajukan
+	TanggalPengajuan *time.Time `json:"-"`
+	DiputuskanOleh   *string    `json:"diputuskan_oleh"`
+	TanggalKeputusan *time.Time `json:"-"`
+	CatatanKeputusan *string    `json:"catatan_keputusan"`
+	CreateBy         string     `json:"create_by"`
+}
+
+func (p *PenyelesaianPerdin) MarshalJSON() ([]byte, error) {
+	type Alias PenyelesaianPerdin
+	return json.Marshal(&struct {
+		TanggalPengajuan string `json:"tanggal_pengajuan"`
+		TanggalKeputusan string `json:"tanggal_keputusan"`
+		*Alias
+	}{
+		TanggalPengajuan: FormatTanggal(p.TanggalPengajuan, "2006-01-02"),
+		TanggalKeputusan: FormatTanggal(p.TanggalKeputusan, "2006-01-02"),
+		Alias:            (*Alias)(p),
+	})
+}
+
+// baris biaya aktual perdin, masing-masing merujuk bukti yang diunggah lewat uploadFilePerdin
+type RealisasiPerdin struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt      *time.Time `gorm:"autoCreateTime" json:"created_at"`
+	PenyelesaianID uint       `gorm:"index;not null" json:"penyelesaian_id"`
+	PerdinID       uint       `gorm:"index;not null" json:"perdin_id"`
+	Kategori       string     `json:"kategori"`
+	Keterangan     *string    `json:"keterangan"`
+	Tanggal        *time.Time `json:"-"`
+	Jumlah         int        `json:"jumlah"`
+	HargaSatuan    Amount     `gorm:"type:numeric(18,2);not null" json:"harga_satuan"`
+	Total          Amount     `gorm:"type:numeric(18,2);not null" json:"total"`
+	FileID         *uint      `gorm:"index" json:"file_id"`
+	NamaFile       string     `json:"nama_file"`
+}
+
+func (r *RealisasiPerdin) MarshalJSON() ([]byte, error) {
+	type Alias RealisasiPerdin
+	return json.Marshal(&struct {
+		Tanggal string `json:"tanggal"`
+		*Alias
+	}{
+		Tanggal: FormatTanggal(r.Tanggal, "2006-01-02"),
+		Alias:   (*Alias)(r),
+	})
+}
+
+// pengingat terjadwal untuk Notification, diproses worker agar tidak hilang saat server restart
+type PengingatNotifikasi struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt      *time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      *time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	NotificationID uint       `gorm:"uniqueIndex:idx_pengingat_notifikasi" json:"notification_id"`
+	Title          string     `json:"title"`
+	Category       string     `json:"category"`
+	UserID         *uint      `json:"user_id"`
+	Role           *string    `json:"role"`
+	WaktuEvent     time.Time  `json:"waktu_event"`
+	OffsetMenit    int        `gorm:"uniqueIndex:idx_pengingat_notifikasi" json:"offset_menit"` // menit sebelum event
+	JadwalKirim    time.Time  `gorm:"index:idx_pengingat_jadwal,priority:2" json:"jadwal_kirim"`
+	Status         string     `gorm:"index:idx_pengingat_jadwal,priority:1" json:"status"` // Menunggu, Diproses, Terkirim, Gagal, Dibatalkan
+	Percobaan      int        `json:"percobaan"`
+	MaksPercobaan  int        `json:"maks_percobaan"`
+	ErrorTerakhir  *string    `json:"error_terakhir"`
+	DikunciOleh    *string    `json:"dikunci_oleh"`
+	DikunciSampai  *time.Time `json:"dikunci_sampai"`
+	TerkirimPada   *time.Time `json:"terkirim_pada"`
+	KanalTerkirim  string     `json:"kanal_terkirim"` // kanal yang sudah berhasil, dipisah koma; dilewati saat dicoba ulang
+}
+
+// antrean email keluar untuk notifikasi, dikirim worker dengan retry
+type EmailKeluar struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt      *time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      *time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	KunciDedup     string     `gorm:"uniqueIndex" json:"kunci_dedup"` // mencegah email ganda saat pengingat dikirim ulang
+	NotificationID *uint      `gorm:"index" json:"notification_id"`
+	PengingatID    *uint      `gorm:"index" json:"pengingat_id"`
+	Category       string     `json:"category"`
+	Kepada         string     `json:"kepada"`
+	Subjek         string     `json:"subjek"`
+	IsiTeks        string     `gorm:"type:text" json:"isi_teks"`
+	IsiHTML        string     `gorm:"type:text" json:"isi_html"`
+	JadwalKirim    time.Time  `gorm:"index:idx_email_keluar_jadwal,priority:2" json:"jadwal_kirim"`
+	Status         string     `gorm:"index:idx_email_keluar_jadwal,priority:1" json:"status"` // Menunggu, Diproses, Terkirim, Gagal
+	Percobaan      int        `json:"percobaan"`
+	MaksPercobaan  int        `json:"maks_percobaan"`
+	ErrorTerakhir  *string    `json:"error_terakhir"`
+	DikunciOleh    *string    `json:"dikunci_oleh"`
+	DikunciSampai  *time.Time `json:"dikunci_sampai"`
+	TerkirimPada   *time.Time `json:"terkirim_pada"`
+}
+
+// endpoint webhook yang didaftarkan admin, Events berisi tipe event dipisah koma atau "*" untuk semua
+type Webhook struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt *time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt *time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	Nama      string     `json:"nama"`
+	URL       string     `json:"url"`
+	Secret    string     `json:"-"` // kunci HMAC tanda tangan payload
+	Events    string     `json:"events"`
+	Aktif     bool       `json:"aktif"`
+	UserID    *uint      `gorm:"index" json:"user_id"` // webhook pribadi penerima notifikasi; kosong berarti webhook admin
+	CreateBy  string     `json:"create_by"`
+}
+
+// log pengiriman webhook, satu baris per event per endpoint
+type PengirimanWebhook struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt     *time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     *time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	WebhookID     uint       `gorm:"index" json:"webhook_id"`
+	EventID       string     `gorm:"index" json:"event_id"`
+	Event         string     `gorm:"index" json:"event"`
+	Payload       string     `gorm:"type:text" json:"payload"`
+	JadwalKirim   time.Time  `gorm:"index:idx_pengiriman_webhook_jadwal,priority:2" json:"jadwal_kirim"`
+	Status        string     `gorm:"index:idx_pengiriman_webhook_jadwal,priority:1" json:"status"` // Menunggu, Diproses, Terkirim, Gagal
+	Percobaan     int        `json:"percobaan"`
+	MaksPercobaan int        `json:"maks_percobaan"`
+	StatusHTTP    *int       `json:"status_http"`
+	Respon        *string    `json:"respon"`
+	ErrorTerakhir *string    `json:"error_terakhir"`
+	DikunciOleh   *string    `json:"dikunci_oleh"`
+	DikunciSampai *time.Time `json:"dikunci_sampai"`
+	TerkirimPada  *time.Time `json:"terkirim_pada"`
+}
+
+// preferensi notifikasi per pengguna, kategori ("*" untuk semua kategori) dan kanal
+type PreferensiNotifikasi struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UpdatedAt *time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	UserID    uint       `gorm:"uniqueIndex:idx_preferensi_notifikasi" json:"user_id"`
+	Category  string     `gorm:"uniqueIndex:idx_preferensi_notifikasi" json:"category"`
+	Kanal     string     `gorm:"uniqueIndex:idx_preferensi_notifikasi" json:"kanal"` // inapp, email, webhook
+	Mode      string     `json:"mode"`                                               // langsung, ringkasan, senyap
+}
+
+// penanda ringkasan harian yang sudah dikirim, satu per pengguna per tanggal
+type RingkasanHarian struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt      *time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UserID         uint       `gorm:"uniqueIndex:idx_ringkasan_harian" json:"user_id"`
+	Tanggal        string     `gorm:"uniqueIndex:idx_ringkasan_harian" json:"tanggal"`
+	Jumlah         int        `json:"jumlah"`
+	NotificationID *uint      `json:"notification_id"`
+}
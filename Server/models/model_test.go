@@ -0,0 +1,65 @@
+package models
+
+import "testing"
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Amount
+		wantErr bool
+	}{
+		{"1500000", 150000000, false},
+		{"1.500.000", 150000000, false},
+		{"1.500.000,50", 150000050, false},
+		{"Rp 1,500,000.50", 150000050, false},
+		{"Rp. 2.500.000,-", 250000000, false},
+		{"2500000.-", 250000000, false},
+		{"IDR 750", 75000, false},
+		{"1,5", 150, false},
+		{"0,05", 5, false},
+		{"1.500", 150000, false},
+		{",75", 75, false},
+		{"-1.000,25", -100025, false},
+		{"  42  ", 4200, false},
+		{"", 0, true},
+		{"Rp", 0, true},
+		{"-", 0, true},
+		{"abc", 0, true},
+		{"12a,50", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseAmount(tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseAmount(%q) = %v, ingin error", tt.input, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseAmount(%q) error: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseAmount(%q) = %d, ingin %d", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestAmountString(t *testing.T) {
+	tests := []struct {
+		amount Amount
+		want   string
+	}{
+		{0, "0.00"},
+		{5, "0.05"},
+		{150000050, "1500000.50"},
+		{-100025, "-1000.25"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.amount.String(); got != tt.want {
+			t.Errorf("Amount(%d).String() = %q, ingin %q", tt.amount, got, tt.want)
+		}
+	}
+}